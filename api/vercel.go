@@ -1,55 +1,60 @@
 package api
 
 import (
+	"be-car-zone/app"
 	"be-car-zone/app/config"
-	"be-car-zone/docs"
-	"be-car-zone/app/routes"
-	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/pkg/logging"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"gorm.io/gorm"
 )
 
 var (
-	App *gin.Engine
-	DB  *gorm.DB
+	engine    *gin.Engine
+	setupOnce sync.Once
 )
 
-func init() {
+// setup builds the application on the first request of a cold start, importing the package
+// does not connect to anything.
+func setup() {
 	gin.SetMode(gin.ReleaseMode)
-	App = gin.New()
 
-	// Load environment variables
-	environment := utils.Getenv("ENVIRONMENT", "development")
-
-	if environment == "development" {
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatal("Error loading .env file in main")
-		}
+	// The settings come from the project's environment variables
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
-
-	// Set Swagger info
-	docs.SwaggerInfo.Title = "Car Zone API"
-	docs.SwaggerInfo.Description = "This is a sample server Car Zone."
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = utils.Getenv("HOST", "localhost:8080")
-	if environment == "development" {
-		docs.SwaggerInfo.Schemes = []string{"http", "https"}
-	} else {
-		docs.SwaggerInfo.Schemes = []string{"https"}
+	// A cold start must not migrate or seed, every instance would race to. Deploys run
+	// "carzone migrate up" before traffic reaches the new functions
+	if cfg.Database.MigrationMode != config.MigrationOff {
+		cfg.Database.MigrationMode = config.MigrationCheck
+	}
+	// Every request comes from the Vercel proxy, which sets X-Real-IP to the client's address
+	if cfg.App.ClientIPHeader == "" && cfg.App.TrustedProxies == "" {
+		cfg.App.ClientIPHeader = "X-Real-IP"
 	}
 
-	// // Connect to the database
-	DB = config.ConnectDataBase()
-
-	// Setup routes
-	routes.SetupRouter(DB, App)
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	// The function instance lives as long as the process, there is nothing to clean up. Spans
+	// are exported in batches, a low OTEL_BSP_SCHEDULE_DELAY keeps a frozen instance from
+	// holding them
+	engine, _, err = app.New(app.Config{Settings: cfg, Logger: logger})
+	if err != nil {
+		logger.Error("Cannot start the application", "error", err)
+		os.Exit(1)
+	}
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
-	App.ServeHTTP(w, r)
+	setupOnce.Do(setup)
+	engine.ServeHTTP(w, r)
 }
@@ -0,0 +1,140 @@
+// Package app assembles the Car Zone API from its dependencies.
+package app
+
+import (
+	"be-car-zone/app/config"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/mailer"
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/pkg/tracing"
+	"be-car-zone/app/routes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Config holds the dependencies of the application. Settings is the loaded configuration, see
+// config.Load. Empty fields get their production default: the database from
+// config.ConnectDataBase, time.Now, the storage, mailer and logger of Settings and new metrics. cmd/server passes its own Metrics to serve
+// them on the admin address as well.
+type Config struct {
+	Settings *config.Config
+	DB       *gorm.DB
+	Now      func() time.Time
+	Storage  storage.Storage
+	Mailer   mailer.Mailer
+	Logger   *slog.Logger
+	Metrics  *metrics.Metrics
+}
+
+// New builds the gin engine with every route. The cleanup function flushes the traces and
+// releases what New opened itself, a database passed in through cfg stays open.
+func New(cfg Config) (*gin.Engine, func(), error) {
+	cleanup := func() {}
+
+	if cfg.Settings == nil {
+		return nil, cleanup, errors.New("app: the configuration is required")
+	}
+	settings := cfg.Settings
+	jwt.Configure(settings.Auth.APISecret, settings.Auth.TokenLifespan())
+
+	if cfg.Logger == nil {
+		logger, err := logging.New(os.Stdout, settings.Log.Level, settings.Log.Format)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		cfg.Logger = logger
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Storage == nil {
+		cfg.Storage = storage.NewLocalStorage(settings.Storage.Dir, settings.Storage.BaseURL)
+	}
+	if cfg.Mailer == nil {
+		smtp := settings.SMTP
+		cfg.Mailer = mailer.New(smtp.Host, smtp.Port, smtp.Username, smtp.Password, smtp.From)
+	}
+	// The encrypted columns of every model are sealed with the default keyring, the KYC scans
+	// with the same one
+	ring, err := settings.Keyring()
+	if err != nil {
+		return nil, cleanup, err
+	}
+	encryption.SetDefault(ring)
+	legacyKYCCipher, err := settings.LegacyKYCCipher()
+	if err != nil {
+		return nil, cleanup, err
+	}
+	if cfg.DB == nil {
+		cfg.DB = config.ConnectDataBase(settings)
+		cleanup = func() {
+			if sqlDB, err := cfg.DB.DB(); err == nil {
+				sqlDB.Close()
+			}
+		}
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = metrics.New()
+	}
+	sqlDB, err := cfg.DB.DB()
+	if err != nil {
+		return nil, cleanup, err
+	}
+	if err := cfg.Metrics.RegisterDB(sqlDB, settings.Database.Provider); err != nil {
+		return nil, cleanup, err
+	}
+
+	// The tracer provider is global, see tracing.Setup
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:      settings.Tracing.Exporter,
+		Endpoint:      settings.Tracing.Endpoint,
+		ServiceName:   settings.Tracing.ServiceName,
+		SamplePercent: settings.Tracing.SamplePercent,
+	})
+	if err != nil {
+		return nil, cleanup, err
+	}
+	closeDB := cleanup
+	cleanup = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			cfg.Logger.Error("Flush traces", "error", err)
+		}
+		closeDB()
+	}
+
+	// Timestamps GORM fills in follow the same clock as the handlers
+	db := cfg.DB.Session(&gorm.Session{NowFunc: cfg.Now})
+
+	routes.ConfigureSwagger(settings.Environment, settings.App.Host)
+
+	engine := gin.New()
+	routes.SetupRouter(engine, routes.Dependencies{
+		DB:              db,
+		Now:             cfg.Now,
+		Storage:         cfg.Storage,
+		Mailer:          cfg.Mailer,
+		Keyring:         ring,
+		LegacyKYCCipher: legacyKYCCipher,
+		Logger:          cfg.Logger,
+		Metrics:         cfg.Metrics,
+		MetricsToken:    settings.Metrics.Token,
+		App:             settings.App,
+		RateLimit:       settings.RateLimit,
+		OIDC:            settings.OIDC,
+		Trash:           settings.Trash,
+		CronSecret:      settings.Cron.Secret,
+	})
+	return engine, cleanup, nil
+}
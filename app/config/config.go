@@ -0,0 +1,591 @@
+package config
+
+import (
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/oidc"
+	"be-car-zone/app/pkg/ratelimit"
+	"be-car-zone/app/pkg/tracing"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Providers for DB_PROVIDER. SQLite needs no server and suits local development and tests,
+// DB_NAME is the database file or ":memory:".
+const (
+	ProviderMySQL    = "mysql"
+	ProviderPostgres = "postgres"
+	ProviderSQLite   = "sqlite"
+)
+
+// InMemory is the DB_NAME of an SQLite database that lives as long as the process.
+const InMemory = ":memory:"
+
+// TLS modes for DB_TLS_MODE. An empty mode keeps the provider default, require for Postgres and
+// disable for MySQL.
+const (
+	TLSDisable    = "disable"
+	TLSRequire    = "require"
+	TLSVerifyFull = "verify-full"
+)
+
+// Config is every setting the application reads at startup. A field is filled from, in order of
+// precedence, its command line flag, its environment variable, the config file and its default.
+// The flag of DB_HOST is -db-host, a second name in the env tag is a deprecated alias.
+type Config struct {
+	Environment string `env:"ENVIRONMENT" default:"production" usage:"development, staging or production"`
+
+	Server     Server
+	App        App
+	Database   Database
+	Auth       Auth
+	Encryption Encryption
+	RateLimit  RateLimit
+	OIDC       OIDC
+	Storage    Storage
+	SMTP       SMTP
+	Cron       Cron
+	Trash      Trash
+	Seed       Seed
+	Log        Log
+	Metrics    Metrics
+	Tracing    Tracing
+}
+
+// Server configures the HTTP server of cmd/server.
+type Server struct {
+	Addr              string        `env:"SERVER_ADDR" default:":8080" usage:"address to listen on"`
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" default:"30s"`
+	WriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT" default:"60s"`
+	IdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	ShutdownTimeout   time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s" usage:"how long to wait for in-flight requests on shutdown"`
+}
+
+// App describes the deployment to the outside world.
+type App struct {
+	URL  string `env:"APP_URL" default:"http://localhost:3000" usage:"URL of the web app, the links in emails point at it"`
+	Host string `env:"HOST" default:"localhost:8080" usage:"public host:port of the API, for the Swagger docs and the mock identity provider"`
+	// The client IP keys the rate limits and is recorded in the audit logs, headers a client can
+	// forge are only trusted when they come from the proxies in front of the API
+	TrustedProxies string `env:"TRUSTED_PROXIES" usage:"comma separated IPs or CIDRs of the reverse proxies in front of the API, the client IP is read from their X-Forwarded-For; empty uses the connection address"`
+	ClientIPHeader string `env:"CLIENT_IP_HEADER" usage:"header the hosting platform sets to the client IP, e.g. CF-Connecting-IP, used before TRUSTED_PROXIES"`
+}
+
+// Database configures the connection and its pool. An empty Port uses the provider default.
+type Database struct {
+	Provider      string `env:"DB_PROVIDER" default:"mysql" usage:"mysql, postgres or sqlite"`
+	Host          string `env:"DB_HOST" default:"127.0.0.1"`
+	Port          string `env:"DB_PORT"`
+	Name          string `env:"DB_NAME,DB_DATABASE" usage:"database name, for sqlite the file or :memory:"`
+	Username      string `env:"DB_USERNAME"`
+	Password      string `env:"DB_PASSWORD" secret:"true"`
+	TLSMode       string `env:"DB_TLS_MODE" usage:"disable, require or verify-full, empty for the provider default"`
+	MigrationMode string `env:"DB_MIGRATION_MODE" usage:"check, auto or off, empty for auto when ENVIRONMENT is development and check otherwise"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25" usage:"0 for unlimited"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"5"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m" usage:"0 to keep connections forever"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m" usage:"0 to keep idle connections forever"`
+}
+
+// Auth configures the access tokens.
+type Auth struct {
+	APISecret         string `env:"API_SECRET" secret:"true" usage:"key that signs access tokens"`
+	TokenHourLifespan int    `env:"TOKEN_HOUR_LIFESPAN" default:"1" usage:"lifetime of access tokens in hours"`
+}
+
+// Encryption configures the keys of the personal data at rest, see Keyring. They are required
+// outside of development, a development setup derives the missing ones from API_SECRET.
+type Encryption struct {
+	FieldKeys     string `env:"FIELD_ENCRYPTION_KEYS" secret:"true" usage:"comma separated id:key pairs of the encrypted columns, the primary key first; keys are 32 bytes as base64 or hex"`
+	BlindIndexKey string `env:"BLIND_INDEX_KEY" secret:"true" usage:"32 byte key of the blind indexes of encrypted columns, it cannot be rotated"`
+	KYCKey        string `env:"KYC_ENCRYPTION_KEY" secret:"true" usage:"32 byte key of KYC document files sealed before they used FIELD_ENCRYPTION_KEYS, unset once reencrypt rewrote them"`
+}
+
+// RateLimit configures the token buckets of the route groups, see ratelimit.ParseLimit.
+type RateLimit struct {
+	Store   string          `env:"RATE_LIMIT_STORE" default:"memory" usage:"memory (per instance) or sql (shared by the instances)"`
+	Global  ratelimit.Limit `env:"RATE_LIMIT_GLOBAL" default:"600/m" usage:"every request by client IP, e.g. 600/m or 600/m:100 with a burst"`
+	Auth    ratelimit.Limit `env:"RATE_LIMIT_AUTH" default:"10/m" usage:"login and registration by client IP"`
+	Public  ratelimit.Limit `env:"RATE_LIMIT_PUBLIC" default:"120/m" usage:"public catalog by client IP"`
+	CMS     ratelimit.Limit `env:"RATE_LIMIT_CMS" default:"300/m" usage:"signed in requests by user"`
+	Partner ratelimit.Limit `env:"RATE_LIMIT_PARTNER" default:"600/m" usage:"partner API by API key"`
+}
+
+// OIDC configures social login. Each provider of Providers is configured with the variables
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES, Load reads them
+// into Clients.
+type OIDC struct {
+	Providers       string `env:"OIDC_PROVIDERS" usage:"comma separated names of the identity providers, e.g. google"`
+	SuccessRedirect string `env:"OIDC_SUCCESS_REDIRECT" usage:"frontend URL receiving the token in its fragment, empty to answer with JSON"`
+	MockEnabled     bool   `env:"OIDC_MOCK_ENABLED" usage:"serve a mock identity provider at /mock-idp, only in development or test"`
+	MockBaseURL     string `env:"OIDC_MOCK_BASE_URL" usage:"URL the API is reached at by browsers, empty for http://HOST"`
+
+	Clients []oidc.Config
+}
+
+// Storage configures where uploads are kept, see storage.LocalStorage.
+type Storage struct {
+	Dir     string `env:"STORAGE_DIR" default:"uploads"`
+	BaseURL string `env:"STORAGE_BASE_URL" default:"/uploads"`
+}
+
+// SMTP configures outgoing mail. Mail is only logged while Host is empty.
+type SMTP struct {
+	Host     string `env:"SMTP_HOST"`
+	Port     string `env:"SMTP_PORT" default:"587"`
+	Username string `env:"SMTP_USERNAME"`
+	Password string `env:"SMTP_PASSWORD" secret:"true"`
+	From     string `env:"SMTP_FROM" default:"Car Zone <no-reply@carzone.local>"`
+}
+
+// Cron configures the scheduled jobs, they answer 503 while Secret is empty.
+type Cron struct {
+	Secret string `env:"CRON_SECRET" secret:"true" usage:"bearer token the scheduler calls the jobs with"`
+}
+
+// Trash configures how long soft deleted rows are kept before the purge job deletes them.
+type Trash struct {
+	RetentionDays int `env:"TRASH_RETENTION_DAYS" default:"30"`
+	// Indonesian tax law requires bookkeeping records to be kept for ten years
+	FinancialRetentionDays int `env:"TRASH_FINANCIAL_RETENTION_DAYS" default:"3650" usage:"retention of orders, transactions and invoices"`
+}
+
+// Seed configures "carzone seed", the admin is skipped while AdminUsername is empty.
+type Seed struct {
+	AdminUsername string `env:"ADMIN_USERNAME" usage:"username of the initial admin"`
+	AdminEmail    string `env:"ADMIN_EMAIL" usage:"email of the initial admin"`
+	AdminPassword string `env:"ADMIN_PASSWORD" secret:"true" usage:"password of the initial admin, the admin is not seeded without it"`
+	Demo          bool   `env:"SEED_DEMO" usage:"also create sample brands, types and cars"`
+}
+
+// Log configures the logs of the application, see logging.New.
+type Log struct {
+	Level  string `env:"LOG_LEVEL" default:"info" usage:"debug, info, warn or error"`
+	Format string `env:"LOG_FORMAT" default:"json" usage:"json, or text for reading in a terminal"`
+}
+
+// Metrics configures the Prometheus endpoint. /metrics is served on Addr, an admin port only
+// cmd/server listens on, and on the API with Token as bearer token; each is off while empty.
+type Metrics struct {
+	Addr  string `env:"METRICS_ADDR" usage:"admin address serving /metrics, e.g. 127.0.0.1:9090"`
+	Token string `env:"METRICS_TOKEN" secret:"true" usage:"bearer token of /metrics on the API address"`
+}
+
+// Tracing configures the OpenTelemetry traces, see tracing.Setup.
+type Tracing struct {
+	Exporter      string `env:"TRACING_EXPORTER" default:"none" usage:"none, stdout or otlp"`
+	Endpoint      string `env:"TRACING_ENDPOINT" usage:"OTLP/HTTP collector URL, e.g. http://localhost:4318, empty for the OTEL_EXPORTER_OTLP_ variables"`
+	ServiceName   string `env:"TRACING_SERVICE_NAME" default:"car-zone-api"`
+	SamplePercent int    `env:"TRACING_SAMPLE_PERCENT" default:"100" usage:"share of new traces that are recorded, 0 to 100"`
+}
+
+// Default returns the configuration made of the defaults alone, without reading the environment.
+// It is not validated, tests fill in what they need.
+func Default() *Config {
+	cfg := &Config{}
+	for _, field := range fields(cfg) {
+		if field.def != "" {
+			// The defaults are constants, a bad one fails every test
+			if err := field.set(field.def); err != nil {
+				panic(fmt.Sprintf("config: default of %s: %v", field.env[0], err))
+			}
+		}
+	}
+	return cfg
+}
+
+// Load reads the configuration from args, the environment and the config file, then validates it.
+// The file is the -config flag, else CONFIG_FILE, else an optional .env in the working directory.
+// Its variables are exported to the environment without overriding it, for the packages that
+// still read their settings from there. All problems are reported together.
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := fields(cfg)
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "")
+	values := map[string]*string{}
+	for _, field := range fields {
+		values[field.env[0]] = flags.String(field.flag(), "", field.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if err := loadFile(*configFile); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, field := range fields {
+		value, ok := "", false
+		if set[field.flag()] {
+			value, ok = *values[field.env[0]], true
+		}
+		for _, name := range field.env {
+			if ok {
+				break
+			}
+			value, ok = os.LookupEnv(name)
+		}
+		if !ok {
+			value = field.def
+		}
+		if err := field.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field.env[0], err))
+		}
+	}
+	cfg.OIDC.Clients = oidcClients(cfg.OIDC.Providers)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Usage describes the flags Load accepts, for the help of the commands.
+func Usage() string {
+	var b strings.Builder
+	b.WriteString("  -config file\n\tread variables from file (CONFIG_FILE), .env by default\n")
+	for _, field := range fields(&Config{}) {
+		fmt.Fprintf(&b, "  -%s %s\n\t", field.flag(), field.kind())
+		if field.usage != "" {
+			b.WriteString(field.usage + " ")
+		}
+		fmt.Fprintf(&b, "(%s)", field.env[0])
+		if field.def != "" {
+			fmt.Fprintf(&b, " (default %q)", field.def)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Validate reports every missing or invalid setting.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Environment != "", "ENVIRONMENT is required")
+
+	check(c.Server.Addr != "", "SERVER_ADDR is required")
+	check(c.Server.ReadHeaderTimeout > 0, "SERVER_READ_HEADER_TIMEOUT must be positive")
+	check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT must be positive")
+	check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT must be positive")
+	check(c.Server.IdleTimeout > 0, "SERVER_IDLE_TIMEOUT must be positive")
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT must be positive")
+
+	db := c.Database
+	switch db.Provider {
+	case ProviderMySQL, ProviderPostgres:
+		check(db.Host != "", "DB_HOST is required")
+		check(db.Username != "", "DB_USERNAME is required")
+	case ProviderSQLite:
+	default:
+		check(false, "DB_PROVIDER must be %s, %s or %s, got %q", ProviderMySQL, ProviderPostgres, ProviderSQLite, db.Provider)
+	}
+	check(db.Name != "", "DB_NAME is required")
+	if db.Port != "" {
+		_, err := strconv.ParseUint(db.Port, 10, 16)
+		check(err == nil, "DB_PORT must be a port number, got %q", db.Port)
+	}
+	switch db.TLSMode {
+	case "", TLSDisable, TLSRequire, TLSVerifyFull:
+	default:
+		check(false, "DB_TLS_MODE must be %s, %s or %s, got %q", TLSDisable, TLSRequire, TLSVerifyFull, db.TLSMode)
+	}
+	switch db.MigrationMode {
+	case "", MigrationCheck, MigrationAuto, MigrationOff:
+	default:
+		check(false, "DB_MIGRATION_MODE must be %s, %s or %s, got %q", MigrationCheck, MigrationAuto, MigrationOff, db.MigrationMode)
+	}
+	check(db.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(db.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns, "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	check(db.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(db.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+
+	check(c.Auth.APISecret != "", "API_SECRET is required")
+	check(c.Auth.TokenHourLifespan > 0, "TOKEN_HOUR_LIFESPAN must be positive")
+
+	keys := c.Encryption
+	if keys.FieldKeys != "" {
+		_, _, err := encryption.ParseKeys(keys.FieldKeys)
+		check(err == nil, "FIELD_ENCRYPTION_KEYS: %v", err)
+	}
+	for _, key := range []struct{ name, value string }{{"BLIND_INDEX_KEY", keys.BlindIndexKey}, {"KYC_ENCRYPTION_KEY", keys.KYCKey}} {
+		if key.value != "" {
+			_, err := encryption.ParseKey(key.value)
+			check(err == nil, "%s: %v", key.name, err)
+		}
+	}
+	if c.Environment != "development" {
+		check(keys.FieldKeys != "", "FIELD_ENCRYPTION_KEYS is required outside of development")
+		check(keys.BlindIndexKey != "", "BLIND_INDEX_KEY is required outside of development")
+	}
+
+	check(c.App.URL != "", "APP_URL is required")
+	check(c.App.Host != "", "HOST is required")
+	for _, proxy := range c.App.Proxies() {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "TRUSTED_PROXIES must hold IPs or CIDRs, got %q", proxy)
+	}
+
+	limits := c.RateLimit
+	check(limits.Store == ratelimit.StoreMemory || limits.Store == ratelimit.StoreSQL, "RATE_LIMIT_STORE must be %s or %s, got %q", ratelimit.StoreMemory, ratelimit.StoreSQL, limits.Store)
+	for _, limit := range []struct {
+		name  string
+		limit ratelimit.Limit
+	}{
+		{"RATE_LIMIT_GLOBAL", limits.Global},
+		{"RATE_LIMIT_AUTH", limits.Auth},
+		{"RATE_LIMIT_PUBLIC", limits.Public},
+		{"RATE_LIMIT_CMS", limits.CMS},
+		{"RATE_LIMIT_PARTNER", limits.Partner},
+	} {
+		check(limit.limit.Rate > 0 && limit.limit.Burst > 0, "%s is required", limit.name)
+	}
+
+	for _, client := range c.OIDC.Clients {
+		prefix := "OIDC_" + strings.ToUpper(client.Name) + "_"
+		check(client.Issuer != "", "%sISSUER is required", prefix)
+		check(client.ClientID != "", "%sCLIENT_ID is required", prefix)
+		check(client.RedirectURL != "", "%sREDIRECT_URL is required", prefix)
+	}
+	// The mock signs in whoever asks, any environment but a local one could be reached by others
+	mockAllowed := c.Environment == "development" || c.Environment == "test"
+	check(!c.OIDC.MockEnabled || mockAllowed, "OIDC_MOCK_ENABLED is only allowed when ENVIRONMENT is development or test, got %q", c.Environment)
+
+	check(c.Storage.Dir != "", "STORAGE_DIR is required")
+	if c.SMTP.Host != "" {
+		check(c.SMTP.Port != "", "SMTP_PORT is required with SMTP_HOST")
+		check(c.SMTP.From != "", "SMTP_FROM is required with SMTP_HOST")
+	}
+
+	check(c.Trash.RetentionDays > 0, "TRASH_RETENTION_DAYS must be positive")
+	check(c.Trash.FinancialRetentionDays > 0, "TRASH_FINANCIAL_RETENTION_DAYS must be positive")
+	check(c.Seed.AdminUsername == "" || c.Seed.AdminEmail != "", "ADMIN_EMAIL is required with ADMIN_USERNAME")
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "text", "LOG_FORMAT must be json or text, got %q", c.Log.Format)
+	check(c.Metrics.Addr == "" || c.Metrics.Addr != c.Server.Addr, "METRICS_ADDR must differ from SERVER_ADDR")
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		check(false, "TRACING_EXPORTER must be %s, %s or %s, got %q", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP, c.Tracing.Exporter)
+	}
+	check(c.Tracing.SamplePercent >= 0 && c.Tracing.SamplePercent <= 100, "TRACING_SAMPLE_PERCENT must be between 0 and 100")
+
+	return errors.Join(errs...)
+}
+
+// String lists every setting by its variable name, secrets show whether they are set only.
+func (c *Config) String() string {
+	var b strings.Builder
+	for _, field := range fields(c) {
+		value := fmt.Sprint(field.value.Interface())
+		if field.secret && value != "" {
+			value = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%s=%s\n", field.env[0], value)
+	}
+	for _, client := range c.OIDC.Clients {
+		prefix := "OIDC_" + strings.ToUpper(client.Name) + "_"
+		secret := ""
+		if client.ClientSecret != "" {
+			secret = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%sISSUER=%s\n%sCLIENT_ID=%s\n%sCLIENT_SECRET=%s\n%sREDIRECT_URL=%s\n%sSCOPES=%s\n",
+			prefix, client.Issuer, prefix, client.ClientID, prefix, secret, prefix, client.RedirectURL, prefix, strings.Join(client.Scopes, " "))
+	}
+	return b.String()
+}
+
+// Proxies returns the entries of TRUSTED_PROXIES.
+func (a App) Proxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(a.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// TokenLifespan is the lifetime of access tokens.
+func (a Auth) TokenLifespan() time.Duration {
+	return time.Duration(a.TokenHourLifespan) * time.Hour
+}
+
+// defaultIssuers are the issuers of the well known identity providers, OIDC_<NAME>_ISSUER
+// overrides them.
+var defaultIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+}
+
+// oidcClients reads the settings of the identity providers named in providers. Their variables
+// depend on the names, they are not fields and have no flags.
+func oidcClients(providers string) []oidc.Config {
+	var clients []oidc.Config
+	for _, name := range strings.Split(providers, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		client := oidc.Config{
+			Name:         name,
+			Issuer:       defaultIssuers[name],
+			ClientID:     strings.TrimSpace(os.Getenv(prefix + "CLIENT_ID")),
+			ClientSecret: strings.TrimSpace(os.Getenv(prefix + "CLIENT_SECRET")),
+			RedirectURL:  strings.TrimSpace(os.Getenv(prefix + "REDIRECT_URL")),
+		}
+		if issuer := strings.TrimSpace(os.Getenv(prefix + "ISSUER")); issuer != "" {
+			client.Issuer = issuer
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			client.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// loadFile exports the variables of the config file. Only the implicit .env may be missing.
+func loadFile(path string) error {
+	optional := path == ""
+	if optional {
+		path = ".env"
+	}
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("config: %w", err)
+	}
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
+
+type field struct {
+	env    []string
+	def    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists the settings of cfg in declaration order, the sections are walked in place.
+func fields(cfg *Config) []field {
+	var fields []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			structField := v.Type().Field(i)
+			env, ok := structField.Tag.Lookup("env")
+			if !ok {
+				if v.Field(i).Kind() == reflect.Struct {
+					walk(v.Field(i))
+				}
+				continue
+			}
+			fields = append(fields, field{
+				env:    strings.Split(env, ","),
+				def:    structField.Tag.Get("default"),
+				usage:  structField.Tag.Get("usage"),
+				secret: structField.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+	return fields
+}
+
+func (f field) flag() string {
+	return strings.ToLower(strings.ReplaceAll(f.env[0], "_", "-"))
+}
+
+func (f field) kind() string {
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	if _, ok := f.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return "value"
+	}
+	return f.value.Kind().String()
+}
+
+func (f field) set(value string) error {
+	value = strings.TrimSpace(value)
+	if unmarshaler, ok := f.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+	switch {
+	case f.value.Type() == reflect.TypeOf(time.Duration(0)):
+		if value == "" {
+			f.value.SetInt(0)
+			return nil
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		f.value.SetInt(int64(duration))
+	case f.value.Kind() == reflect.Int:
+		if value == "" {
+			f.value.SetInt(0)
+			return nil
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		f.value.SetInt(int64(number))
+	case f.value.Kind() == reflect.Bool:
+		if value == "" {
+			f.value.SetBool(false)
+			return nil
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		f.value.SetBool(enabled)
+	default:
+		f.value.SetString(value)
+	}
+	return nil
+}
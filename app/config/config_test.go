@@ -0,0 +1,254 @@
+package config
+
+import (
+	"be-car-zone/app/pkg/oidc"
+	"be-car-zone/app/pkg/ratelimit"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.env")
+	content := "DB_HOST=file-host\nDB_PORT=5433\nDB_DATABASE=file-db\nDB_USERNAME=file-user\nAPI_SECRET=file-secret\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"DB_HOST", "DB_PORT", "DB_NAME", "DB_DATABASE", "DB_USERNAME", "API_SECRET", "DB_PROVIDER", "SERVER_READ_TIMEOUT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("DB_PORT", "5434")
+	t.Setenv("DB_PROVIDER", "postgres")
+
+	cfg, err := Load([]string{"-config", file, "-environment", "development", "-db-provider", "mysql", "-server-read-timeout", "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := map[string][2]interface{}{
+		"flag over env":    {cfg.Database.Provider, "mysql"},
+		"env over file":    {cfg.Database.Port, "5434"},
+		"file":             {cfg.Database.Host, "file-host"},
+		"deprecated alias": {cfg.Database.Name, "file-db"},
+		"default":          {cfg.Server.WriteTimeout, 60 * time.Second},
+		"flag duration":    {cfg.Server.ReadTimeout, 10 * time.Second},
+	}
+	for name, check := range checks {
+		if check[0] != check[1] {
+			t.Errorf("%s: got %v, want %v", name, check[0], check[1])
+		}
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Provider = "oracle"
+	cfg.Database.TLSMode = "sometimes"
+	cfg.Server.ReadTimeout = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("an invalid configuration must be rejected")
+	}
+	for _, want := range []string{"DB_PROVIDER", "DB_TLS_MODE", "SERVER_READ_TIMEOUT", "DB_NAME", "API_SECRET", "FIELD_ENCRYPTION_KEYS"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q is missing from %q", want, err)
+		}
+	}
+}
+
+func TestValidateSQLiteNeedsNoServer(t *testing.T) {
+	cfg := Default()
+	cfg.Environment = "development"
+	cfg.Database.Provider = ProviderSQLite
+	cfg.Database.Host = ""
+	cfg.Database.Name = InMemory
+	cfg.Auth.APISecret = "secret"
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteInMemoryMigrates(t *testing.T) {
+	db := OpenDataBase(Database{Provider: ProviderSQLite, Name: InMemory, MaxOpenConns: 4, MaxIdleConns: 2})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Every connection of the pool sees the same database
+	var tables int64
+	if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'cars'").Scan(&tables).Error; err != nil || tables != 1 {
+		t.Fatalf("cars table: %d, %v", tables, err)
+	}
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "hunter2"
+	cfg.Auth.APISecret = "signing-key"
+
+	printed := cfg.String()
+	for _, secret := range []string{"hunter2", "signing-key"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("%q leaked into\n%s", secret, printed)
+		}
+	}
+	if !strings.Contains(printed, "DB_PASSWORD=[redacted]") || !strings.Contains(printed, "SMTP_PASSWORD=\n") {
+		t.Errorf("set secrets should read [redacted] and empty ones stay empty:\n%s", printed)
+	}
+	if !strings.Contains(printed, "DB_HOST=127.0.0.1") {
+		t.Errorf("plain settings should be printed:\n%s", printed)
+	}
+}
+
+func TestLoadTypedSettings(t *testing.T) {
+	for key, value := range map[string]string{
+		"DB_PROVIDER":              ProviderSQLite,
+		"DB_NAME":                  InMemory,
+		"API_SECRET":               "secret",
+		"RATE_LIMIT_AUTH":          "30/m:5",
+		"OIDC_MOCK_ENABLED":        "true",
+		"OIDC_PROVIDERS":           "Google, acme",
+		"OIDC_GOOGLE_CLIENT_ID":    "google-client",
+		"OIDC_GOOGLE_REDIRECT_URL": "http://localhost:8080/api/auth/oidc/google/callback",
+		"OIDC_ACME_ISSUER":         "https://id.acme.test",
+		"OIDC_ACME_CLIENT_ID":      "acme-client",
+		"OIDC_ACME_CLIENT_SECRET":  "acme-secret",
+		"OIDC_ACME_REDIRECT_URL":   "http://localhost:8080/api/auth/oidc/acme/callback",
+		"OIDC_ACME_SCOPES":         "openid,email",
+	} {
+		t.Setenv(key, value)
+	}
+
+	cfg, err := Load([]string{"-environment", "development"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.RateLimit.Auth; got != (ratelimit.Limit{Rate: 0.5, Burst: 5}) || got.String() != "30/m:5" {
+		t.Errorf("RATE_LIMIT_AUTH = %+v (%s)", got, got)
+	}
+	if cfg.RateLimit.Global.String() != "600/m" {
+		t.Errorf("RATE_LIMIT_GLOBAL = %s, want the default", cfg.RateLimit.Global)
+	}
+	if !cfg.OIDC.MockEnabled {
+		t.Error("OIDC_MOCK_ENABLED is not read")
+	}
+	if len(cfg.OIDC.Clients) != 2 {
+		t.Fatalf("clients = %+v", cfg.OIDC.Clients)
+	}
+	google, acme := cfg.OIDC.Clients[0], cfg.OIDC.Clients[1]
+	if google.Name != "google" || google.Issuer != "https://accounts.google.com" || google.ClientID != "google-client" {
+		t.Errorf("google = %+v", google)
+	}
+	if acme.Issuer != "https://id.acme.test" || strings.Join(acme.Scopes, " ") != "openid email" {
+		t.Errorf("acme = %+v", acme)
+	}
+	if printed := cfg.String(); strings.Contains(printed, "acme-secret") || !strings.Contains(printed, "OIDC_ACME_CLIENT_SECRET=[redacted]") {
+		t.Errorf("the client secret is not redacted:\n%s", printed)
+	}
+}
+
+func TestValidateTypedSettings(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Provider = ProviderSQLite
+	cfg.Database.Name = InMemory
+	cfg.Auth.APISecret = "secret"
+	cfg.RateLimit.Store = "redis"
+	cfg.OIDC.MockEnabled = true
+	cfg.OIDC.Clients = []oidc.Config{{Name: "acme", Issuer: "https://id.acme.test"}}
+	cfg.Trash.RetentionDays = 0
+	cfg.Seed.AdminUsername = "admin"
+	cfg.App.TrustedProxies = "10.0.0.0/8, 192.168.1.1, proxy.internal"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("an invalid configuration must be rejected")
+	}
+	for _, want := range []string{"RATE_LIMIT_STORE", "OIDC_ACME_CLIENT_ID", "OIDC_ACME_REDIRECT_URL", "OIDC_MOCK_ENABLED", "TRASH_RETENTION_DAYS", "ADMIN_EMAIL", `TRUSTED_PROXIES must hold IPs or CIDRs, got "proxy.internal"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q is missing from %q", want, err)
+		}
+	}
+
+	if _, err := Load([]string{"-rate-limit-cms", "often"}); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_CMS") {
+		t.Errorf("an invalid limit must fail the load, got %v", err)
+	}
+}
+
+func TestMockIdentityProviderOnlyInDevelopment(t *testing.T) {
+	for environment, allowed := range map[string]bool{
+		"development": true,
+		"test":        true,
+		"staging":     false,
+		"production":  false,
+		"prod":        false,
+	} {
+		cfg := Default()
+		cfg.Environment = environment
+		cfg.OIDC.MockEnabled = true
+
+		err := cfg.Validate()
+		refused := err != nil && strings.Contains(err.Error(), "OIDC_MOCK_ENABLED")
+		if refused == allowed {
+			t.Errorf("ENVIRONMENT=%s: the mock identity provider allowed %v, want %v (%v)", environment, !refused, allowed, err)
+		}
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.APISecret = "secret"
+	if _, err := cfg.Keyring(); err == nil {
+		t.Error("keys must not be derived outside of development")
+	}
+
+	cfg.Environment = "development"
+	derived, err := cfg.Keyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.Primary() != "default" {
+		t.Errorf("primary = %q", derived.Primary())
+	}
+
+	cfg.Environment = "production"
+	cfg.Encryption.FieldKeys = "k2:" + strings.Repeat("22", 32) + ",k1:" + strings.Repeat("11", 32)
+	cfg.Encryption.BlindIndexKey = strings.Repeat("33", 32)
+	ring, err := cfg.Keyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring.Primary() != "k2" {
+		t.Errorf("primary = %q, want the first key", ring.Primary())
+	}
+	if ring.BlindIndex("users.phone_number", "081234567890") == derived.BlindIndex("users.phone_number", "081234567890") {
+		t.Error("BLIND_INDEX_KEY is not used")
+	}
+
+	if legacy, err := cfg.LegacyKYCCipher(); legacy != nil || err != nil {
+		t.Errorf("no legacy KYC cipher is expected outside of development, got %v, %v", legacy, err)
+	}
+	cfg.Encryption.KYCKey = "short"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "KYC_ENCRYPTION_KEY") {
+		t.Errorf("an invalid key must be rejected, got %v", err)
+	}
+}
@@ -1,74 +1,114 @@
 package config
 
 import (
-	"be-car-zone/app/models"
-	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/pkg/audit"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/migrate"
+	"be-car-zone/app/pkg/tracing"
 	"fmt"
 	"log"
-	"os"
+	"sync/atomic"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func ConnectDataBase() *gorm.DB {
-	dbProvider := utils.Getenv("DB_PROVIDER", "mysql")
-	var db *gorm.DB
+// ConnectDataBase opens the database and makes sure its schema matches the build, see
+// DB_MIGRATION_MODE in migrate.go.
+func ConnectDataBase(cfg *Config) *gorm.DB {
+	db := OpenDataBase(cfg.Database)
+	if err := applyMigrationMode(db, cfg.Environment, cfg.Database.MigrationMode); err != nil {
+		log.Fatal(err)
+	}
+	return db
+}
 
-	if dbProvider == "postgres" {
-		username := os.Getenv("DB_USERNAME")
-		password := os.Getenv("DB_PASSWORD")
-		host := os.Getenv("DB_HOST")
-		port := os.Getenv("DB_PORT")
-		database := os.Getenv("DB_NAME")
-		// production
-		dsn := "host=" + host + " user=" + username + " password=" + password + " dbname=" + database + " port=" + port + " sslmode=require"
-		dbGorm, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err != nil {
-			panic(err.Error())
+// OpenDataBase connects to the cfg.Provider database, sizes its connection pool and installs the
+// GORM plugins, the schema is left alone. Encrypted columns are sealed with the keyring of
+// encryption.SetDefault, see Config.Keyring.
+func OpenDataBase(cfg Database) *gorm.DB {
+	var dialector gorm.Dialector
+
+	switch cfg.Provider {
+	case ProviderPostgres:
+		port := cfg.Port
+		if port == "" {
+			port = "5432"
 		}
-
-		log.Println("Database Connected at", dbProvider, "provider")
-
-		db = dbGorm
-
-	} else {
-		username := utils.Getenv("DB_USERNAME", "root")
-		password := utils.Getenv("DB_PASSWORD", "root")
-		host := utils.Getenv("DB_HOST", "127.0.0.1")
-		port := utils.Getenv("DB_PORT", "3306")
-		database := utils.Getenv("DB_NAME", "db_name")
-
-		dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?charset=utf8mb4&parseTime=True&loc=Local", username, password, host, port, database)
-
-		dbGorm, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-
-		if err != nil {
-			panic(err.Error())
+		sslMode := cfg.TLSMode
+		if sslMode == "" {
+			sslMode = TLSRequire
 		}
+		dsn := "host=" + cfg.Host + " user=" + cfg.Username + " password=" + cfg.Password + " dbname=" + cfg.Name + " port=" + port + " sslmode=" + sslMode
+		dialector = postgres.Open(dsn)
+	case ProviderSQLite:
+		dialector = sqlite.Open(sqliteDSN(cfg.Name))
+	default:
+		port := cfg.Port
+		if port == "" {
+			port = "3306"
+		}
+		dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?charset=utf8mb4&parseTime=True&loc=Local", cfg.Username, cfg.Password, cfg.Host, port, cfg.Name)
+		switch cfg.TLSMode {
+		case TLSRequire:
+			// Encrypted like sslmode=require on Postgres, the server certificate is not checked
+			dsn += "&tls=skip-verify"
+		case TLSVerifyFull:
+			dsn += "&tls=true"
+		}
+		dialector = mysql.Open(dsn)
+	}
 
-		log.Println("Database Connected at", dbProvider, "provider")
-
-		db = dbGorm
-
+	// Drivers report duplicate keys and foreign key violations as gorm.ErrDuplicatedKey and
+	// gorm.ErrForeignKeyViolated, whatever the provider, see problem.Error
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		panic(err.Error())
 	}
+	log.Println("Database Connected at", cfg.Provider, "provider")
 
-	errs := db.AutoMigrate(
-		&models.User{},
-		&models.Role{},
-		&models.Invoice{},
-		&models.Order{},
-		&models.Transaction{},
-		&models.Car{},
-		&models.TypeCar{},
-		&models.BrandCar{},
-	)
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err.Error())
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	if cfg.Provider == ProviderSQLite && cfg.Name == InMemory {
+		// The database is gone once its last connection closes, keep one open for good
+		sqlDB.SetMaxIdleConns(max(cfg.MaxIdleConns, 1))
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
 
-	if errs != nil {
-		log.Fatalf(`Failed Migrate %v`, errs)
+	if err := db.Use(encryption.Plugin{}); err != nil {
+		log.Fatal(err)
+	}
+	// Rate limit buckets and API key usage change on every request and have their own trail,
+	// migrations are tracked in their own table
+	if err := db.Use(audit.Plugin{Skip: []string{"rate_limit_buckets", "api_key_audits", migrate.Table}}); err != nil {
+		log.Fatal(err)
+	}
+	// Queries run with the context of a traced request are spans of its trace
+	if err := db.Use(tracing.Plugin{}); err != nil {
+		log.Fatal(err)
 	}
 
 	return db
 
 }
+
+var memoryDatabases atomic.Int64
+
+// sqliteDSN turns on foreign keys, which SQLite leaves off by default. Every in-memory database
+// gets its own name, its connections share it through the shared cache.
+func sqliteDSN(name string) string {
+	pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if name == InMemory {
+		return fmt.Sprintf("file:carzone-%d?mode=memory&cache=shared&%s", memoryDatabases.Add(1), pragmas)
+	}
+	return name + "?" + pragmas + "&_pragma=journal_mode(WAL)"
+}
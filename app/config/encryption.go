@@ -0,0 +1,62 @@
+package config
+
+import (
+	"be-car-zone/app/pkg/encryption"
+	"fmt"
+	"log/slog"
+)
+
+// Keyring builds the keyring of the encrypted columns from FIELD_ENCRYPTION_KEYS and
+// BLIND_INDEX_KEY, install it with encryption.SetDefault.
+func (c *Config) Keyring() (*encryption.Keyring, error) {
+	primary, keys := "default", map[string][]byte{}
+	if c.Encryption.FieldKeys != "" {
+		var err error
+		if primary, keys, err = encryption.ParseKeys(c.Encryption.FieldKeys); err != nil {
+			return nil, fmt.Errorf("FIELD_ENCRYPTION_KEYS: %w", err)
+		}
+	} else {
+		key, err := c.encryptionKey("FIELD_ENCRYPTION_KEYS", "")
+		if err != nil {
+			return nil, err
+		}
+		keys[primary] = key
+	}
+
+	indexKey, err := c.encryptionKey("BLIND_INDEX_KEY", c.Encryption.BlindIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.NewKeyring(primary, keys, indexKey)
+}
+
+// LegacyKYCCipher returns the cipher of the KYC document files sealed with KYC_ENCRYPTION_KEY,
+// before the scans moved onto the keyring. It is nil when the key is not set, except in
+// development where the files were sealed with a key derived from API_SECRET.
+func (c *Config) LegacyKYCCipher() (*encryption.Cipher, error) {
+	if c.Encryption.KYCKey == "" && c.Environment != "development" {
+		return nil, nil
+	}
+	key, err := c.encryptionKey("KYC_ENCRYPTION_KEY", c.Encryption.KYCKey)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.New(key)
+}
+
+// encryptionKey parses the key in the variable name. Only development falls back to a key
+// derived from API_SECRET, Validate rejects a missing key everywhere else.
+func (c *Config) encryptionKey(name, value string) ([]byte, error) {
+	if value != "" {
+		key, err := encryption.ParseKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return key, nil
+	}
+	if c.Environment != "development" {
+		return nil, fmt.Errorf("%s is required outside of development", name)
+	}
+	slog.Warn("Deriving an encryption key from API_SECRET, set your own before going to production", "variable", name)
+	return encryption.DeriveKey(c.Auth.APISecret, name), nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"be-car-zone/app/migrations"
+	"be-car-zone/app/pkg/migrate"
+	"be-car-zone/app/seeders"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Migration modes for DB_MIGRATION_MODE.
+const (
+	// MigrationCheck refuses to start when a migration is pending or the database was migrated
+	// by a newer release. Migrations are applied with "carzone migrate up" during deploys.
+	MigrationCheck = "check"
+	// MigrationAuto applies pending migrations on startup, convenient for development.
+	MigrationAuto = "auto"
+	// MigrationOff skips the schema check entirely.
+	MigrationOff = "off"
+)
+
+// NewMigrator returns the migrator for the dialect of db.
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrations.FS)
+}
+
+func applyMigrationMode(db *gorm.DB, environment, mode string) error {
+	if mode == "" {
+		mode = MigrationCheck
+		if environment == "development" {
+			mode = MigrationAuto
+		}
+	}
+	if mode == MigrationOff {
+		return nil
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch mode {
+	case MigrationAuto:
+		applied, err := migrator.Up(ctx, 0)
+		for _, migration := range applied {
+			log.Printf("Migrated %d_%s", migration.Version, migration.Name)
+		}
+		if errors.Is(err, migrate.ErrUnversioned) {
+			return fmt.Errorf("%w. Check that the schema matches the migrations, then record it with \"carzone migrate baseline <version>\"", err)
+		}
+		if err != nil {
+			return err
+		}
+		// Registration needs the roles, a fresh development database gets them right away
+		if _, err := seeders.Roles(db); err != nil {
+			return err
+		}
+		return migrator.Check(ctx)
+	case MigrationCheck:
+		if err := migrator.Check(ctx); err != nil {
+			return fmt.Errorf("%w, run \"carzone migrate up\" or set DB_MIGRATION_MODE=auto", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown DB_MIGRATION_MODE %q, use %s, %s or %s", mode, MigrationCheck, MigrationAuto, MigrationOff)
+	}
+}
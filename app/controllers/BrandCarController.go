@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
 	"net/http"
 	"strconv"
 
@@ -23,18 +24,18 @@ type BrandCarController struct {
 // @Security BearerToken
 // @Param brand_car body models.BrandCar true "Brand Car object"
 // @Success 201 {object} models.BrandCar
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/brand-cars [post]
 func (bcc *BrandCarController) Create(c *gin.Context) {
 	var brandCar models.BrandCar
 	if err := c.ShouldBindJSON(&brandCar); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	if err := bcc.DB.Create(&brandCar).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bcc.DB.WithContext(c).Create(&brandCar).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -47,12 +48,12 @@ func (bcc *BrandCarController) Create(c *gin.Context) {
 // @Tags brand-cars
 // @Produce json
 // @Success 200 {array} models.BrandCar
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/brand-cars [get]
 func (bcc *BrandCarController) GetAll(c *gin.Context) {
 	var brandCars []models.BrandCar
-	if err := bcc.DB.Order("created_at DESC").Find(&brandCars).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bcc.DB.WithContext(c).Order("created_at DESC").Find(&brandCars).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -66,19 +67,19 @@ func (bcc *BrandCarController) GetAll(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Brand Car ID"
 // @Success 200 {object} models.BrandCar
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/brand-cars/{id} [get]
 func (bcc *BrandCarController) GetByID(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var brandCar models.BrandCar
-	if err := bcc.DB.First(&brandCar, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Brand car not found"})
+	if err := bcc.DB.WithContext(c).First(&brandCar, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Brand car not found")
 		return
 	}
 
@@ -96,30 +97,30 @@ func (bcc *BrandCarController) GetByID(c *gin.Context) {
 // @Param id path int true "Brand Car ID"
 // @Param brand_car body models.BrandCar true "Updated Brand Car object"
 // @Success 200 {object} models.BrandCar
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/brand-cars/{id} [put]
 func (bcc *BrandCarController) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var brandCar models.BrandCar
-	if err := bcc.DB.First(&brandCar, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Brand car not found"})
+	if err := bcc.DB.WithContext(c).First(&brandCar, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Brand car not found")
 		return
 	}
 
 	if err := c.ShouldBindJSON(&brandCar); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	if err := bcc.DB.Save(&brandCar).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bcc.DB.WithContext(c).Save(&brandCar).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -128,25 +129,36 @@ func (bcc *BrandCarController) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a brand car
-// @Description Delete a specific brand car
+// @Description Move a brand car to the trash, brands still used by cars cannot be deleted
 // @Tags brand-cars
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
 // @Param id path int true "Brand Car ID"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/brand-cars/{id} [delete]
 func (bcc *BrandCarController) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
-	if err := bcc.DB.Delete(&models.BrandCar{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	inUse, err := isReferenced(bcc.DB.WithContext(c), &models.Car{}, "brand_id", id)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if inUse {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "Brand car is still used by cars")
+		return
+	}
+
+	if err := bcc.DB.WithContext(c).Delete(&models.BrandCar{}, id).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
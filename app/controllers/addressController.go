@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddressController manages the address book of the user owning the token.
+type AddressController struct {
+	DB *gorm.DB
+}
+
+// FindAll godoc
+// @Summary Get my addresses
+// @Description Get the address book of the user owning the token, default address first
+// @Tags addresses
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=[]models.UserAddress}
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/addresses [get]
+func (ctrl *AddressController) FindAll(c *gin.Context) {
+	addresses := []models.UserAddress{}
+	if err := ctrl.DB.WithContext(c).Where("user_id = ?", c.GetUint("user_id")).Order("is_default DESC, created_at DESC").Find(&addresses).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": addresses})
+}
+
+// Create godoc
+// @Summary Add an address
+// @Description Add an address to the address book. The first address always becomes the default one.
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param address body models.UserAddressRequest true "Address Data"
+// @Success 201 {object} object{data=models.UserAddress}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/addresses [post]
+func (ctrl *AddressController) Create(c *gin.Context) {
+	var req models.UserAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	address := models.UserAddress{
+		UserID:          userID,
+		Label:           req.Label,
+		IsDefault:       req.IsDefault,
+		DeliveryAddress: req.DeliveryAddress(),
+	}
+
+	err := ctrl.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserAddress{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&address).Error
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": address})
+}
+
+// Update godoc
+// @Summary Update an address
+// @Description Update an address of the address book. Orders keep the address they were placed with.
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Address ID"
+// @Param address body models.UserAddressRequest true "Address Data"
+// @Success 200 {object} object{data=models.UserAddress}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/addresses/{id} [put]
+func (ctrl *AddressController) Update(c *gin.Context) {
+	var req models.UserAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	address, ok := ctrl.findOwn(c)
+	if !ok {
+		return
+	}
+
+	address.Label = req.Label
+	address.DeliveryAddress = req.DeliveryAddress()
+
+	err := ctrl.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// A default address can only be replaced by making another one default
+		if req.IsDefault && !address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+			address.IsDefault = true
+		}
+		return tx.Save(&address).Error
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// SetDefault godoc
+// @Summary Set default address
+// @Description Make an address the default delivery address
+// @Tags addresses
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Address ID"
+// @Success 200 {object} object{data=models.UserAddress}
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/addresses/{id}/default [post]
+func (ctrl *AddressController) SetDefault(c *gin.Context) {
+	address, ok := ctrl.findOwn(c)
+	if !ok {
+		return
+	}
+
+	err := ctrl.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultAddress(tx, address.UserID); err != nil {
+			return err
+		}
+		address.IsDefault = true
+		return tx.Model(&address).Update("is_default", true).Error
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// Delete godoc
+// @Summary Delete an address
+// @Description Delete an address. When the default address is deleted the most recent remaining one becomes the default.
+// @Tags addresses
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Address ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/addresses/{id} [delete]
+func (ctrl *AddressController) Delete(c *gin.Context) {
+	address, ok := ctrl.findOwn(c)
+	if !ok {
+		return
+	}
+
+	err := ctrl.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.UserAddress
+		err := tx.Where("user_id = ?", address.UserID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully!"})
+}
+
+// findOwn loads the address from the path, answering 404 when it belongs to someone else.
+func (ctrl *AddressController) findOwn(c *gin.Context) (models.UserAddress, bool) {
+	var address models.UserAddress
+	if err := ctrl.DB.WithContext(c).Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&address).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "address not found")
+		return address, false
+	}
+	return address, true
+}
+
+func clearDefaultAddress(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.UserAddress{}).Where("user_id = ? AND is_default = ?", userID, true).Update("is_default", false).Error
+}
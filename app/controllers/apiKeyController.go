@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/apikey"
+	"be-car-zone/app/pkg/problem"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APIKeyController struct {
+	DB  *gorm.DB
+	Now func() time.Time
+}
+
+// FindAll godoc
+// @Summary Get all API keys
+// @Description Get all partner API keys (only admin). Key hashes are never returned.
+// @Tags api-keys
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=[]models.APIKey}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/api-keys [get]
+func (ctrl *APIKeyController) FindAll(c *gin.Context) {
+	var apiKeys []models.APIKey
+	if err := ctrl.DB.WithContext(c).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": apiKeys})
+}
+
+// Create godoc
+// @Summary Create new API key
+// @Description Create a partner API key (only admin). The plaintext key is only returned in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param api_key body models.APIKeyRequest true "API Key Data"
+// @Success 201 {object} object{data=models.APIKeyCreated}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/api-keys [post]
+func (ctrl *APIKeyController) Create(c *gin.Context) {
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(ctrl.Now()) {
+		problem.Abort(c, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	newAPIKey := models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    strings.Join(req.Scopes, ","),
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: c.GetUint("user_id"),
+	}
+
+	if err := ctrl.DB.WithContext(c).Create(&newAPIKey).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": models.APIKeyCreated{APIKey: newAPIKey, Key: key}})
+}
+
+// Revoke godoc
+// @Summary Revoke API key
+// @Description Revoke a partner API key (only admin). Revoked keys are kept for the audit trail.
+// @Tags api-keys
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "API Key ID"
+// @Success 200 {object} object{data=models.APIKey}
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/api-keys/{id} [delete]
+func (ctrl *APIKeyController) Revoke(c *gin.Context) {
+	var apiKey models.APIKey
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&apiKey).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "record not found")
+		return
+	}
+
+	if apiKey.RevokedAt == nil {
+		now := ctrl.Now()
+		apiKey.RevokedAt = &now
+		if err := ctrl.DB.WithContext(c).Save(&apiKey).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": apiKey})
+}
+
+// FindAudits godoc
+// @Summary Get API key audit trail
+// @Description Get every call made with an API key (only admin)
+// @Tags api-keys
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "API Key ID"
+// @Success 200 {object} object{data=[]models.APIKeyAudit}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/api-keys/{id}/audits [get]
+func (ctrl *APIKeyController) FindAudits(c *gin.Context) {
+	var audits []models.APIKeyAudit
+	if err := ctrl.DB.WithContext(c).Where("api_key_id = ?", c.Param("id")).Order("created_at DESC").Limit(500).Find(&audits).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": audits})
+}
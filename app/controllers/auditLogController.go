@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	auditLogDefaultLimit = 100
+	auditLogMaxLimit     = 500
+)
+
+type AuditLogController struct {
+	DB *gorm.DB
+}
+
+// FindAll godoc
+// @Summary Get audit logs
+// @Description Get the audit trail of creates, updates and deletes, newest first (only admin). Filter by entity (table name) and entity_id to get the history of one row, or by actor_id to get everything a user changed.
+// @Tags audit-logs
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param entity query string false "Table name, e.g. cars"
+// @Param entity_id query string false "Row ID"
+// @Param actor_id query int false "User ID of the actor"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge)
+// @Param request_id query string false "Request ID"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date (YYYY-MM-DD), inclusive"
+// @Param before_id query int false "Only entries older than this ID, for paging"
+// @Param limit query int false "Page size, at most 500" default(100)
+// @Success 200 {object} object{data=[]models.AuditLog}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/audit-logs [get]
+func (ctrl *AuditLogController) FindAll(c *gin.Context) {
+	query := ctrl.DB.WithContext(c).Model(&models.AuditLog{})
+
+	for param, column := range map[string]string{
+		"entity":     "entity",
+		"entity_id":  "entity_id",
+		"actor_id":   "actor_id",
+		"action":     "action",
+		"request_id": "request_id",
+	} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	if from := c.Query("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, "from must be a date in the YYYY-MM-DD format")
+			return
+		}
+		query = query.Where("created_at >= ?", day)
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, "to must be a date in the YYYY-MM-DD format")
+			return
+		}
+		query = query.Where("created_at < ?", day.AddDate(0, 0, 1))
+	}
+	if beforeID := c.Query("before_id"); beforeID != "" {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(auditLogDefaultLimit)))
+	if err != nil || limit < 1 {
+		problem.Abort(c, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	if limit > auditLogMaxLimit {
+		limit = auditLogMaxLimit
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Limit(limit).Find(&logs).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
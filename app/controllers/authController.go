@@ -3,6 +3,7 @@ package controllers
 import (
 	"be-car-zone/app/models"
 	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/problem"
 	"be-car-zone/app/pkg/utils"
 	"net/http"
 
@@ -20,36 +21,32 @@ type AuthController struct {
 // @Tags Auth
 // @Param Body body models.LoginRequest true "the body to login a user"
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} object{token=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/auth/login [post]
 func (ctrl *AuthController) Login(c *gin.Context) {
 	var req models.LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := utils.NewValidator()
-	if err := utils.ValidateStruct(validate, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
 	var user *models.User
-	if err := ctrl.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid username or password"})
+	if err := ctrl.DB.WithContext(c).Where("username = ?", req.Username).First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid username or password")
 		return
 	}
 
 	if user == nil || !utils.CheckPasswordHash(req.Password, user.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username or password"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid username or password")
 		return
 	}
 
 	token, err := jwt.GenerateToken(user.ID, uint(user.RoleID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(c, err)
 		return
 	}
 
@@ -62,40 +59,37 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 // @Tags Auth
 // @Param Body body models.RegisterRequest true "the body to register a user"
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} object{user=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/auth/register [post]
 func (ctrl *AuthController) Register(c *gin.Context) {
 	var req models.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := utils.NewValidator()
-	if err := utils.ValidateStruct(validate, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(c, err)
 		return
 	}
 
 	var existingUser models.User
-	if err := ctrl.DB.Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser).Error; err == nil {
+	if err := ctrl.DB.WithContext(c).Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser).Error; err == nil {
 		if existingUser.Username == req.Username {
-			c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "username already exists")
 			return
 		}
 		if existingUser.Email == req.Email {
-			c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "email already exists")
 			return
 		}
 	} else if err != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(c, err)
 		return
 	}
 
@@ -106,8 +100,8 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 		RoleID:   utils.IDRoleUser,
 	}
 
-	if err := ctrl.DB.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := ctrl.DB.WithContext(c).Create(&newUser).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -121,7 +115,8 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
-// @Success 200 {object} models.User
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 401 {object} problem.Problem
 // @Router /api/auth/me [get]
 func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 
@@ -129,12 +124,12 @@ func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 
 	var user models.User
 
-	if err := ctrl.DB.Where("id = ?", userId).Preload("Role").First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Record not found!"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", userId).Preload("Role").First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusUnauthorized, "Record not found!")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": user})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, models.Viewer{UserID: user.ID})})
 }
 
 // ChangePasswordUser godoc
@@ -145,15 +140,15 @@ func (ctrl *AuthController) GetCurrentUser(c *gin.Context) {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param body body models.InputChangePassword true "Change Password Request Body"
 // @Security BearerToken
-// @Success 200 {object} map[string]interface{} "{"message": "Password changed successfully"}"
-// @Failure 400 {object} map[string]interface{} "{"error": "string"}"
-// @Failure 401 {object} map[string]interface{} "{"error": "string"}"
-// @Failure 500 {object} map[string]interface{} "{"error": "string"}"
+// @Success 200 {object} object{message=string} "{"message": "Password changed successfully"}"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/auth/change-password [post]
 func (ctrl *AuthController) ChangePassword(c *gin.Context) {
 	var input models.InputChangePassword
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
@@ -161,32 +156,32 @@ func (ctrl *AuthController) ChangePassword(c *gin.Context) {
 
 	// Cari user berdasarkan userID
 	var user models.User
-	if err := ctrl.DB.Where("id = ?", userId).First(&user).Error; err != nil {
+	if err := ctrl.DB.WithContext(c).Where("id = ?", userId).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			problem.Abort(c, http.StatusUnauthorized, "User not found")
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Error(c, err)
 		}
 		return
 	}
 
 	// Verifikasi old password
 	if !utils.CheckPasswordHash(input.OldPassword, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Old password is incorrect"})
+		problem.Abort(c, http.StatusUnauthorized, "Old password is incorrect")
 		return
 	}
 
 	// Hash new password
 	hashedPassword, err := utils.HashPassword(input.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		problem.Abort(c, http.StatusInternalServerError, "Failed to hash password")
 		return
 	}
 
 	// Update password user
 	user.Password = hashedPassword
-	if err := ctrl.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+	if err := ctrl.DB.WithContext(c).Save(&user).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to update password")
 		return
 	}
 
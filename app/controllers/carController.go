@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"be-car-zone/app/pkg/problem"
 	"net/http"
 	"sort"
 	"strconv"
@@ -13,18 +14,19 @@ import (
 )
 
 type CarInput struct {
-	Name        string  `json:"name" binding:"required"`
+	Name        string  `json:"name" validate:"required"`
 	Description string  `json:"description"`
 	ImageCar    string  `json:"image_car"`
-	Price       float64 `json:"price" binding:"required"`
-	TypeID      uint    `json:"type_id" binding:"required"`
-	BrandID     uint    `json:"brand_id" binding:"required"`
+	Price       float64 `json:"price" validate:"required,idr"`
+	TypeID      uint    `json:"type_id" validate:"required"`
+	BrandID     uint    `json:"brand_id" validate:"required"`
 	IsSecond    bool    `json:"is_second"`
 	Sold        bool    `json:"sold"`
 }
 
 type CarController struct {
-	DB *gorm.DB
+	DB  *gorm.DB
+	Now func() time.Time
 }
 
 type Result struct {
@@ -53,14 +55,14 @@ type CarSalesDataResponse struct {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
 // @Param car body CarInput true "Car object"
-// @Success 201 {object} models.Car
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 201 {object} object{message=string,car=models.Car}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/cars [post]
 func (cc *CarController) Create(c *gin.Context) {
 	var input CarInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
@@ -75,13 +77,13 @@ func (cc *CarController) Create(c *gin.Context) {
 		Sold:        input.Sold,
 	}
 
-	if err := cc.DB.Create(&car).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create car"})
+	if err := cc.DB.WithContext(c).Create(&car).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to create car")
 		return
 	}
 
-	if err := cc.DB.Preload("Type").Preload("Brand").First(&car, car.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load car details"})
+	if err := cc.DB.WithContext(c).Preload("Type").Preload("Brand").First(&car, car.ID).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to load car details")
 		return
 	}
 
@@ -93,13 +95,13 @@ func (cc *CarController) Create(c *gin.Context) {
 // @Description Get a list of all cars with their types and brands
 // @Tags cars
 // @Produce json
-// @Success 200 {array} models.Car
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{cars=[]models.Car}
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/cars [get]
 func (cc *CarController) GetAll(c *gin.Context) {
 	var cars []models.Car
-	if err := cc.DB.Preload("Type").Preload("Brand").Order("created_at DESC").Find(&cars).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cars"})
+	if err := cc.DB.WithContext(c).Preload("Type").Preload("Brand").Order("created_at DESC").Find(&cars).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to retrieve cars")
 		return
 	}
 
@@ -112,20 +114,20 @@ func (cc *CarController) GetAll(c *gin.Context) {
 // @Tags cars
 // @Produce json
 // @Param id path int true "Car ID"
-// @Success 200 {object} models.Car
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Success 200 {object} object{car=models.Car}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/cars/{id} [get]
 func (cc *CarController) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var car models.Car
-	if err := cc.DB.Preload("Type").Preload("Brand").First(&car, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Car not found"})
+	if err := cc.DB.WithContext(c).Preload("Type").Preload("Brand").First(&car, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Car not found")
 		return
 	}
 
@@ -142,27 +144,27 @@ func (cc *CarController) GetByID(c *gin.Context) {
 // @Security BearerToken
 // @Param id path int true "Car ID"
 // @Param car body CarInput true "Updated Car object"
-// @Success 200 {object} models.Car
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{message=string,car=models.Car}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/cars/{id} [put]
 func (cc *CarController) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var car models.Car
-	if err := cc.DB.First(&car, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Car not found"})
+	if err := cc.DB.WithContext(c).First(&car, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Car not found")
 		return
 	}
 
 	var input CarInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
@@ -175,13 +177,13 @@ func (cc *CarController) Update(c *gin.Context) {
 	car.IsSecond = input.IsSecond
 	car.Sold = input.Sold
 
-	if err := cc.DB.Save(&car).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update car"})
+	if err := cc.DB.WithContext(c).Save(&car).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to update car")
 		return
 	}
 
-	if err := cc.DB.Preload("Type").Preload("Brand").First(&car, car.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load car details"})
+	if err := cc.DB.WithContext(c).Preload("Type").Preload("Brand").First(&car, car.ID).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to load car details")
 		return
 	}
 
@@ -190,32 +192,43 @@ func (cc *CarController) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a car
-// @Description Delete a specific car
+// @Description Move a car to the trash, cars with orders cannot be deleted
 // @Tags cars
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
 // @Param id path int true "Car ID"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/cars/{id} [delete]
 func (cc *CarController) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var car models.Car
-	if err := cc.DB.First(&car, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Car not found"})
+	if err := cc.DB.WithContext(c).First(&car, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Car not found")
 		return
 	}
 
-	if err := cc.DB.Delete(&car).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete car"})
+	hasOrders, err := isReferenced(cc.DB.WithContext(c), &models.Order{}, "car_id", car.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if hasOrders {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "Car has orders and cannot be deleted")
+		return
+	}
+
+	if err := cc.DB.WithContext(c).Delete(&car).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to delete car")
 		return
 	}
 
@@ -230,14 +243,15 @@ func (cc *CarController) Delete(c *gin.Context) {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
 // @Success 200 {object} CarSalesDataResponse
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/cars/sales-data [get]
 func (cc *CarController) GetCarChartData(c *gin.Context) {
-	// Fetch sold cars data
+	// Fetch sold cars data. Grouping by day, month and year happens below rather than in SQL,
+	// date functions differ between MySQL, Postgres and SQLite
 	var cars []models.Car
-	if err := cc.DB.Where("sold = ?", true).Find(&cars).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cars data"})
+	if err := cc.DB.WithContext(c).Select("created_at", "is_second").Where("sold = ?", true).Find(&cars).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to get cars data")
 		return
 	}
 
@@ -246,11 +260,12 @@ func (cc *CarController) GetCarChartData(c *gin.Context) {
 	monthlySales := make(map[string]int)
 	yearlySales := make(map[string]int)
 
-	now := time.Now()
+	now := cc.Now()
 	weekAgo := now.AddDate(0, 0, -7)
 
 	for _, car := range cars {
-		createdDate := car.CreatedAt
+		// Drivers return times in different zones, the periods follow the server clock
+		createdDate := car.CreatedAt.In(now.Location())
 
 		// Check if the car was sold in the last week
 		if createdDate.After(weekAgo) && createdDate.Before(now) {
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/problem"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// encryptedModels are the models with columns using the encrypted serializer.
+var encryptedModels = map[string]interface{}{
+	"users":          &models.User{},
+	"user_addresses": &models.UserAddress{},
+	"orders":         &models.Order{},
+	"transactions":   &models.Transaction{},
+	"kyc_documents":  &models.KYCDocument{},
+}
+
+type EncryptionController struct {
+	DB *gorm.DB
+	// Files are the KYC scans, sealed with the same keyring as the columns
+	Files KYCFiles
+}
+
+// Reencrypt godoc
+// @Summary Re-encrypt personal data
+// @Description Rewrites encrypted columns and KYC document scans still holding plaintext or sealed with an old key so they use the primary key of FIELD_ENCRYPTION_KEYS (only admin). Run it after adding a new key, old keys and KYC_ENCRYPTION_KEY can be removed once it reports nothing left to do.
+// @Tags encryption
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=object{primary_key=string,rewritten=map[string]int64}}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/encryption/reencrypt [post]
+func (ctrl *EncryptionController) Reencrypt(c *gin.Context) {
+	rewritten := map[string]int64{}
+	for table, model := range encryptedModels {
+		count, err := encryption.Reencrypt(ctrl.DB.WithContext(c.Request.Context()), model)
+		rewritten[table] = count
+		if err != nil {
+			p := problem.From(c, err)
+			p.Data = rewritten
+			problem.Write(c, p)
+			return
+		}
+	}
+
+	var documents []models.KYCDocument
+	rewritten["kyc_files"] = 0
+	err := ctrl.DB.WithContext(c).Where("file_key <> ''").FindInBatches(&documents, 100, func(*gorm.DB, int) error {
+		for _, document := range documents {
+			resealed, err := ctrl.Files.Reseal(c.Request.Context(), document)
+			if err != nil {
+				return fmt.Errorf("kyc document %d: %w", document.ID, err)
+			}
+			if resealed {
+				rewritten["kyc_files"]++
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		p := problem.From(c, err)
+		p.Data = rewritten
+		problem.Write(c, p)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"primary_key": ctrl.Files.Keyring.Primary(), "rewritten": rewritten}})
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// viewerFrom returns the authenticated user set by JwtAuthMiddleware, used to mask personal data.
+func viewerFrom(c *gin.Context) models.Viewer {
+	return models.Viewer{
+		UserID: c.GetUint("user_id"),
+		Role:   c.GetString("user_role"),
+	}
+}
+
+// isReferenced reports whether live rows of model point at id through column, rows in the trash
+// do not count.
+func isReferenced(db *gorm.DB, model interface{}, column string, id interface{}) (bool, error) {
+	var count int64
+	err := db.Model(model).Where(column+" = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// idParam parses the :id path parameter, it writes a 400 and returns false when it is not an ID.
+func idParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// serviceError writes the response for an error returned by a service. Business rule
+// violations keep their code and message, anything else goes through problem.Error.
+func serviceError(c *gin.Context, err error) {
+	var ruleErr *services.Error
+	if !errors.As(err, &ruleErr) {
+		problem.Error(c, err)
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch ruleErr.Kind {
+	case services.NotFound:
+		status = http.StatusNotFound
+	case services.Conflict:
+		status = http.StatusConflict
+	}
+	problem.AbortWithCode(c, status, problem.Code(ruleErr.Code), ruleErr.Message)
+}
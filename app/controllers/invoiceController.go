@@ -2,71 +2,42 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
-	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/services"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type InvoiceController struct {
-	DB *gorm.DB
+	Invoices *services.InvoiceService
 }
 
 // FindAll godoc
 // @Summary Get all invoices
-// @Description Get all invoices
+// @Description Get the invoices of the paid orders of the authenticated user, admins get every invoice
 // @Tags invoices
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Success 200 {object} models.Invoice
+// @Success 200 {object} object{data=[]models.InvoiceDetail}
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/invoices [get]
 func (ctrl *InvoiceController) FindAll(c *gin.Context) {
-	var invoices []models.Invoice
-	if err := ctrl.DB.Preload("Order").Preload("Transaction").Order("created_at DESC").Find(&invoices).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	viewer := viewerFrom(c)
+	invoices, err := ctrl.Invoices.List(c, viewer)
+	if err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	var invoiceDetails []models.InvoiceDetail
+	invoiceDetails := []models.InvoiceDetail{}
 	for _, invoice := range invoices {
-		// Filter untuk hanya memproses invoices dengan status true
-		if invoice.Order.Status {
-			invoiceDetails = append(invoiceDetails, models.InvoiceDetail{
-				ID:            invoice.ID,
-				OrderID:       invoice.OrderID,
-				TransactionID: invoice.TransactionID,
-				CreatedAt:     invoice.CreatedAt,
-				UpdatedAt:     invoice.UpdatedAt,
-				Order: models.OrderDetail{
-					ID:         invoice.Order.ID,
-					UserID:     invoice.Order.UserID,
-					CarID:      invoice.Order.CarID,
-					TotalPrice: invoice.Order.TotalPrice,
-					Status:     invoice.Order.Status,
-					OrderImage: invoice.Order.OrderImage,
-					CreatedAt:  invoice.Order.CreatedAt,
-					UpdatedAt:  invoice.Order.UpdatedAt,
-				},
-				Transaction: models.TransactionDetail{
-					ID:               invoice.Transaction.ID,
-					OrderID:          invoice.Transaction.OrderID,
-					PaymentProvider:  invoice.Transaction.PaymentProvider,
-					NoRek:            invoice.Transaction.NoRek,
-					Amount:           invoice.Transaction.Amount,
-					TransactionDate:  invoice.Transaction.TransactionDate,
-					CreatedAt:        invoice.Transaction.CreatedAt,
-					UpdatedAt:        invoice.Transaction.UpdatedAt,
-				},
-			})
-		}
+		invoiceDetails = append(invoiceDetails, models.NewInvoiceDetail(invoice, viewer))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": invoiceDetails})
 }
 
-
 // FindByID godoc
 // @Summary Get invoice by id
 // @Description Get invoice by id
@@ -74,59 +45,58 @@ func (ctrl *InvoiceController) FindAll(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Order ID"
-// @Success 200 {object} models.Invoice
+// @Success 200 {object} object{data=[]models.InvoiceDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/invoices/{id} [get]
 func (ctrl *InvoiceController) FindByID(c *gin.Context) {
-	var invoice []models.Invoice
-	if err := ctrl.DB.Where("order_id = ?", c.Param("id")).Find(&invoice).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	orderID, ok := idParam(c)
+	if !ok {
+		return
+	}
+	viewer := viewerFrom(c)
+	invoices, err := ctrl.Invoices.ListByOrder(c, viewer, orderID)
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": invoice})
+	invoiceDetails := []models.InvoiceDetail{}
+	for _, invoice := range invoices {
+		invoiceDetails = append(invoiceDetails, models.NewInvoiceDetail(invoice, viewer))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invoiceDetails})
 }
 
 // Create godoc
 // @Summary Create new invoice
-// @Description Create new invoice
+// @Description Issue an invoice for a transaction of a paid order, order_id may be left out
 // @Tags invoices
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param invoice body models.Invoice true "Invoice Data"
-// @Success 200 {object} models.Invoice
+// @Success 200 {object} object{data=models.InvoiceDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/invoices [post]
 func (ctrl *InvoiceController) Create(c *gin.Context) {
 	var req models.Invoice
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	var userId, _ = jwt.ExtractTokenID(c)
-
-	// Cari user berdasarkan userID
-	var user models.User
-	if err := ctrl.DB.Where("id = ?", userId).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		problem.Invalid(c, err)
 		return
 	}
 
-	newInvoice := models.Invoice{
-		OrderID:       req.OrderID,
-		TransactionID: req.TransactionID,
-		CreatedAt:     time.Now(),
-	}
-
-	if err := ctrl.DB.Create(&newInvoice).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	invoice, err := ctrl.Invoices.Create(c, viewerFrom(c), services.InvoiceInput{OrderID: req.OrderID, TransactionID: req.TransactionID})
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": newInvoice})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewInvoiceDetail(invoice, viewerFrom(c))})
 }
 
 // Update godoc
@@ -138,65 +108,49 @@ func (ctrl *InvoiceController) Create(c *gin.Context) {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Invoice ID"
 // @Param invoice body models.Invoice true "Invoice Data"
-// @Success 200 {object} models.Invoice
+// @Success 200 {object} object{data=models.InvoiceDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/invoices/{id} [put]
 func (ctrl *InvoiceController) Update(c *gin.Context) {
-	var invoice models.Invoice
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&invoice).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
-		return
-	}
-
-	var userId, _ = jwt.ExtractTokenID(c)
-
-	// Cari user berdasarkan userID
-	var user models.User
-	if err := ctrl.DB.Where("id = ?", userId).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
 	var req models.Invoice
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
-	// Update fields
-	invoice.OrderID = req.OrderID
-	invoice.TransactionID = req.TransactionID
-	invoice.UpdatedAt = time.Now()
 
-	if err := ctrl.DB.Save(&invoice).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	invoice, err := ctrl.Invoices.Update(c, viewerFrom(c), id, services.InvoiceInput{OrderID: req.OrderID, TransactionID: req.TransactionID})
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": invoice})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewInvoiceDetail(invoice, viewerFrom(c))})
 }
 
 // Delete godoc
 // @Summary Delete invoice
-// @Description Delete invoice
+// @Description Move an invoice to the trash
 // @Tags invoices
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Invoice ID"
-// @Success 200 {object} models.Invoice
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/invoices/{id} [delete]
 func (ctrl *InvoiceController) Delete(c *gin.Context) {
-	var invoice models.Invoice
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&invoice).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
-	if err := ctrl.DB.Delete(&invoice).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.Invoices.Delete(c, viewerFrom(c), id); err != nil {
+		serviceError(c, err)
 		return
 	}
 
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/pkg/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxKYCDocumentSize = 5 << 20
+
+var kycContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// kycNumberValidators checks the document number of each supported document type.
+var kycNumberValidators = map[string]func(string) bool{
+	utils.KYCTypeKTP:  utils.ValidNIK,
+	utils.KYCTypeNPWP: utils.ValidNPWP,
+	utils.KYCTypeSIM:  utils.ValidSIM,
+}
+
+// KYCController handles identity documents buyers submit before paying for a car. Scans are
+// encrypted before they reach the storage and are only ever served to their owner and admins.
+type KYCController struct {
+	DB      *gorm.DB
+	Now     func() time.Time
+	Storage storage.Storage
+	Files   KYCFiles
+}
+
+// FindMine godoc
+// @Summary Get my KYC documents
+// @Description Get the identity documents submitted by the user owning the token and their review status
+// @Tags kyc
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=[]models.KYCDocumentResponse}
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/kyc [get]
+func (ctrl *KYCController) FindMine(c *gin.Context) {
+	var documents []models.KYCDocument
+	if err := ctrl.DB.WithContext(c).Where("user_id = ?", c.GetUint("user_id")).Order("type").Find(&documents).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": newKYCDocumentResponses(documents, viewerFrom(c))})
+}
+
+// Submit godoc
+// @Summary Submit a KYC document
+// @Description Upload a KTP, NPWP or SIM scan (JPEG, PNG or PDF of at most 5 MB) with its number. A rejected or pending document of the same type is replaced, an approved one cannot be replaced.
+// @Tags kyc
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param type formData string true "Document type" Enums(ktp, npwp, sim)
+// @Param number formData string true "NIK, NPWP or SIM number"
+// @Param file formData file true "Document scan"
+// @Success 201 {object} object{data=models.KYCDocumentResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/kyc [post]
+func (ctrl *KYCController) Submit(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	docType := c.PostForm("type")
+	number := utils.DigitsOnly(c.PostForm("number"))
+
+	validNumber, ok := kycNumberValidators[docType]
+	if !ok {
+		problem.Abort(c, http.StatusBadRequest, "type must be one of ktp, npwp or sim")
+		return
+	}
+	if !validNumber(number) {
+		problem.Abort(c, http.StatusBadRequest, fmt.Sprintf("invalid %s number", docType))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	if fileHeader.Size > maxKYCDocumentSize {
+		problem.Abort(c, http.StatusBadRequest, "file must be at most 5 MB")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxKYCDocumentSize+1))
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	contentType := http.DetectContentType(content)
+	if !kycContentTypes[contentType] || len(content) > maxKYCDocumentSize {
+		problem.Abort(c, http.StatusBadRequest, "file must be a JPEG, PNG or PDF of at most 5 MB")
+		return
+	}
+
+	var document models.KYCDocument
+	err = ctrl.DB.WithContext(c).Where("user_id = ? AND type = ?", userID, docType).First(&document).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		problem.Error(c, err)
+		return
+	}
+	if document.Status == utils.KYCStatusApproved {
+		problem.Abort(c, http.StatusConflict, "this document has already been approved")
+		return
+	}
+
+	// The same NIK or NPWP may only back one account
+	numberIndex, err := models.KYCNumberBlindIndex(docType, number)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	var duplicates int64
+	err = ctrl.DB.WithContext(c).Model(&models.KYCDocument{}).
+		Where("number_bidx = ? AND user_id <> ? AND status <> ?", numberIndex, userID, utils.KYCStatusRejected).
+		Count(&duplicates).Error
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if duplicates > 0 {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, fmt.Sprintf("this %s number is already used by another account", docType))
+		return
+	}
+
+	// Private key, outside storage.PublicPrefix so it is never served statically
+	key := fmt.Sprintf("kyc/%d/%s-%s.enc", userID, docType, utils.RandomToken()[:16])
+	if err := ctrl.Files.Write(c.Request.Context(), key, content); err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to store document")
+		return
+	}
+
+	oldKey := document.FileKey
+	document.UserID = userID
+	document.Type = docType
+	document.Number = number
+	document.FileKey = key
+	document.ContentType = contentType
+	document.Status = utils.KYCStatusPending
+	document.RejectionReason = ""
+	document.ReviewedBy = nil
+	document.ReviewedAt = nil
+
+	if err := ctrl.DB.WithContext(c).Save(&document).Error; err != nil {
+		if err := ctrl.Storage.Delete(c.Request.Context(), key); err != nil {
+			logging.FromContext(c.Request.Context()).Error("delete unsaved kyc document", "key", key, "error", err)
+		}
+		problem.Error(c, err)
+		return
+	}
+
+	if oldKey != "" {
+		if err := ctrl.Storage.Delete(c.Request.Context(), oldKey); err != nil {
+			logging.FromContext(c.Request.Context()).Error("delete old kyc document", "key", oldKey, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": models.NewKYCDocumentResponse(document, viewerFrom(c))})
+}
+
+// DownloadMine godoc
+// @Summary Download my KYC document
+// @Description Download the decrypted scan of a document submitted by the user owning the token
+// @Tags kyc
+// @Produce octet-stream
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Document ID"
+// @Success 200 {file} file
+// @Failure 404 {object} problem.Problem
+// @Router /api/me/kyc/{id}/file [get]
+func (ctrl *KYCController) DownloadMine(c *gin.Context) {
+	var document models.KYCDocument
+	if err := ctrl.DB.WithContext(c).Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&document).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "document not found")
+		return
+	}
+
+	ctrl.serveFile(c, document)
+}
+
+// FindAll godoc
+// @Summary Get the KYC review queue
+// @Description Get KYC documents filtered by status, pending documents by default, oldest first
+// @Tags kyc
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param status query string false "Document status" Enums(pending, approved, rejected)
+// @Success 200 {object} object{data=[]models.KYCDocumentResponse}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/kyc [get]
+func (ctrl *KYCController) FindAll(c *gin.Context) {
+	status := c.DefaultQuery("status", utils.KYCStatusPending)
+
+	var documents []models.KYCDocument
+	if err := ctrl.DB.WithContext(c).Preload("User.Role").Where("status = ?", status).Order("updated_at").Find(&documents).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": newKYCDocumentResponses(documents, viewerFrom(c))})
+}
+
+// Download godoc
+// @Summary Download a KYC document
+// @Description Download the decrypted scan of any KYC document for review
+// @Tags kyc
+// @Produce octet-stream
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Document ID"
+// @Success 200 {file} file
+// @Failure 404 {object} problem.Problem
+// @Router /api/cms/kyc/{id}/file [get]
+func (ctrl *KYCController) Download(c *gin.Context) {
+	var document models.KYCDocument
+	if err := ctrl.DB.WithContext(c).First(&document, c.Param("id")).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "document not found")
+		return
+	}
+
+	ctrl.serveFile(c, document)
+}
+
+// Approve godoc
+// @Summary Approve a KYC document
+// @Description Approve a pending KYC document. An approved KTP lets the user pay for orders.
+// @Tags kyc
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Document ID"
+// @Success 200 {object} object{data=models.KYCDocumentResponse}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Router /api/cms/kyc/{id}/approve [post]
+func (ctrl *KYCController) Approve(c *gin.Context) {
+	ctrl.review(c, utils.KYCStatusApproved, "")
+}
+
+// Reject godoc
+// @Summary Reject a KYC document
+// @Description Reject a pending KYC document with a reason shown to the user, who can then submit a new one
+// @Tags kyc
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Document ID"
+// @Param body body models.KYCRejectRequest true "Rejection reason"
+// @Success 200 {object} object{data=models.KYCDocumentResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/kyc/{id}/reject [post]
+func (ctrl *KYCController) Reject(c *gin.Context) {
+	var req models.KYCRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	ctrl.review(c, utils.KYCStatusRejected, req.Reason)
+}
+
+func (ctrl *KYCController) review(c *gin.Context, status, reason string) {
+	var document models.KYCDocument
+	if err := ctrl.DB.WithContext(c).First(&document, c.Param("id")).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "document not found")
+		return
+	}
+	if document.Status != utils.KYCStatusPending {
+		problem.Abort(c, http.StatusConflict, "only pending documents can be reviewed")
+		return
+	}
+
+	reviewerID := c.GetUint("user_id")
+	now := ctrl.Now()
+	document.Status = status
+	document.RejectionReason = reason
+	document.ReviewedBy = &reviewerID
+	document.ReviewedAt = &now
+
+	if err := ctrl.DB.WithContext(c).Save(&document).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	if err := ctrl.DB.WithContext(c).Preload("User.Role").First(&document, document.ID).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": models.NewKYCDocumentResponse(document, viewerFrom(c))})
+}
+
+func (ctrl *KYCController) serveFile(c *gin.Context, document models.KYCDocument) {
+	content, err := ctrl.Files.Read(c.Request.Context(), document)
+	if errors.Is(err, storage.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, "document file not found")
+		return
+	}
+	if err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to read document")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s-%d"`, document.Type, document.ID))
+	c.Data(http.StatusOK, document.ContentType, content)
+}
+
+// KYCFiles keeps the scans of KYC documents in the storage, sealed with the keyring of the
+// encrypted columns so they are rotated along with them.
+type KYCFiles struct {
+	Storage storage.Storage
+	Keyring *encryption.Keyring
+	// Legacy opens the scans sealed with KYC_ENCRYPTION_KEY before they moved onto the keyring,
+	// nil once Reseal rewrote them all.
+	Legacy *encryption.Cipher
+}
+
+// Write seals content and stores it at key, the key is authenticated with the content so a scan
+// cannot be swapped for another.
+func (f KYCFiles) Write(ctx context.Context, key string, content []byte) error {
+	sealed, err := f.Keyring.Seal(content, key)
+	if err != nil {
+		return err
+	}
+	return f.Storage.Put(ctx, key, bytes.NewReader(sealed), "application/octet-stream")
+}
+
+// Read loads and decrypts the scan of a document.
+func (f KYCFiles) Read(ctx context.Context, document models.KYCDocument) ([]byte, error) {
+	sealed, err := f.load(ctx, document.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	return f.open(sealed, document.FileKey)
+}
+
+// Reseal seals the scan of a document again with the primary key when it was sealed with an
+// old key or with KYC_ENCRYPTION_KEY, it reports whether the file was rewritten.
+func (f KYCFiles) Reseal(ctx context.Context, document models.KYCDocument) (bool, error) {
+	sealed, err := f.load(ctx, document.FileKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil || f.Keyring.SealedWithPrimary(sealed) {
+		return false, err
+	}
+	content, err := f.open(sealed, document.FileKey)
+	if err != nil {
+		return false, err
+	}
+	return true, f.Write(ctx, document.FileKey, content)
+}
+
+func (f KYCFiles) load(ctx context.Context, key string) ([]byte, error) {
+	file, err := f.Storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (f KYCFiles) open(sealed []byte, key string) ([]byte, error) {
+	if encryption.IsSealed(sealed) {
+		return f.Keyring.Open(sealed, key)
+	}
+	if f.Legacy == nil {
+		return nil, errors.New("the document was sealed with KYC_ENCRYPTION_KEY, which is not set")
+	}
+	return f.Legacy.Decrypt(sealed, []byte(key))
+}
+
+func newKYCDocumentResponses(documents []models.KYCDocument, viewer models.Viewer) []models.KYCDocumentResponse {
+	responses := make([]models.KYCDocumentResponse, 0, len(documents))
+	for _, document := range documents {
+		responses = append(responses, models.NewKYCDocumentResponse(document, viewer))
+	}
+	return responses
+}
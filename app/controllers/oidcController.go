@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/oidc"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateCookie   = "oidc_state"
+	oidcStateLifespan = 10 * time.Minute
+)
+
+var (
+	errUnverifiedEmail   = errors.New("the identity provider did not verify this email address")
+	errUnverifiedAccount = errors.New("an account with this email exists but its email is not verified, log in with the password and verify the email first")
+)
+
+type OIDCController struct {
+	DB        *gorm.DB
+	Now       func() time.Time
+	Providers map[string]*oidc.Provider
+	// SuccessRedirect receives the token of a completed login in its fragment, OIDC_SUCCESS_REDIRECT.
+	// The token is answered as JSON while it is empty.
+	SuccessRedirect string
+}
+
+// Login godoc
+// @Summary Start social login.
+// @Description Redirects to the identity provider using the authorization code flow with PKCE.
+// @Tags Auth
+// @Param provider path string true "Provider name, e.g. google"
+// @Param login_hint query string false "Email hint forwarded to the provider"
+// @Success 302 {string} string "Redirect to the identity provider"
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Failure 502 {object} problem.Problem
+// @Router /api/auth/oidc/{provider}/login [get]
+func (ctrl *OIDCController) Login(c *gin.Context) {
+	provider, ok := ctrl.Providers[c.Param("provider")]
+	if !ok {
+		problem.Abort(c, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	state := jwt.OIDCState{
+		Provider:     provider.Name(),
+		State:        utils.RandomToken(),
+		Nonce:        utils.RandomToken(),
+		CodeVerifier: utils.RandomToken(),
+	}
+
+	extra := url.Values{}
+	if hint := c.Query("login_hint"); hint != "" {
+		extra.Set("login_hint", hint)
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.CodeVerifier, extra)
+	if err != nil {
+		problem.Abort(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	cookie, err := jwt.GenerateOIDCStateToken(state, oidcStateLifespan)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, cookie, int(oidcStateLifespan.Seconds()), "/api/auth/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Finish social login.
+// @Description Exchanges the authorization code, links the identity to a user by verified email and returns our own JWT.
+// @Description An existing account is only linked when both the provider and the account verified the email.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} object{token=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/auth/oidc/{provider}/callback [get]
+func (ctrl *OIDCController) Callback(c *gin.Context) {
+	provider, ok := ctrl.Providers[c.Param("provider")]
+	if !ok {
+		problem.Abort(c, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		problem.Abort(c, http.StatusBadRequest, "login failed at identity provider: "+errCode)
+		return
+	}
+
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "login session expired, please try again")
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+
+	state, err := jwt.ParseOIDCStateToken(cookie)
+	if err != nil || state.Provider != provider.Name() || state.State != c.Query("state") {
+		problem.Abort(c, http.StatusBadRequest, "invalid login state")
+		return
+	}
+
+	tokens, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.CodeVerifier)
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(c.Request.Context(), tokens.IDToken, state.Nonce)
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	user, err := ctrl.findOrLinkUser(c, provider.Name(), claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errUnverifiedEmail):
+			problem.Abort(c, http.StatusForbidden, err.Error())
+		case errors.Is(err, errUnverifiedAccount):
+			problem.Abort(c, http.StatusConflict, err.Error())
+		default:
+			problem.Error(c, err)
+		}
+		return
+	}
+
+	token, err := jwt.GenerateToken(user.ID, uint(user.RoleID))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	// Browser based frontends receive the token in the URL fragment so it is never sent to a server.
+	if ctrl.SuccessRedirect != "" {
+		c.Redirect(http.StatusFound, ctrl.SuccessRedirect+"#token="+url.QueryEscape(token))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// findOrLinkUser resolves the local user for an external identity. Known identities log in
+// directly, otherwise the identity is linked to the user with the same email when both sides
+// verified it, and a new user is registered when there is none. Linking an account whose
+// email was never verified would hand it to whoever registered the address at the provider.
+func (ctrl *OIDCController) findOrLinkUser(ctx context.Context, provider string, claims *oidc.Claims) (*models.User, error) {
+	var user models.User
+
+	err := ctrl.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+		if err == nil {
+			return tx.First(&user, identity.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if claims.Email == "" || !claims.EmailVerified {
+			return errUnverifiedEmail
+		}
+
+		email := strings.ToLower(claims.Email)
+		err = tx.Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user, err = ctrl.registerExternalUser(tx, email)
+		case err == nil && user.EmailVerifiedAt == nil:
+			err = errUnverifiedAccount
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// registerExternalUser creates a user for a first time social login. The password is random
+// so the account can only log in through the identity provider.
+func (ctrl *OIDCController) registerExternalUser(tx *gorm.DB, email string) (models.User, error) {
+	base := usernameInvalidChars.ReplaceAllString(strings.Split(email, "@")[0], "")
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for i := 0; ; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return models.User{}, err
+		}
+		if count == 0 {
+			break
+		}
+		if i == 5 {
+			return models.User{}, errors.New("could not generate a unique username")
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return models.User{}, err
+		}
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+
+	hashedPassword, err := utils.HashPassword(utils.RandomToken())
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// The identity provider verified the email before it was linked
+	verifiedAt := ctrl.Now()
+	user := models.User{
+		Username:        username,
+		Email:           email,
+		EmailVerifiedAt: &verifiedAt,
+		Password:        hashedPassword,
+		RoleID:          utils.IDRoleUser,
+	}
+	return user, tx.Create(&user).Error
+}
@@ -2,65 +2,51 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
-	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/services"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type OrderController struct {
-	DB *gorm.DB
+	Orders *services.OrderService
 }
 
 // FindAll godoc
 // @Summary Get all orders
-// @Description Get all orders
+// @Description Get all orders, admins can keep only the ones delivered to an exact phone number
 // @Tags orders
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Success 200 {object} models.Order
+// @Param phone query string false "Delivery phone number, e.g. 081234567890 or +6281234567890 (only admin)"
+// @Success 200 {object} object{data=[]models.OrderDetail}
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/orders [get]
 func (ctrl *OrderController) FindAll(c *gin.Context) {
 	var orders []models.Order
-	if err := ctrl.DB.Preload("Car").Preload("User").Order("created_at DESC").Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	var err error
+	if phone := c.Query("phone"); phone != "" {
+		// Looking up who a phone number belongs to is for the staff only
+		if c.GetString("user_role") != utils.RoleAdmin {
+			problem.Abort(c, http.StatusForbidden, "only admins can search orders by phone")
+			return
+		}
+		orders, err = ctrl.Orders.ListByDeliveryPhone(c, phone)
+	} else {
+		orders, err = ctrl.Orders.List(c)
+	}
+	if err != nil {
+		problem.Error(c, err)
 		return
 	}
 
+	viewer := viewerFrom(c)
 	var orderDetails []models.OrderDetail
 	for _, order := range orders {
-		orderDetails = append(orderDetails, models.OrderDetail{
-			ID:         order.ID,
-			UserID:     order.UserID,
-			CarID:      order.CarID,
-			TotalPrice: order.TotalPrice,
-			Status:     order.Status,
-			OrderImage: order.OrderImage,
-			CreatedAt:  order.CreatedAt,
-			UpdatedAt:  order.UpdatedAt,
-			Car: models.CarDetail{
-				ID:          order.Car.ID,
-				Name:        order.Car.Name,
-				Description: order.Car.Description,
-				ImageCar:    order.Car.ImageCar,
-				Price:       order.Car.Price,
-				TypeID:      order.Car.TypeID,
-				BrandID:     order.Car.BrandID,
-				IsSecond:    order.Car.IsSecond,
-				CreatedAt:   order.Car.CreatedAt,
-				UpdatedAt:   order.Car.UpdatedAt,
-			},
-			User: models.UserList{
-				ID:          order.User.ID,
-				Username:    order.User.Username,
-				PhoneNumber: order.User.PhoneNumber,
-				Address:     order.User.Address,
-				Email:       order.User.Email,
-				RoleName:    order.User.Role.RoleName, // Fetching RoleName from the Role association
-			},
-		})
+		orderDetails = append(orderDetails, models.NewOrderDetail(order, viewer))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": orderDetails})
@@ -73,135 +59,125 @@ func (ctrl *OrderController) FindAll(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "User ID"
-// @Success 200 {object} models.Order
+// @Success 200 {object} object{data=[]models.OrderDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/orders/{id} [get]
 func (ctrl *OrderController) FindByID(c *gin.Context) {
-	var order []models.Order
-	if err := ctrl.DB.Preload("Car").Preload("User").Where("user_id = ?", c.Param("id")).Find(&order).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	userID, ok := idParam(c)
+	if !ok {
+		return
+	}
+	orders, err := ctrl.Orders.ListByUser(c, userID)
+	if err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": order})
+	viewer := viewerFrom(c)
+	orderDetails := []models.OrderDetail{}
+	for _, order := range orders {
+		orderDetails = append(orderDetails, models.NewOrderDetail(order, viewer))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": orderDetails})
 }
 
 // Create godoc
 // @Summary Create new order
-// @Description Create new order
+// @Description Order a car for the authenticated user. The total price is the price of the car, an unpaid order reserves the car and paying needs an approved KTP.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param order body models.Order true "Order Data"
-// @Success 200 {object} models.Order
+// @Success 200 {object} object{data=models.OrderDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/orders [post]
 func (ctrl *OrderController) Create(c *gin.Context) {
 	var req models.Order
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	var userId, _ = jwt.ExtractTokenID(c)
-
-	// Cari user berdasarkan userID
-	var user models.User
-	if err := ctrl.DB.Where("id = ?", userId).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		problem.Invalid(c, err)
 		return
 	}
 
-	newOrder := models.Order{
-		UserID:     userId,
+	order, err := ctrl.Orders.Create(c, services.OrderInput{
+		UserID:     c.GetUint("user_id"),
 		CarID:      req.CarID,
 		TotalPrice: req.TotalPrice,
 		Status:     req.Status,
 		OrderImage: req.OrderImage,
-		CreatedAt:  time.Now(),
-	}
-
-	if err := ctrl.DB.Create(&newOrder).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		AddressID:  req.AddressID,
+	})
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": newOrder})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewOrderDetail(order, viewerFrom(c))})
 }
 
 // Update godoc
 // @Summary Update order
-// @Description Update order
+// @Description Update an order, users can only update their own. The buyer cannot be changed. Paying marks the car sold and needs an approved KTP of the buyer, a paid order keeps its car and price and cannot become unpaid.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Order ID"
 // @Param order body models.Order true "Order Data"
-// @Success 200 {object} models.Order
+// @Success 200 {object} object{data=models.OrderDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/orders/{id} [put]
 func (ctrl *OrderController) Update(c *gin.Context) {
-	var order models.Order
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&order).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
-	// var userId, _ = jwt.ExtractTokenID(c)
-
-	// Cari user berdasarkan userID
-	// var user models.User
-	// if err := ctrl.DB.Where("id = ?", userId).First(&user).Error; err != nil {
-	// 	if err == gorm.ErrRecordNotFound {
-	// 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-	// 	} else {
-	// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	// 	}
-	// 	return
-	// }
-
 	var req models.Order
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
-	// Update fields
-	order.UserID = req.UserID
-	order.CarID = req.CarID
-	order.TotalPrice = req.TotalPrice
-	order.Status = req.Status
-	order.OrderImage = req.OrderImage
-	order.UpdatedAt = time.Now()
-
-	if err := ctrl.DB.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+
+	order, err := ctrl.Orders.Update(c, viewerFrom(c), id, services.OrderInput{
+		CarID:      req.CarID,
+		TotalPrice: req.TotalPrice,
+		Status:     req.Status,
+		OrderImage: req.OrderImage,
+		AddressID:  req.AddressID,
+	})
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": order})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewOrderDetail(order, viewerFrom(c))})
 }
 
 // Delete godoc
 // @Summary Delete order
-// @Description Delete order
+// @Description Move an order to the trash and put a sold car back on sale, users can only delete their own. Orders with transactions or invoices cannot be deleted
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Order ID"
-// @Success 200 {object} models.Order
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/orders/{id} [delete]
 func (ctrl *OrderController) Delete(c *gin.Context) {
-	var order models.Order
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&order).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
-	if err := ctrl.DB.Delete(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.Orders.Delete(c, viewerFrom(c), id); err != nil {
+		serviceError(c, err)
 		return
 	}
 
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PartnerController struct {
+	DB *gorm.DB
+}
+
+// GetCars godoc
+// @Summary Get available cars for partners
+// @Description Get every car that is not sold yet. Requires an API key with the cars:read scope.
+// @Tags partner
+// @Produce json
+// @Param X-API-Key header string true "Partner API key"
+// @Success 200 {object} object{data=[]models.Car}
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/partner/cars [get]
+func (ctrl *PartnerController) GetCars(c *gin.Context) {
+	var cars []models.Car
+	if err := ctrl.DB.WithContext(c).Preload("Type").Preload("Brand").Where("sold = ?", false).Order("created_at DESC").Find(&cars).Error; err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to retrieve cars")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": cars})
+}
+
+// GetCarByID godoc
+// @Summary Get a car for partners
+// @Description Get details of a specific car. Requires an API key with the cars:read scope.
+// @Tags partner
+// @Produce json
+// @Param X-API-Key header string true "Partner API key"
+// @Param id path int true "Car ID"
+// @Success 200 {object} object{data=models.Car}
+// @Failure 404 {object} problem.Problem
+// @Failure 400 {object} problem.Problem
+// @Router /api/partner/cars/{id} [get]
+func (ctrl *PartnerController) GetCarByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var car models.Car
+	if err := ctrl.DB.WithContext(c).Preload("Type").Preload("Brand").First(&car, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Car not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": car})
+}
+
+// CreateLead godoc
+// @Summary Push a lead
+// @Description Push a buyer lead for a car. Requires an API key with the leads:write scope.
+// @Tags partner
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "Partner API key"
+// @Param lead body models.LeadRequest true "Lead Data"
+// @Success 201 {object} object{data=models.Lead}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/partner/leads [post]
+func (ctrl *PartnerController) CreateLead(c *gin.Context) {
+	var req models.LeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	var car models.Car
+	if err := ctrl.DB.WithContext(c).First(&car, req.CarID).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "Car not found")
+		return
+	}
+
+	apiKeyID := c.GetUint("api_key_id")
+	var apiKey models.APIKey
+	ctrl.DB.WithContext(c).First(&apiKey, apiKeyID)
+
+	lead := models.Lead{
+		APIKeyID:    apiKeyID,
+		CarID:       req.CarID,
+		Name:        req.Name,
+		Email:       req.Email,
+		PhoneNumber: req.PhoneNumber,
+		Message:     req.Message,
+		Source:      apiKey.Name,
+		Car:         car,
+	}
+
+	if err := ctrl.DB.WithContext(c).Create(&lead).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": lead})
+}
+
+// FindAllLeads godoc
+// @Summary Get all leads
+// @Description Get every lead pushed by partners (only admin)
+// @Tags partner
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=[]models.Lead}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/leads [get]
+func (ctrl *PartnerController) FindAllLeads(c *gin.Context) {
+	var leads []models.Lead
+	if err := ctrl.DB.WithContext(c).Preload("Car").Order("created_at DESC").Find(&leads).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leads})
+}
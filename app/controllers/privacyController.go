@@ -0,0 +1,545 @@
+package controllers
+
+import (
+	"archive/zip"
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/audit"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var kycFileExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+var errOpenOrders = errors.New("the user still has unpaid orders, cancel them before erasing the account")
+
+// PrivacyController implements the data subject rights of UU PDP: exporting everything stored
+// about a user and erasing the account on request.
+type PrivacyController struct {
+	DB      *gorm.DB
+	Now     func() time.Time
+	Storage storage.Storage
+	Files   KYCFiles
+}
+
+// Export godoc
+// @Summary Export my data
+// @Description Export everything stored about the user owning the token. The default ZIP archive also contains the KYC document scans, format=json returns the data only.
+// @Tags privacy
+// @Produce json
+// @Produce application/zip
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param format query string false "Archive format" Enums(zip, json)
+// @Success 200 {object} object{data=models.DataExport}
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/export [get]
+func (ctrl *PrivacyController) Export(c *gin.Context) {
+	export, documents, err := ctrl.collect(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, gin.H{"data": export})
+		return
+	}
+
+	filename := fmt.Sprintf("carzone-data-%d-%s.zip", export.Profile.ID, export.ExportedAt.Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	if err := ctrl.writeArchive(c.Request.Context(), zip.NewWriter(c.Writer), export, documents); err != nil {
+		// Headers are already sent, all we can do is cut the archive short
+		logging.FromContext(c.Request.Context()).Error("data export", "error", err)
+	}
+}
+
+// collect gathers the export of a user, together with the KYC documents whose scans go in the archive.
+func (ctrl *PrivacyController) collect(ctx context.Context, userID uint) (models.DataExport, []models.KYCDocument, error) {
+	db := ctrl.DB.WithContext(ctx)
+	export := models.DataExport{ExportedAt: ctrl.Now()}
+	viewer := models.Viewer{UserID: userID}
+
+	var user models.User
+	if err := db.Preload("Role").First(&user, userID).Error; err != nil {
+		return export, nil, err
+	}
+	export.Profile = models.NewUserResponse(user, viewer)
+
+	export.Addresses = []models.UserAddress{}
+	if err := db.Where("user_id = ?", userID).Find(&export.Addresses).Error; err != nil {
+		return export, nil, err
+	}
+
+	// Trashed orders, payments and invoices are still held about the user, they are exported too
+	var orders []models.Order
+	if err := db.Unscoped().Preload("Car").Preload("User.Role").Where("user_id = ?", userID).Find(&orders).Error; err != nil {
+		return export, nil, err
+	}
+	export.Orders = []models.OrderDetail{}
+	for _, order := range orders {
+		export.Orders = append(export.Orders, models.NewOrderDetail(order, viewer))
+	}
+
+	var transactions []models.Transaction
+	err := db.Unscoped().Preload("Order.Car").Preload("Order.User.Role").
+		Joins("JOIN orders ON orders.id = transactions.order_id").
+		Where("orders.user_id = ?", userID).Find(&transactions).Error
+	if err != nil {
+		return export, nil, err
+	}
+	export.Transactions = []models.TransactionDetail{}
+	for _, transaction := range transactions {
+		export.Transactions = append(export.Transactions, models.NewTransactionDetail(transaction, viewer))
+	}
+
+	var invoices []models.Invoice
+	err = db.Unscoped().Preload("Order.Car").Preload("Order.User.Role").Preload("Transaction").
+		Joins("JOIN orders ON orders.id = invoices.order_id").
+		Where("orders.user_id = ?", userID).Find(&invoices).Error
+	if err != nil {
+		return export, nil, err
+	}
+	export.Invoices = []models.InvoiceDetail{}
+	for _, invoice := range invoices {
+		export.Invoices = append(export.Invoices, models.NewInvoiceDetail(invoice, viewer))
+	}
+
+	export.LinkedAccounts = []models.UserIdentity{}
+	if err := db.Where("user_id = ?", userID).Find(&export.LinkedAccounts).Error; err != nil {
+		return export, nil, err
+	}
+
+	var documents []models.KYCDocument
+	if err := db.Where("user_id = ?", userID).Find(&documents).Error; err != nil {
+		return export, nil, err
+	}
+	export.KYCDocuments = newKYCDocumentResponses(documents, viewer)
+
+	export.ErasureRequests = []models.ErasureRequest{}
+	if err := db.Where("user_id = ?", userID).Find(&export.ErasureRequests).Error; err != nil {
+		return export, nil, err
+	}
+
+	return export, documents, nil
+}
+
+func (ctrl *PrivacyController) writeArchive(ctx context.Context, archive *zip.Writer, export models.DataExport, documents []models.KYCDocument) error {
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"addresses.json", export.Addresses},
+		{"orders.json", export.Orders},
+		{"transactions.json", export.Transactions},
+		{"invoices.json", export.Invoices},
+		{"linked_accounts.json", export.LinkedAccounts},
+		{"kyc_documents.json", export.KYCDocuments},
+		{"erasure_requests.json", export.ErasureRequests},
+	}
+	for _, section := range sections {
+		w, err := archive.Create(section.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+
+	for _, document := range documents {
+		content, err := ctrl.Files.Read(ctx, document)
+		if err != nil {
+			return fmt.Errorf("kyc document %d: %w", document.ID, err)
+		}
+		w, err := archive.Create("documents/" + document.Type + kycFileExtensions[document.ContentType])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// FindMyErasureRequests godoc
+// @Summary Get my erasure requests
+// @Description Get the account erasure requests of the user owning the token, newest first
+// @Tags privacy
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=[]models.ErasureRequest}
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/erasure-requests [get]
+func (ctrl *PrivacyController) FindMyErasureRequests(c *gin.Context) {
+	requests := []models.ErasureRequest{}
+	if err := ctrl.DB.WithContext(c).Where("user_id = ?", c.GetUint("user_id")).Order("created_at DESC").Find(&requests).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// RequestErasure godoc
+// @Summary Request account erasure
+// @Description Ask for the account of the user owning the token to be erased, confirmed with the password. Once an admin approves it personal data is anonymized, orders, transactions and invoices are kept without it as required for tax records.
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param body body models.ErasureRequestInput true "Password confirmation"
+// @Success 201 {object} object{data=models.ErasureRequest}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/erasure-requests [post]
+func (ctrl *PrivacyController) RequestErasure(c *gin.Context) {
+	var req models.ErasureRequestInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	var user models.User
+	if err := ctrl.DB.WithContext(c).First(&user, c.GetUint("user_id")).Error; err != nil {
+		problem.Abort(c, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		problem.Abort(c, http.StatusUnauthorized, "password is incorrect")
+		return
+	}
+
+	var pending int64
+	if err := ctrl.DB.WithContext(c).Model(&models.ErasureRequest{}).Where("user_id = ? AND status = ?", user.ID, utils.ErasureStatusPending).Count(&pending).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if pending > 0 {
+		problem.Abort(c, http.StatusConflict, "an erasure request is already pending")
+		return
+	}
+
+	request := models.ErasureRequest{
+		UserID: user.ID,
+		Status: utils.ErasureStatusPending,
+		Reason: req.Reason,
+	}
+	if err := ctrl.DB.WithContext(c).Create(&request).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": request})
+}
+
+// CancelErasure godoc
+// @Summary Cancel my erasure request
+// @Description Cancel the pending erasure request of the user owning the token
+// @Tags privacy
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Erasure request ID"
+// @Success 200 {object} object{data=models.ErasureRequest}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/erasure-requests/{id} [delete]
+func (ctrl *PrivacyController) CancelErasure(c *gin.Context) {
+	var request models.ErasureRequest
+	if err := ctrl.DB.WithContext(c).Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&request).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "erasure request not found")
+		return
+	}
+	if request.Status != utils.ErasureStatusPending {
+		problem.Abort(c, http.StatusConflict, "only pending requests can be cancelled")
+		return
+	}
+
+	now := ctrl.Now()
+	request.Status = utils.ErasureStatusCancelled
+	request.ProcessedAt = &now
+	if err := ctrl.DB.WithContext(c).Save(&request).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// FindErasureRequests godoc
+// @Summary Get erasure requests
+// @Description Get account erasure requests filtered by status, pending requests by default, oldest first (only admin)
+// @Tags privacy
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param status query string false "Request status" Enums(pending, completed, rejected, cancelled)
+// @Success 200 {object} object{data=[]models.ErasureRequest}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/erasure-requests [get]
+func (ctrl *PrivacyController) FindErasureRequests(c *gin.Context) {
+	requests := []models.ErasureRequest{}
+	err := ctrl.DB.WithContext(c).Where("status = ?", c.DefaultQuery("status", utils.ErasureStatusPending)).Order("created_at").Find(&requests).Error
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// ApproveErasure godoc
+// @Summary Approve an erasure request
+// @Description Anonymize the account of a pending erasure request (only admin). Addresses, linked accounts and KYC documents are deleted, orders, transactions and invoices are kept without personal data. Fails while the user has unpaid orders.
+// @Tags privacy
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Erasure request ID"
+// @Success 200 {object} object{data=models.ErasureRequest}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/erasure-requests/{id}/approve [post]
+func (ctrl *PrivacyController) ApproveErasure(c *gin.Context) {
+	request, ok := ctrl.pendingRequest(c)
+	if !ok {
+		return
+	}
+
+	var fileKeys []string
+	err := ctrl.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		fileKeys, err = ctrl.eraseUser(tx, request.UserID)
+		if err != nil {
+			return err
+		}
+
+		now := ctrl.Now()
+		processedBy := c.GetUint("user_id")
+		request.Status = utils.ErasureStatusCompleted
+		request.ProcessedBy = &processedBy
+		request.ProcessedAt = &now
+		return tx.Save(&request).Error
+	})
+	if errors.Is(err, errOpenOrders) {
+		problem.Abort(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	// Files go only after the commit, a failed erasure must not lose documents
+	for _, key := range fileKeys {
+		if err := ctrl.Storage.Delete(c.Request.Context(), key); err != nil {
+			logging.FromContext(c.Request.Context()).Error("erasure: delete file", "key", key, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// RejectErasure godoc
+// @Summary Reject an erasure request
+// @Description Reject a pending erasure request with a reason, e.g. an open dispute (only admin)
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "Erasure request ID"
+// @Param body body models.ErasureRejectRequest true "Rejection reason"
+// @Success 200 {object} object{data=models.ErasureRequest}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/erasure-requests/{id}/reject [post]
+func (ctrl *PrivacyController) RejectErasure(c *gin.Context) {
+	var req models.ErasureRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	request, ok := ctrl.pendingRequest(c)
+	if !ok {
+		return
+	}
+
+	now := ctrl.Now()
+	processedBy := c.GetUint("user_id")
+	request.Status = utils.ErasureStatusRejected
+	request.RejectionReason = req.Reason
+	request.ProcessedBy = &processedBy
+	request.ProcessedAt = &now
+	if err := ctrl.DB.WithContext(c).Save(&request).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+func (ctrl *PrivacyController) pendingRequest(c *gin.Context) (models.ErasureRequest, bool) {
+	var request models.ErasureRequest
+	if err := ctrl.DB.WithContext(c).First(&request, c.Param("id")).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "erasure request not found")
+		return request, false
+	}
+	if request.Status != utils.ErasureStatusPending {
+		problem.Abort(c, http.StatusConflict, "only pending requests can be processed")
+		return request, false
+	}
+	return request, true
+}
+
+// eraseUser anonymizes a user inside tx and returns the storage keys of files to delete once it
+// commits. Financial records stay, with only what tax law needs: amounts, dates, the car and the
+// city of delivery.
+func (ctrl *PrivacyController) eraseUser(tx *gorm.DB, userID uint) ([]string, error) {
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var openOrders int64
+	if err := tx.Model(&models.Order{}).Where("user_id = ? AND status = ?", userID, false).Count(&openOrders).Error; err != nil {
+		return nil, err
+	}
+	if openOrders > 0 {
+		return nil, errOpenOrders
+	}
+
+	var fileKeys []string
+	var documents []models.KYCDocument
+	if err := tx.Where("user_id = ?", userID).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	for _, document := range documents {
+		fileKeys = append(fileKeys, document.FileKey)
+	}
+	if baseURL := ctrl.Storage.URL(""); user.AvatarURL != "" && strings.HasPrefix(user.AvatarURL, baseURL) {
+		fileKeys = append(fileKeys, storage.PublicPrefix+strings.TrimPrefix(user.AvatarURL, baseURL))
+	}
+
+	// Keep the city so deliveries still add up in reports, drop who and where exactly. Hooks do
+	// not run for UpdateColumns, the blind index of the phone number is cleared here too
+	delivery := map[string]interface{}{
+		"address_id":                 nil,
+		"delivery_recipient_name":    "",
+		"delivery_phone_number":      "",
+		"delivery_phone_number_bidx": "",
+		"delivery_street":            "",
+		"delivery_kelurahan":         "",
+		"delivery_kecamatan":         "",
+		"delivery_latitude":          nil,
+		"delivery_longitude":         nil,
+	}
+	deliveryColumns := make([]string, 0, len(delivery))
+	for column := range delivery {
+		deliveryColumns = append(deliveryColumns, column)
+	}
+
+	// What is erased below stays in the history of the rows, the audit logs are scrubbed at the end
+	scrubbed := []struct {
+		table   string
+		model   interface{}
+		ids     []uint
+		columns []string
+	}{
+		{table: "kyc_documents", model: &models.KYCDocument{}},
+		{table: "user_addresses", model: &models.UserAddress{}},
+		{table: "user_identities", model: &models.UserIdentity{}},
+		{table: "orders", model: &models.Order{}, columns: deliveryColumns},
+	}
+	for i := range scrubbed {
+		if err := tx.Unscoped().Model(scrubbed[i].model).Where("user_id = ?", userID).Pluck("id", &scrubbed[i].ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, model := range []interface{}{&models.KYCDocument{}, &models.UserAddress{}, &models.UserIdentity{}} {
+		if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Unscoped().Model(&models.Order{}).Where("user_id = ?", userID).UpdateColumns(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	// Account numbers are reduced to what is printed on a receipt
+	var transactions []models.Transaction
+	// Trashed ones too, they are restorable and purging keeps them for a while
+	if err := tx.Unscoped().Joins("JOIN orders ON orders.id = transactions.order_id").Where("orders.user_id = ?", userID).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if err := tx.Unscoped().Model(&transaction).UpdateColumn("no_rek", utils.MaskAccountNumber(transaction.NoRek)).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	password, err := utils.HashPassword(utils.RandomToken())
+	if err != nil {
+		return nil, err
+	}
+	now := ctrl.Now()
+	user.Username = fmt.Sprintf("deleted-user-%d", user.ID)
+	user.Email = fmt.Sprintf("deleted-user-%d@erased.invalid", user.ID)
+	user.Password = password
+	user.PhoneNumber = ""
+	user.Address = ""
+	user.AvatarURL = ""
+	user.EmailVerifiedAt = nil
+	user.PendingEmail = ""
+	user.EmailVerificationHash = ""
+	user.EmailVerificationExpiresAt = nil
+	user.ErasedAt = &now
+	if err := tx.Save(&user).Error; err != nil {
+		return nil, err
+	}
+
+	// Last, so the entries of the erasure itself are scrubbed too
+	if err := audit.Scrub(tx, "users", []uint{userID}); err != nil {
+		return nil, err
+	}
+	for _, rows := range scrubbed {
+		if err := audit.Scrub(tx, rows.table, rows.ids, rows.columns...); err != nil {
+			return nil, err
+		}
+	}
+	return fileKeys, audit.ScrubActor(tx, userID)
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/mailer"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/pkg/utils"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAvatarSize          = 2 << 20
+	emailVerificationValid = 24 * time.Hour
+)
+
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ProfileController serves the authenticated user's own profile. Every handler works on the
+// user from the token, there is no way to address another user.
+type ProfileController struct {
+	DB      *gorm.DB
+	Now     func() time.Time
+	Storage storage.Storage
+	Mailer  mailer.Mailer
+	// AppURL is the web app the verification links open, APP_URL
+	AppURL string
+}
+
+// Get godoc
+// @Summary Get my profile.
+// @Description Get the profile of the user owning the token.
+// @Tags profile
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 401 {object} problem.Problem
+// @Router /api/me/profile [get]
+func (ctrl *ProfileController) Get(c *gin.Context) {
+	user, ok := ctrl.currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
+}
+
+// Patch godoc
+// @Summary Update my profile.
+// @Description Partially update the profile of the user owning the token. A new email is kept pending until it is verified with the link sent to it.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param body body models.ProfilePatchRequest true "Fields to update"
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/profile [patch]
+func (ctrl *ProfileController) Patch(c *gin.Context) {
+	var req models.ProfilePatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	user, ok := ctrl.currentUser(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.Username != nil && *req.Username != user.Username {
+		var count int64
+		if err := ctrl.DB.WithContext(c).Model(&models.User{}).Where("username = ? AND id <> ?", *req.Username, user.ID).Count(&count).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+		if count > 0 {
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "username already exists")
+			return
+		}
+		updates["username"] = *req.Username
+	}
+	if req.PhoneNumber != nil {
+		updates["phone_number"] = *req.PhoneNumber
+	}
+	if req.Address != nil {
+		updates["address"] = *req.Address
+	}
+
+	// Emails are compared and stored lowercased, Foo@x.com is the address of foo@x.com
+	var email, verificationToken string
+	if req.Email != nil {
+		email = strings.ToLower(strings.TrimSpace(*req.Email))
+	}
+	if email != "" && email != strings.ToLower(user.Email) {
+		var count int64
+		if err := ctrl.DB.WithContext(c).Model(&models.User{}).Where("LOWER(email) = ? AND id <> ?", email, user.ID).Count(&count).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+		if count > 0 {
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "email already exists")
+			return
+		}
+
+		verificationToken = utils.RandomToken()
+		expiresAt := ctrl.Now().Add(emailVerificationValid)
+		updates["pending_email"] = email
+		updates["email_verification_hash"] = utils.HashToken(verificationToken)
+		updates["email_verification_expires_at"] = &expiresAt
+	}
+
+	if len(updates) > 0 {
+		if err := ctrl.DB.WithContext(c).Model(&user).Updates(updates).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+	}
+
+	if verificationToken != "" {
+		ctrl.sendVerificationEmail(c, email, verificationToken)
+	}
+
+	if err := ctrl.DB.WithContext(c).Preload("Role").First(&user, user.ID).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
+}
+
+// VerifyEmail godoc
+// @Summary Verify a new email address.
+// @Description Confirms the pending email of a profile with the token sent to it, the pending email then replaces the current one.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param body body models.VerifyEmailRequest true "Verification token from the email"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/auth/verify-email [post]
+func (ctrl *ProfileController) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	var user models.User
+	err := ctrl.DB.WithContext(c).Where("email_verification_hash = ?", utils.HashToken(req.Token)).First(&user).Error
+	if err != nil || user.PendingEmail == "" || user.EmailVerificationExpiresAt == nil || ctrl.Now().After(*user.EmailVerificationExpiresAt) {
+		problem.Abort(c, http.StatusBadRequest, "invalid or expired verification token")
+		return
+	}
+
+	var count int64
+	if err := ctrl.DB.WithContext(c).Model(&models.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", user.PendingEmail, user.ID).Count(&count).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if count > 0 {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "email already exists")
+		return
+	}
+
+	now := ctrl.Now()
+	if err := ctrl.DB.WithContext(c).Model(&user).Updates(map[string]interface{}{
+		"email":                         user.PendingEmail,
+		"email_verified_at":             &now,
+		"pending_email":                 "",
+		"email_verification_hash":       "",
+		"email_verification_expires_at": nil,
+	}).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// UploadAvatar godoc
+// @Summary Upload my avatar.
+// @Description Upload a JPEG, PNG or WebP avatar of at most 2 MB for the user owning the token.
+// @Tags profile
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param avatar formData file true "Avatar image"
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/me/profile/avatar [post]
+func (ctrl *ProfileController) UploadAvatar(c *gin.Context) {
+	user, ok := ctrl.currentUser(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "avatar file is required")
+		return
+	}
+	if fileHeader.Size > maxAvatarSize {
+		problem.Abort(c, http.StatusBadRequest, "avatar must be at most 2 MB")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxAvatarSize+1))
+	if err != nil {
+		problem.Invalid(c, err)
+		return
+	}
+
+	// Trust the bytes, not the client supplied content type
+	contentType := http.DetectContentType(content)
+	ext, allowed := avatarExtensions[contentType]
+	if !allowed || len(content) > maxAvatarSize {
+		problem.Abort(c, http.StatusBadRequest, "avatar must be a JPEG, PNG or WebP image of at most 2 MB")
+		return
+	}
+
+	key := fmt.Sprintf("%savatars/%d-%s%s", storage.PublicPrefix, user.ID, utils.RandomToken()[:16], ext)
+	if err := ctrl.Storage.Put(c.Request.Context(), key, bytes.NewReader(content), contentType); err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "Failed to store avatar")
+		return
+	}
+
+	oldAvatarURL := user.AvatarURL
+	user.AvatarURL = ctrl.Storage.URL(key)
+	if err := ctrl.DB.WithContext(c).Model(&user).Update("avatar_url", user.AvatarURL).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	if baseURL := ctrl.Storage.URL(""); oldAvatarURL != "" && strings.HasPrefix(oldAvatarURL, baseURL) {
+		oldKey := storage.PublicPrefix + strings.TrimPrefix(oldAvatarURL, baseURL)
+		if err := ctrl.Storage.Delete(c.Request.Context(), oldKey); err != nil {
+			logging.FromContext(c.Request.Context()).Error("delete old avatar", "key", oldKey, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
+}
+
+// currentUser loads the user owning the token, writing a 401 when it no longer exists.
+func (ctrl *ProfileController) currentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := ctrl.DB.WithContext(c).Preload("Role").First(&user, c.GetUint("user_id")).Error; err != nil {
+		problem.Abort(c, http.StatusUnauthorized, "User not found")
+		return user, false
+	}
+	return user, true
+}
+
+func (ctrl *ProfileController) sendVerificationEmail(c *gin.Context, email, token string) {
+	link := ctrl.AppURL + "/verify-email?token=" + token
+	err := ctrl.Mailer.Send(c.Request.Context(), mailer.Message{
+		To:      email,
+		Subject: "Verifikasi email Car Zone",
+		Body: "Hi,\n\nPlease confirm your new email address for Car Zone by opening the link below.\n\n" +
+			link + "\n\nThe link is valid for 24 hours. If you did not request this change you can ignore this email.",
+	})
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("send verification email", "error", err)
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,12 +19,13 @@ type RoleController struct {
 // @Tags roles
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Success 200 {object} models.Role
+// @Success 200 {object} object{data=[]models.RoleList}
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/roles [get]
 func (ctrl *RoleController) FindAll(c *gin.Context) {
 	var roles []models.Role
-	if err := ctrl.DB.Order("created_at DESC").Find(&roles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.DB.WithContext(c).Order("created_at DESC").Find(&roles).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -45,12 +47,13 @@ func (ctrl *RoleController) FindAll(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Role ID"
-// @Success 200 {object} models.Role
-// @Router /roles/{id} [get]
+// @Success 200 {object} object{data=models.Role}
+// @Failure 400 {object} problem.Problem
+// @Router /api/cms/roles/{id} [get]
 func (ctrl *RoleController) FindByID(c *gin.Context) {
 	var role models.Role
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "record not found")
 		return
 	}
 
@@ -65,18 +68,19 @@ func (ctrl *RoleController) FindByID(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param role body models.Role true "Role Data"
-// @Success 200 {object} models.Role
+// @Success 200 {object} object{data=models.Role}
+// @Failure 400 {object} problem.Problem
 // @Router /api/cms/roles [post]
 func (ctrl *RoleController) Create(c *gin.Context) {
 	var role models.Role
 
 	if err := c.ShouldBindJSON(&role); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request"})
+		problem.Abort(c, http.StatusBadRequest, "invalid request")
 		return
 	}
 
-	if err := ctrl.DB.Create(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "error when creating role"})
+	if err := ctrl.DB.WithContext(c).Create(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "error when creating role")
 		return
 	}
 
@@ -92,23 +96,24 @@ func (ctrl *RoleController) Create(c *gin.Context) {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Role ID"
 // @Param role body models.RoleRequest true "Role Data"
-// @Success 200 {object} models.Role
+// @Success 200 {object} object{data=models.Role}
+// @Failure 400 {object} problem.Problem
 // @Router /api/cms/roles/{id} [put]
 func (ctrl *RoleController) Update(c *gin.Context) {
 	var role models.Role
 
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "record not found")
 		return
 	}
 
 	if err := c.ShouldBindJSON(&role); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request"})
+		problem.Abort(c, http.StatusBadRequest, "invalid request")
 		return
 	}
 
-	if err := ctrl.DB.Save(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "error when updating role"})
+	if err := ctrl.DB.WithContext(c).Save(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "error when updating role")
 		return
 	}
 
@@ -117,22 +122,35 @@ func (ctrl *RoleController) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete role
-// @Description Delete role
+// @Description Move a role to the trash, roles still assigned to users cannot be deleted
 // @Tags roles
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Role ID"
-// @Success 200 {object} models.Role
+// @Success 200 {object} object{data=models.Role}
+// @Failure 409 {object} problem.Problem
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/roles/{id} [delete]
 func (ctrl *RoleController) Delete(c *gin.Context) {
 	var role models.Role
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "record not found")
 		return
 	}
 
-	if err := ctrl.DB.Delete(&role).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "error when deleting role"})
+	inUse, err := isReferenced(ctrl.DB.WithContext(c), &models.User{}, "role_id", role.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if inUse {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "role is still assigned to users")
+		return
+	}
+
+	if err := ctrl.DB.WithContext(c).Delete(&role).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "error when deleting role")
 		return
 	}
 
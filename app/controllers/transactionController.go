@@ -2,74 +2,37 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/services"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type TransactionController struct {
-	DB *gorm.DB
+	Transactions *services.TransactionService
 }
 
 // FindAll godoc
 // @Summary Get all transactions
-// @Description Get all transactions
+// @Description Get the transactions of the orders of the authenticated user, admins get every transaction
 // @Tags transactions
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Success 200 {object} models.Transaction
+// @Success 200 {object} object{data=[]models.TransactionDetail}
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/transactions [get]
 func (ctrl *TransactionController) FindAll(c *gin.Context) {
-	var transactions []models.Transaction
-	if err := ctrl.DB.Preload("Order.Car").Preload("Order.User").Order("created_at DESC").Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	viewer := viewerFrom(c)
+	transactions, err := ctrl.Transactions.List(c, viewer)
+	if err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	var transactionDetails []models.TransactionDetail
+	transactionDetails := []models.TransactionDetail{}
 	for _, transaction := range transactions {
-		transactionDetails = append(transactionDetails, models.TransactionDetail{
-			ID:              transaction.ID,
-			OrderID:         transaction.OrderID,
-			PaymentProvider: transaction.PaymentProvider,
-			NoRek:           transaction.NoRek,
-			Amount:          transaction.Amount,
-			TransactionDate: transaction.TransactionDate,
-			CreatedAt:       transaction.CreatedAt,
-			UpdatedAt:       transaction.UpdatedAt,
-			Order: models.OrderDetail{
-				ID:         transaction.Order.ID,
-				UserID:     transaction.Order.UserID,
-				CarID:      transaction.Order.CarID,
-				TotalPrice: transaction.Order.TotalPrice,
-				Status:     transaction.Order.Status,
-				OrderImage: transaction.Order.OrderImage,
-				CreatedAt:  transaction.Order.CreatedAt,
-				UpdatedAt:  transaction.Order.UpdatedAt,
-				Car: models.CarDetail{
-					ID:          transaction.Order.Car.ID,
-					Name:        transaction.Order.Car.Name,
-					Description: transaction.Order.Car.Description,
-					ImageCar:    transaction.Order.Car.ImageCar,
-					Price:       transaction.Order.Car.Price,
-					TypeID:      transaction.Order.Car.TypeID,
-					BrandID:     transaction.Order.Car.BrandID,
-					IsSecond:    transaction.Order.Car.IsSecond,
-					CreatedAt:   transaction.Order.Car.CreatedAt,
-					UpdatedAt:   transaction.Order.Car.UpdatedAt,
-				},
-				User: models.UserList{
-					ID:          transaction.Order.User.ID,
-					Username:    transaction.Order.User.Username,
-					PhoneNumber: transaction.Order.User.PhoneNumber,
-					Address:     transaction.Order.User.Address,
-					Email:       transaction.Order.User.Email,
-					RoleName:    transaction.Order.User.Role.RoleName, // Fetching RoleName from the Role association
-				},
-			},
-		})
+		transactionDetails = append(transactionDetails, models.NewTransactionDetail(transaction, viewer))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": transactionDetails})
@@ -82,50 +45,57 @@ func (ctrl *TransactionController) FindAll(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Order ID"
-// @Success 200 {object} models.Transaction
+// @Success 200 {object} object{data=[]models.TransactionDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/transactions/{id} [get]
 func (ctrl *TransactionController) FindByID(c *gin.Context) {
-	var transaction []models.Transaction
-	if err := ctrl.DB.Where("order_id = ?", c.Param("id")).Find(&transaction).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	orderID, ok := idParam(c)
+	if !ok {
 		return
 	}
+	viewer := viewerFrom(c)
+	transactions, err := ctrl.Transactions.ListByOrder(c, viewer, orderID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	transactionDetails := []models.TransactionDetail{}
+	for _, transaction := range transactions {
+		transactionDetails = append(transactionDetails, models.NewTransactionDetail(transaction, viewer))
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": transaction})
+	c.JSON(http.StatusOK, gin.H{"data": transactionDetails})
 }
 
 // Create godoc
 // @Summary Create new transaction
-// @Description Create new transaction
+// @Description Record a payment for an order, the amount must be positive
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param transaction body models.Transaction true "Transaction Data"
-// @Success 200 {object} models.Transaction
+// @Success 200 {object} object{data=models.TransactionDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/transactions [post]
 func (ctrl *TransactionController) Create(c *gin.Context) {
 	var req models.Transaction
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	newTransaction := models.Transaction{
-		OrderID:         req.OrderID,
-		PaymentProvider: req.PaymentProvider,
-		NoRek:           req.NoRek,
-		Amount:          req.Amount,
-		TransactionDate: time.Now(),
-		CreatedAt:       time.Now(),
-	}
-
-	if err := ctrl.DB.Create(&newTransaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	transaction, err := ctrl.Transactions.Create(c, viewerFrom(c), transactionInput(req))
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": newTransaction})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewTransactionDetail(transaction, viewerFrom(c))})
 }
 
 // Update godoc
@@ -137,56 +107,60 @@ func (ctrl *TransactionController) Create(c *gin.Context) {
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Transaction ID"
 // @Param transaction body models.Transaction true "Transaction Data"
-// @Success 200 {object} models.Transaction
+// @Success 200 {object} object{data=models.TransactionDetail}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/transactions/{id} [put]
 func (ctrl *TransactionController) Update(c *gin.Context) {
-	var transaction models.Transaction
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
 	var req models.Transaction
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
-	// Update fields
-	transaction.OrderID = req.OrderID
-	transaction.PaymentProvider = req.PaymentProvider
-	transaction.NoRek = req.NoRek
-	transaction.Amount = req.Amount
-	transaction.UpdatedAt = time.Now()
-
-	if err := ctrl.DB.Save(&transaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+
+	transaction, err := ctrl.Transactions.Update(c, viewerFrom(c), id, transactionInput(req))
+	if err != nil {
+		serviceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": transaction})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewTransactionDetail(transaction, viewerFrom(c))})
 }
 
 // Delete godoc
 // @Summary Delete transaction
-// @Description Delete transaction
+// @Description Move a transaction to the trash, invoiced transactions cannot be deleted
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "Transaction ID"
-// @Success 200 {object} models.Transaction
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
 // @Router /api/cms/transactions/{id} [delete]
 func (ctrl *TransactionController) Delete(c *gin.Context) {
-	var transaction models.Transaction
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
-
-	if err := ctrl.DB.Delete(&transaction).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.Transactions.Delete(c, viewerFrom(c), id); err != nil {
+		serviceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully!"})
 }
+
+func transactionInput(req models.Transaction) services.TransactionInput {
+	return services.TransactionInput{
+		OrderID:         req.OrderID,
+		PaymentProvider: req.PaymentProvider,
+		NoRek:           req.NoRek,
+		Amount:          req.Amount,
+	}
+}
@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/services"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashEntity describes a soft deleted model for the trash endpoints.
+type trashEntity struct {
+	table string
+	model func() interface{}
+	// list loads the deleted rows and renders them like the endpoints of the entity do
+	list func(db *gorm.DB, viewer models.Viewer) ([]trashedRow, error)
+	// references are the "table.column" foreign keys pointing at this entity, a row is only
+	// purged once nothing references it anymore, not even rows in the trash.
+	references []string
+	// parents are the foreign key columns that must point at live rows before a row is restored.
+	parents map[string]string
+	// financial records are kept for the tax retention period instead of the trash one.
+	financial bool
+	// beforePurge returns storage keys of files that belong to the purged rows.
+	beforePurge func(tx *gorm.DB, store storage.Storage, ids []uint) ([]string, error)
+}
+
+var trashEntities = map[string]trashEntity{
+	"invoices": {
+		table:     "invoices",
+		model:     func() interface{} { return &models.Invoice{} },
+		list:      listTrashed(models.NewInvoiceDetail, "Order.Car", "Order.User.Role", "Transaction"),
+		parents:   map[string]string{"order_id": "orders", "transaction_id": "transactions"},
+		financial: true,
+	},
+	"transactions": {
+		table:      "transactions",
+		model:      func() interface{} { return &models.Transaction{} },
+		list:       listTrashed(models.NewTransactionDetail, "Order.Car", "Order.User.Role"),
+		references: []string{"invoices.transaction_id"},
+		parents:    map[string]string{"order_id": "orders"},
+		financial:  true,
+	},
+	"orders": {
+		table:      "orders",
+		model:      func() interface{} { return &models.Order{} },
+		list:       listTrashed(models.NewOrderDetail, "Car", "User.Role"),
+		references: []string{"transactions.order_id", "invoices.order_id"},
+		parents:    map[string]string{"user_id": "users", "car_id": "cars"},
+		financial:  true,
+	},
+	"cars": {
+		table:      "cars",
+		model:      func() interface{} { return &models.Car{} },
+		list:       listTrashed(func(car models.Car, _ models.Viewer) models.CarDetail { return models.NewCarDetail(car) }),
+		references: []string{"orders.car_id", "leads.car_id"},
+		parents:    map[string]string{"brand_id": "brand_cars", "type_id": "type_cars"},
+	},
+	"brand-cars": {
+		table:      "brand_cars",
+		model:      func() interface{} { return &models.BrandCar{} },
+		list:       listTrashed(asIs[models.BrandCar]),
+		references: []string{"cars.brand_id"},
+	},
+	"type-cars": {
+		table:      "type_cars",
+		model:      func() interface{} { return &models.TypeCar{} },
+		list:       listTrashed(asIs[models.TypeCar]),
+		references: []string{"cars.type_id"},
+	},
+	"users": {
+		table:      "users",
+		model:      func() interface{} { return &models.User{} },
+		list:       listTrashed(models.NewUserResponse, "Role"),
+		references: []string{"orders.user_id"},
+		parents:    map[string]string{"role_id": "roles"},
+		// Addresses, linked accounts and KYC rows go with the user through ON DELETE CASCADE,
+		// only the encrypted scans and the avatars need to be removed by hand
+		beforePurge: func(tx *gorm.DB, store storage.Storage, ids []uint) ([]string, error) {
+			var keys []string
+			if err := tx.Model(&models.KYCDocument{}).Where("user_id IN ?", ids).Pluck("file_key", &keys).Error; err != nil {
+				return nil, err
+			}
+
+			var avatarURLs []string
+			if err := tx.Unscoped().Model(&models.User{}).Where("id IN ? AND avatar_url <> ''", ids).Pluck("avatar_url", &avatarURLs).Error; err != nil {
+				return nil, err
+			}
+			baseURL := store.URL("")
+			for _, avatarURL := range avatarURLs {
+				if strings.HasPrefix(avatarURL, baseURL) {
+					keys = append(keys, storage.PublicPrefix+strings.TrimPrefix(avatarURL, baseURL))
+				}
+			}
+			return keys, nil
+		},
+	},
+	"roles": {
+		table:      "roles",
+		model:      func() interface{} { return &models.Role{} },
+		list:       listTrashed(asIs[models.Role]),
+		references: []string{"users.role_id"},
+	},
+}
+
+// trashedRow is a row of the trash with the time it was deleted.
+type trashedRow struct {
+	DeletedAt time.Time   `json:"deleted_at"`
+	Row       interface{} `json:"row"`
+}
+
+// listTrashed returns the list function of an entity whose rows are rendered with render, most
+// recently deleted first. preloads are the relations render needs.
+func listTrashed[T, R any](render func(T, models.Viewer) R, preloads ...string) func(*gorm.DB, models.Viewer) ([]trashedRow, error) {
+	return func(db *gorm.DB, viewer models.Viewer) ([]trashedRow, error) {
+		db = db.Unscoped()
+		for _, preload := range preloads {
+			db = db.Preload(preload)
+		}
+		var rows []T
+		if err := db.Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		trashed := make([]trashedRow, len(rows))
+		for i, row := range rows {
+			deletedAt := reflect.ValueOf(row).FieldByName("DeletedAt").Interface().(gorm.DeletedAt)
+			trashed[i] = trashedRow{DeletedAt: deletedAt.Time, Row: render(row, viewer)}
+		}
+		return trashed, nil
+	}
+}
+
+// asIs renders the models without a response type, they hold nothing private.
+func asIs[T any](row T, _ models.Viewer) T {
+	return row
+}
+
+// trashPurgeOrder purges dependents before the rows they point at.
+var trashPurgeOrder = []string{"invoices", "transactions", "orders", "cars", "brand-cars", "type-cars", "users", "roles"}
+
+// TrashController lists, restores and purges soft deleted rows.
+type TrashController struct {
+	DB      *gorm.DB
+	Now     func() time.Time
+	Storage storage.Storage
+	// Orders restores orders, they reserve or sell their car again
+	Orders *services.OrderService
+	// RetentionDays and FinancialRetentionDays are how long rows stay in the trash, see config.Trash
+	RetentionDays          int
+	FinancialRetentionDays int
+}
+
+// Summary godoc
+// @Summary Get the trash summary
+// @Description Get the number of deleted rows per entity (only admin)
+// @Tags trash
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} object{data=map[string]int64}
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/trash [get]
+func (ctrl *TrashController) Summary(c *gin.Context) {
+	summary := map[string]int64{}
+	for name, entity := range trashEntities {
+		var count int64
+		if err := ctrl.DB.WithContext(c).Unscoped().Model(entity.model()).Where("deleted_at IS NOT NULL").Count(&count).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+		summary[name] = count
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// FindAll godoc
+// @Summary Get deleted rows
+// @Description Get the deleted rows of an entity, most recently deleted first (only admin). Each row is rendered like the endpoints of its entity render it.
+// @Tags trash
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param entity path string true "Entity" Enums(cars, brand-cars, type-cars, users, roles, orders, transactions, invoices)
+// @Success 200 {object} object{data=[]controllers.trashedRow}
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/trash/{entity} [get]
+func (ctrl *TrashController) FindAll(c *gin.Context) {
+	entity, ok := ctrl.entity(c)
+	if !ok {
+		return
+	}
+
+	rows, err := entity.list(ctrl.DB.WithContext(c), viewerFrom(c))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// Restore godoc
+// @Summary Restore a deleted row
+// @Description Take a row out of the trash (only admin). Rows pointing at something that is itself in the trash, users whose username or email has been taken since, and orders whose car has been sold or reserved since, cannot be restored. A restored paid order marks its car sold again.
+// @Tags trash
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param entity path string true "Entity" Enums(cars, brand-cars, type-cars, users, roles, orders, transactions, invoices)
+// @Param id path int true "Row ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/trash/{entity}/{id}/restore [post]
+func (ctrl *TrashController) Restore(c *gin.Context) {
+	entity, ok := ctrl.entity(c)
+	if !ok {
+		return
+	}
+	row, ok := ctrl.deletedRow(c, entity)
+	if !ok {
+		return
+	}
+
+	// Checked in a fixed order so the same row always reports the same parent
+	columns := make([]string, 0, len(entity.parents))
+	for column := range entity.parents {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		parent := entity.parents[column]
+		value, ok := row[column]
+		if !ok || value == nil {
+			continue
+		}
+		var live int64
+		if err := ctrl.DB.WithContext(c).Table(parent).Where("id = ? AND deleted_at IS NULL", value).Count(&live).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+		if live == 0 {
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, fmt.Sprintf("%s %v is deleted, restore it first", strings.TrimSuffix(parent, "s"), value))
+			return
+		}
+	}
+
+	if entity.table == "users" {
+		var taken int64
+		if err := ctrl.DB.WithContext(c).Model(&models.User{}).Where("username = ? OR email = ?", row["username"], row["email"]).Count(&taken).Error; err != nil {
+			problem.Error(c, err)
+			return
+		}
+		if taken > 0 {
+			problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "the username or email of this user is used by another account")
+			return
+		}
+	}
+
+	if entity.table == "orders" {
+		id, ok := idParam(c)
+		if !ok {
+			return
+		}
+		if err := ctrl.Orders.Restore(c.Request.Context(), id); err != nil {
+			serviceError(c, err)
+			return
+		}
+	} else if err := ctrl.DB.WithContext(c).Unscoped().Model(entity.model()).Where("id = ?", row["id"]).Update("deleted_at", nil).Error; err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "restored successfully!"})
+}
+
+// Purge godoc
+// @Summary Permanently delete a row
+// @Description Permanently delete a row from the trash before the retention period ends (only admin). Rows still referenced by other rows cannot be purged.
+// @Tags trash
+// @Produce json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param entity path string true "Entity" Enums(cars, brand-cars, type-cars, users, roles, orders, transactions, invoices)
+// @Param id path int true "Row ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cms/trash/{entity}/{id} [delete]
+func (ctrl *TrashController) Purge(c *gin.Context) {
+	entity, ok := ctrl.entity(c)
+	if !ok {
+		return
+	}
+	row, ok := ctrl.deletedRow(c, entity)
+	if !ok {
+		return
+	}
+
+	purged, err := ctrl.purge(c.Request.Context(), entity, func(q *gorm.DB) *gorm.DB {
+		return q.Where(entity.table+".id = ?", row["id"])
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if purged == 0 {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "this row is still referenced by other rows")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted permanently!"})
+}
+
+// PurgeExpired godoc
+// @Summary Purge the trash
+// @Description Permanently delete rows that have been in the trash longer than TRASH_RETENTION_DAYS, or TRASH_FINANCIAL_RETENTION_DAYS for orders, transactions and invoices. Called by the scheduler with the CRON_SECRET bearer token.
+// @Tags trash
+// @Produce json
+// @Param Authorization header string true "Bearer <CRON_SECRET>"
+// @Success 200 {object} object{data=map[string]int64}
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/cron/purge-trash [get]
+func (ctrl *TrashController) PurgeExpired(c *gin.Context) {
+	retention := time.Duration(ctrl.RetentionDays) * 24 * time.Hour
+	financialRetention := time.Duration(ctrl.FinancialRetentionDays) * 24 * time.Hour
+
+	purged := map[string]int64{}
+	for _, name := range trashPurgeOrder {
+		entity := trashEntities[name]
+		cutoff := ctrl.Now().Add(-retention)
+		if entity.financial {
+			cutoff = ctrl.Now().Add(-financialRetention)
+		}
+
+		count, err := ctrl.purge(c.Request.Context(), entity, func(q *gorm.DB) *gorm.DB {
+			return q.Where(entity.table+".deleted_at < ?", cutoff)
+		})
+		purged[name] = count
+		if err != nil {
+			p := problem.From(c, err)
+			p.Data = purged
+			problem.Write(c, p)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": purged})
+}
+
+// purge permanently deletes the deleted rows matched by scope that nothing references anymore.
+func (ctrl *TrashController) purge(ctx context.Context, entity trashEntity, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	var fileKeys []string
+	var purged int64
+
+	err := ctrl.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := scope(tx.Unscoped().Model(entity.model()).Where(entity.table + ".deleted_at IS NOT NULL"))
+		for _, reference := range entity.references {
+			table, column, _ := strings.Cut(reference, ".")
+			query = query.Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s.%s = %s.id)", table, table, column, entity.table))
+		}
+
+		var ids []uint
+		if err := query.Pluck(entity.table+".id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if entity.beforePurge != nil {
+			keys, err := entity.beforePurge(tx, ctrl.Storage, ids)
+			if err != nil {
+				return err
+			}
+			fileKeys = keys
+		}
+
+		result := tx.Unscoped().Delete(entity.model(), ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range fileKeys {
+		if err := ctrl.Storage.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("trash: delete file", "key", key, "error", err)
+		}
+	}
+	return purged, nil
+}
+
+func (ctrl *TrashController) entity(c *gin.Context) (trashEntity, bool) {
+	entity, ok := trashEntities[c.Param("entity")]
+	if !ok {
+		problem.Abort(c, http.StatusNotFound, "unknown entity")
+	}
+	return entity, ok
+}
+
+// deletedRow loads the raw columns of a row in the trash.
+func (ctrl *TrashController) deletedRow(c *gin.Context, entity trashEntity) (map[string]interface{}, bool) {
+	row := map[string]interface{}{}
+	err := ctrl.DB.WithContext(c).Table(entity.table).Where("id = ? AND deleted_at IS NOT NULL", c.Param("id")).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		problem.Abort(c, http.StatusNotFound, "record not found in the trash")
+		return nil, false
+	}
+	if err != nil {
+		problem.Error(c, err)
+		return nil, false
+	}
+	return row, true
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
 	"net/http"
 	"strconv"
 
@@ -23,18 +24,18 @@ type TypeCarController struct {
 // @Security BearerToken
 // @Param type_car body models.TypeCar true "Type Car object"
 // @Success 201 {object} models.TypeCar
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/type-cars [post]
 func (tcc *TypeCarController) Create(c *gin.Context) {
 	var typeCar models.TypeCar
 	if err := c.ShouldBindJSON(&typeCar); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	if err := tcc.DB.Create(&typeCar).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tcc.DB.WithContext(c).Create(&typeCar).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -47,12 +48,12 @@ func (tcc *TypeCarController) Create(c *gin.Context) {
 // @Tags type-cars
 // @Produce json
 // @Success 200 {array} models.TypeCar
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/type-cars [get]
 func (tcc *TypeCarController) GetAll(c *gin.Context) {
 	var typeCars []models.TypeCar
-	if err := tcc.DB.Order("created_at DESC").Find(&typeCars).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tcc.DB.WithContext(c).Order("created_at DESC").Find(&typeCars).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -66,19 +67,19 @@ func (tcc *TypeCarController) GetAll(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Type Car ID"
 // @Success 200 {object} models.TypeCar
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
 // @Router /api/cms/type-cars/{id} [get]
 func (tcc *TypeCarController) GetByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var typeCar models.TypeCar
-	if err := tcc.DB.Preload("Cars").First(&typeCar, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Type car not found"})
+	if err := tcc.DB.WithContext(c).Preload("Cars").First(&typeCar, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Type car not found")
 		return
 	}
 
@@ -96,30 +97,30 @@ func (tcc *TypeCarController) GetByID(c *gin.Context) {
 // @Param id path int true "Type Car ID"
 // @Param type_car body models.TypeCar true "Updated Type Car object"
 // @Success 200 {object} models.TypeCar
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/type-cars/{id} [put]
 func (tcc *TypeCarController) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
 	var typeCar models.TypeCar
-	if err := tcc.DB.First(&typeCar, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Type car not found"})
+	if err := tcc.DB.WithContext(c).First(&typeCar, id).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "Type car not found")
 		return
 	}
 
 	if err := c.ShouldBindJSON(&typeCar); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	if err := tcc.DB.Save(&typeCar).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tcc.DB.WithContext(c).Save(&typeCar).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -128,25 +129,36 @@ func (tcc *TypeCarController) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a type car
-// @Description Delete a specific type car
+// @Description Move a type car to the trash, types still used by cars cannot be deleted
 // @Tags type-cars
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Security BearerToken
 // @Param id path int true "Type Car ID"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/type-cars/{id} [delete]
 func (tcc *TypeCarController) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		problem.Abort(c, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
-	if err := tcc.DB.Delete(&models.TypeCar{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	inUse, err := isReferenced(tcc.DB.WithContext(c), &models.Car{}, "type_id", id)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if inUse {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "Type car is still used by cars")
+		return
+	}
+
+	if err := tcc.DB.WithContext(c).Delete(&models.TypeCar{}, id).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/problem"
 	"be-car-zone/app/pkg/utils"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,34 +14,43 @@ import (
 )
 
 type UserController struct {
-	DB *gorm.DB
+	DB  *gorm.DB
+	Now func() time.Time
 }
 
 // FindAll godoc
 // @Summary Get all users
-// @Description Get all users
+// @Description Get all users, optionally only the ones with an exact phone number
 // @Tags users
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Success 200 {object} models.User
+// @Param phone query string false "Phone number, e.g. 081234567890 or +6281234567890"
+// @Success 200 {object} object{data=[]models.UserList}
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/users [get]
 func (ctrl *UserController) FindAll(c *gin.Context) {
+	query := ctrl.DB.WithContext(c).Preload("Role").Order("created_at DESC")
+
+	// Phone numbers are encrypted, exact matches go through the blind index
+	if phone := c.Query("phone"); phone != "" {
+		index, err := models.PhoneBlindIndex(phone)
+		if err != nil {
+			problem.Error(c, err)
+			return
+		}
+		query = query.Where("phone_number_bidx = ?", index)
+	}
+
 	var users []models.User
-	if err := ctrl.DB.Preload("Role").Order("created_at DESC").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := query.Find(&users).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
+	viewer := viewerFrom(c)
 	var listUsers []models.UserList
 	for _, res := range users {
-		listUsers = append(listUsers, models.UserList{
-			ID:          res.ID,
-			Username:    res.Username,
-			PhoneNumber: res.PhoneNumber,
-			Address:     res.Address,
-			Email:       res.Email,
-			RoleName:    res.Role.RoleName,
-		})
+		listUsers = append(listUsers, models.NewUserList(res, viewer))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": listUsers})
@@ -51,16 +63,17 @@ func (ctrl *UserController) FindAll(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "User ID"
-// @Success 200 {object} models.User
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
 // @Router /api/cms/users/{id} [get]
 func (ctrl *UserController) FindByID(c *gin.Context) {
 	var user models.User
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).Preload("Role").First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).Preload("Role").First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "record not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": user})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
 }
 
 // Create godoc
@@ -70,54 +83,58 @@ func (ctrl *UserController) FindByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param user body models.User true "User Data"
-// @Success 200 {object} models.User
+// @Param user body models.UserRequest true "User Data"
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/users [post]
 func (ctrl *UserController) Create(c *gin.Context) {
-	var req models.User
+	var req models.UserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	validate := utils.NewValidator()
-	if err := utils.ValidateStruct(validate, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if req.Password == "" {
+		problem.Abort(c, http.StatusBadRequest, "password is required")
 		return
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(c, err)
 		return
 	}
 
 	var existingUser models.User
-	if err := ctrl.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if err := ctrl.DB.WithContext(c).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
 		// If no error, it means email already exists
-		c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeDuplicate, "email already exists")
 		return
 	} else if err != gorm.ErrRecordNotFound {
 		// If other error, return internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Error(c, err)
 		return
 	}
 
 	newUser := models.User{
-		Username:  req.Username,
-		Email:     req.Email,
-		Password:  string(hashedPassword),
-		RoleID:    req.RoleID,
-		CreatedAt: time.Now(),
+		Username:    req.Username,
+		Email:       req.Email,
+		Password:    string(hashedPassword),
+		PhoneNumber: req.PhoneNumber,
+		Address:     req.Address,
+		RoleID:      req.RoleID,
+		CreatedAt:   ctrl.Now(),
 	}
 
-	if err := ctrl.DB.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.DB.WithContext(c).Create(&newUser).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": newUser})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(newUser, viewerFrom(c))})
 }
 
 // Update godoc
@@ -128,26 +145,23 @@ func (ctrl *UserController) Create(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path int true "User ID"
-// @Param user body models.User true "User Data"
-// @Success 200 {object} models.User
+// @Param user body models.UserRequest true "User Data"
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/users/{id} [put]
 func (ctrl *UserController) Update(c *gin.Context) {
-	var req models.User
+	var req models.UserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	validate := utils.NewValidator()
-	if err := utils.ValidateStruct(validate, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
 	var user models.User
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "user not found")
 		return
 	}
 
@@ -156,102 +170,124 @@ func (ctrl *UserController) Update(c *gin.Context) {
 	user.Email = req.Email
 	user.RoleID = req.RoleID
 	user.PhoneNumber = req.PhoneNumber
-	user.UpdatedAt = time.Now()
+	user.Address = req.Address
+	user.UpdatedAt = ctrl.Now()
 
 	// Hash the password if it is being updated
 	if req.Password != "" {
 		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Error(c, err)
 			return
 		}
 		user.Password = string(hashedPassword)
 	}
 
-	if err := ctrl.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.DB.WithContext(c).Save(&user).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": user})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
 }
 
 // Update godoc
-// @Summary Update profile user or admin
-// @Description Update profile user or admin
+// @Summary Update own profile (deprecated, use PATCH /api/me/profile)
+// @Description Update the profile of the user owning the token. The id must be the caller's own id, the role is never changed. The email cannot be changed here, PATCH /api/me/profile changes it once the new address is verified.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path int true "User ID"
-// @Param user body models.User true "User Data"
-// @Success 200 {object} models.User
+// @Param user body models.ProfileRequest true "User Data"
+// @Success 200 {object} object{data=models.UserResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/user/profile/{id} [put]
 func (ctrl *UserController) UserUpdate(c *gin.Context) {
-	var req models.User
+	var req models.ProfileRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Invalid(c, err)
 		return
 	}
 
-	validate := utils.NewValidator()
-	if err := utils.ValidateStruct(validate, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if c.Param("id") != strconv.FormatUint(uint64(c.GetUint("user_id")), 10) {
+		problem.Abort(c, http.StatusForbidden, "you can only update your own profile")
 		return
 	}
 
 	var user models.User
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.GetUint("user_id")).First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusNotFound, "user not found")
 		return
 	}
 
-	// Update fields
+	// Only PATCH /api/me/profile changes the email, it is applied once the new address is verified.
+	// Clients sending the whole profile back with the current email keep working
+	if req.Email != "" && !strings.EqualFold(req.Email, user.Email) {
+		problem.Abort(c, http.StatusBadRequest, "the email is changed with PATCH /api/me/profile, which verifies the new address")
+		return
+	}
+
+	// Update fields, the role is managed by admins only
 	user.Username = req.Username
-	user.Email = req.Email
-	user.RoleID = utils.IDRoleUser
 	user.Address = req.Address
 	user.PhoneNumber = req.PhoneNumber
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = ctrl.Now()
 
 	// Hash the password if it is being updated
 	if req.Password != "" {
 		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Error(c, err)
 			return
 		}
 		user.Password = string(hashedPassword)
 	}
 
-	if err := ctrl.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.DB.WithContext(c).Save(&user).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": user})
+	c.JSON(http.StatusOK, gin.H{"data": models.NewUserResponse(user, viewerFrom(c))})
 }
 
 // Delete godoc
 // @Summary Delete user
-// @Description Delete user
+// @Description Move a user to the trash. Users with orders cannot be deleted, their data can be removed through an erasure request instead.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
 // @Param id path string true "User ID"
-// @Success 200 {object} models.User
+// @Success 200 {object} object{message=string}
+// @Failure 409 {object} problem.Problem
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/cms/users/{id} [delete]
 func (ctrl *UserController) Delete(c *gin.Context) {
 	var user models.User
-	if err := ctrl.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "record not found"})
+	if err := ctrl.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+		problem.Abort(c, http.StatusBadRequest, "record not found")
+		return
+	}
+
+	hasOrders, err := isReferenced(ctrl.DB.WithContext(c), &models.Order{}, "user_id", user.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if hasOrders {
+		problem.AbortWithCode(c, http.StatusConflict, problem.CodeReferenced, "user has orders and cannot be deleted, use an erasure request instead")
 		return
 	}
 
-	if err := ctrl.DB.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.DB.WithContext(c).Delete(&user).Error; err != nil {
+		problem.Error(c, err)
 		return
 	}
 
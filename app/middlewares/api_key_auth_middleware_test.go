@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/apikey"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var apiKeyNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func apiKeyDB(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return apiKeyNow },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.APIKey{}, &models.APIKeyAudit{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createAPIKey stores a new key, change adjusts it before it is saved, and returns the plaintext.
+func createAPIKey(t *testing.T, db *gorm.DB, scopes string, change func(*models.APIKey)) (string, models.APIKey) {
+	t.Helper()
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKey := models.APIKey{Name: "partner", Prefix: prefix, KeyHash: hash, Scopes: scopes}
+	if change != nil {
+		change(&apiKey)
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		t.Fatal(err)
+	}
+	return key, apiKey
+}
+
+func apiKeyRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("db", db) })
+	r.GET("/cars", APIKeyAuthMiddleware("cars:read"), func(c *gin.Context) {
+		c.String(http.StatusOK, "%d", c.GetUint("api_key_id"))
+	})
+	r.GET("/bucket", func(c *gin.Context) { c.String(http.StatusOK, RateLimitByAPIKey(c)) })
+	return r
+}
+
+func serveAPIKey(r *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	db := apiKeyDB(t)
+	r := apiKeyRouter(db)
+
+	past, future := apiKeyNow.Add(-time.Minute), apiKeyNow.Add(time.Minute)
+	valid, validKey := createAPIKey(t, db, "cars:read, leads:write", nil)
+	expiring, _ := createAPIKey(t, db, "cars:read", func(k *models.APIKey) { k.ExpiresAt = &future })
+	expired, _ := createAPIKey(t, db, "cars:read", func(k *models.APIKey) { k.ExpiresAt = &past })
+	revoked, _ := createAPIKey(t, db, "cars:read", func(k *models.APIKey) { k.RevokedAt = &past })
+	leadsOnly, _ := createAPIKey(t, db, "leads:write", nil)
+	validPrefix, _ := apikey.Prefix(valid)
+	other, _, _, _ := apikey.Generate()
+	wrongSecret := "cz_" + validPrefix + other[len("cz_")+len(validPrefix):]
+
+	for _, tc := range []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"valid", valid, http.StatusOK},
+		{"expires later", expiring, http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"malformed", "not-a-key", http.StatusUnauthorized},
+		{"unknown prefix", other, http.StatusUnauthorized},
+		{"wrong secret with the right prefix", wrongSecret, http.StatusUnauthorized},
+		{"expired", expired, http.StatusUnauthorized},
+		{"revoked", revoked, http.StatusUnauthorized},
+		{"missing scope", leadsOnly, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := serveAPIKey(r, "/cars", tc.key); w.Code != tc.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tc.status, w.Body)
+			}
+		})
+	}
+
+	if w := serveAPIKey(r, "/cars", valid); w.Body.String() != fmt.Sprint(validKey.ID) {
+		t.Fatalf("api_key_id %q, want %d", w.Body, validKey.ID)
+	}
+
+	// Authenticated calls are audited, rejected keys never reach the bookkeeping
+	var audits []models.APIKeyAudit
+	if err := db.Order("id").Find(&audits).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(audits) != 4 {
+		t.Fatalf("%d audits, want the 4 authenticated calls", len(audits))
+	}
+	forbidden := audits[2]
+	if forbidden.Status != http.StatusForbidden || forbidden.Path != "/cars" || forbidden.IP != "192.0.2.1" {
+		t.Fatalf("the call without the scope was audited as %+v", forbidden)
+	}
+	var used models.APIKey
+	if err := db.First(&used, validKey.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if used.LastUsedAt == nil || !used.LastUsedAt.Equal(apiKeyNow) || used.LastUsedIP != "192.0.2.1" {
+		t.Fatalf("last use not recorded: %+v", used)
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	db := apiKeyDB(t)
+	r := apiKeyRouter(db)
+	valid, validKey := createAPIKey(t, db, "cars:read", nil)
+	unknown, _, _, _ := apikey.Generate()
+
+	for key, want := range map[string]string{
+		valid:   fmt.Sprintf("key:%d", validKey.ID),
+		unknown: "ip:192.0.2.1",
+		"":      "ip:192.0.2.1",
+	} {
+		if got := serveAPIKey(r, "/bucket", key).Body.String(); got != want {
+			t.Errorf("bucket of %q = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"be-car-zone/app/pkg/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditMiddleware attaches an audit.Request to the request context so changes made while handling
+// it are logged with the IP, user agent and request ID of RequestIDMiddleware. The auth
+// middlewares add the actor.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := audit.WithRequest(c.Request.Context(), &audit.Request{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("request_id"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// setAuditActor records the authenticated caller on the audit request, if there is one.
+func setAuditActor(c *gin.Context, set func(*audit.Request)) {
+	if r := audit.FromContext(c.Request.Context()); r != nil {
+		set(r)
+	}
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"be-car-zone/app/pkg/problem"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CronAuthMiddleware guards scheduled jobs. The scheduler sends secret, CRON_SECRET, as a bearer
+// token, the jobs are disabled while the secret is not configured.
+func CronAuthMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			problem.Abort(c, http.StatusServiceUnavailable, "scheduled jobs are not configured")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+secret)) != 1 {
+			problem.Abort(c, http.StatusUnauthorized, "invalid cron secret")
+			return
+		}
+		c.Next()
+	}
+}
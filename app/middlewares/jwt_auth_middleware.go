@@ -2,7 +2,10 @@ package middlewares
 
 import (
 	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/audit"
 	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/tracing"
 	"errors"
 	"net/http"
 
@@ -15,24 +18,34 @@ func JwtAuthMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		err := jwt.TokenValid(c)
 		if err != nil {
-			c.String(http.StatusUnauthorized, err.Error())
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, err.Error())
 			return
 		}
 
 		userId, err := jwt.ExtractTokenID(c)
 		if err != nil {
-			c.String(http.StatusUnauthorized, err.Error())
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, err.Error())
 			return
 		}
 
 		var user models.User
 		db := c.MustGet("db").(*gorm.DB)
-		findUserErr := db.Preload("Role").Where("id = ?", userId).First(&user).Error
+		// Every authenticated request pays for the lookup, its own span shows how much
+		ctx, span := tracing.Tracer().Start(c.Request.Context(), "jwt.user_lookup")
+		findUserErr := db.WithContext(ctx).Preload("Role").Where("id = ?", userId).First(&user).Error
+		span.End()
+		if errors.Is(findUserErr, gorm.ErrRecordNotFound) {
+			problem.Abort(c, http.StatusUnauthorized, "the user of this token does not exist")
+			return
+		}
 		if findUserErr != nil {
-			c.String(http.StatusUnauthorized, findUserErr.Error())
-			c.Abort()
+			problem.Error(c, findUserErr)
+			return
+		}
+
+		// Tokens issued before an erasure stay signed, the account itself is gone
+		if user.ErasedAt != nil {
+			problem.Abort(c, http.StatusUnauthorized, "this account has been deleted")
 			return
 		}
 
@@ -41,13 +54,12 @@ func JwtAuthMiddleware(allowedRoles ...string) gin.HandlerFunc {
 			if role == user.Role.RoleName || user.Role.RoleName == "admin" {
 				c.Set("user_id", user.ID)
 				c.Set("user_role", user.Role.RoleName)
+				setAuditActor(c, func(r *audit.Request) { r.SetUser(user.ID, user.Role.RoleName) })
 				c.Next()
 				return
 			}
 		}
 
-		roleError := errors.New("sorry, your role cannot access this route")
-		c.String(http.StatusForbidden, roleError.Error())
-		c.Abort()
+		problem.Abort(c, http.StatusForbidden, "sorry, your role cannot access this route")
 	}
 }
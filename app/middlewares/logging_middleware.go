@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/problem"
+	"be-car-zone/app/pkg/utils"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID, a valid incoming value is kept so a request can be
+// traced across services.
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestIDMiddleware takes the request ID from X-Request-ID or generates one, returns it in the
+// response and puts a logger with it in the request context, see logging.FromContext. The
+// logger has the trace and span IDs of the request too, once TracingMiddleware started its span.
+// It runs before the other middlewares so they log with the IDs.
+func RequestIDMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = utils.RandomToken()[:22]
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+
+		requestLogger := logger.With("request_id", requestID)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+		}
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), requestLogger))
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware logs every request once it is answered: errors at the error level, client
+// errors at the warn level and the rest at the info level. Errors recorded on the context, such
+// as the internal errors problem.Error hides from the client, are logged with it.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if query := logging.Query(c.Request.URL.Query()); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if userID := c.GetUint("user_id"); userID != 0 {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// RecoveryMiddleware turns a panic into a 500 problem and logs it with the stack and the request
+// headers, credentials redacted. A client that went away is not an error worth a stack.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+
+			logging.FromContext(c.Request.Context()).Error("panic",
+				slog.Any("panic", recovered),
+				slog.String("stack", string(debug.Stack())),
+				logging.Headers(c.Request.Header),
+			)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "an unexpected error occurred")
+		}()
+		c.Next()
+	}
+}
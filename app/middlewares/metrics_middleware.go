@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/pkg/problem"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware observes the duration of every request by method, route pattern and status.
+func MetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.RequestStarted()
+		c.Next()
+		m.RequestDone(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAuthMiddleware guards /metrics on the API, the scraper sends token as a bearer token.
+func MetricsAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			problem.Abort(c, http.StatusUnauthorized, "invalid metrics token")
+			return
+		}
+		c.Next()
+	}
+}
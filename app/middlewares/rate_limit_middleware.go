@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/problem"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"be-car-zone/app/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc returns the identity a request is throttled by.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitByIP throttles by client IP.
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByUserID throttles by the user set by JwtAuthMiddleware, falling back to the client IP.
+func RateLimitByUserID(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimitByAPIKey throttles by the API key the request authenticates with. Missing, unknown,
+// revoked and expired keys share the bucket of the client IP, made up keys must not each get a
+// budget of their own.
+func RateLimitByAPIKey(c *gin.Context) string {
+	apiKey, err := authenticateAPIKey(c)
+	if err != nil {
+		return RateLimitByIP(c)
+	}
+	return fmt.Sprintf("key:%d", apiKey.ID)
+}
+
+// RateLimitMiddleware applies a token bucket limit per key. Buckets are namespaced by name so
+// the same client gets independent budgets on different route groups.
+func RateLimitMiddleware(store ratelimit.Store, name string, limit ratelimit.Limit, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), name+":"+keyFunc(c), limit)
+		if err != nil {
+			// Fail open, a broken limiter store should not take the API down.
+			logging.FromContext(c.Request.Context()).Error("rate limiter", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			problem.Abort(c, http.StatusTooManyRequests, "too many requests, please try again later")
+			return
+		}
+
+		c.Next()
+	}
+}
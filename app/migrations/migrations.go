@@ -0,0 +1,11 @@
+// Package migrations holds the versioned schema migrations, one directory per SQL dialect.
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql. Versions are applied in
+// ascending order and never change once released, schema changes get a new version in every
+// dialect: mysql, postgres and sqlite.
+package migrations
+
+import "embed"
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,279 @@
+-- Schema as it was created by GORM AutoMigrate, tables are only created when missing so the
+-- migration can also adopt a database that was set up by AutoMigrate.
+
+CREATE TABLE roles (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  role_name VARCHAR(255) NOT NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_roles_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE users (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  username VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  phone_number VARCHAR(512) NULL,
+  address VARCHAR(1024) NULL,
+  role_id BIGINT UNSIGNED NULL,
+  avatar_url VARCHAR(512) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  email_verified_at DATETIME(3) NULL,
+  pending_email VARCHAR(255) NULL,
+  email_verification_hash VARCHAR(64) NULL,
+  email_verification_expires_at DATETIME(3) NULL,
+  erased_at DATETIME(3) NULL,
+  phone_number_bidx VARCHAR(64) NULL,
+  PRIMARY KEY (id),
+  KEY idx_users_deleted_at (deleted_at),
+  KEY idx_users_email_verification_hash (email_verification_hash),
+  KEY idx_users_phone_number_bidx (phone_number_bidx),
+  CONSTRAINT fk_users_role FOREIGN KEY (role_id) REFERENCES roles (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE brand_cars (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name LONGTEXT NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_brand_cars_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE type_cars (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name LONGTEXT NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_type_cars_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE cars (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name LONGTEXT NULL,
+  description LONGTEXT NULL,
+  image_car LONGTEXT NULL,
+  price DOUBLE NULL,
+  type_id BIGINT UNSIGNED NULL,
+  brand_id BIGINT UNSIGNED NULL,
+  is_second BOOLEAN NULL,
+  sold BOOLEAN NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_cars_deleted_at (deleted_at),
+  CONSTRAINT fk_cars_type FOREIGN KEY (type_id) REFERENCES type_cars (id),
+  CONSTRAINT fk_cars_brand FOREIGN KEY (brand_id) REFERENCES brand_cars (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_addresses (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  label VARCHAR(64) NOT NULL,
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  recipient_name VARCHAR(255) NULL,
+  phone_number VARCHAR(32) NULL,
+  street VARCHAR(255) NULL,
+  kelurahan VARCHAR(128) NULL,
+  kecamatan VARCHAR(128) NULL,
+  city VARCHAR(128) NULL,
+  province VARCHAR(128) NULL,
+  postal_code VARCHAR(10) NULL,
+  latitude DOUBLE NULL,
+  longitude DOUBLE NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_user_addresses_user_id (user_id),
+  CONSTRAINT fk_user_addresses_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE orders (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NULL,
+  car_id BIGINT UNSIGNED NULL,
+  total_price DOUBLE NULL,
+  status BOOLEAN NULL,
+  order_image LONGTEXT NULL,
+  address_id BIGINT UNSIGNED NULL,
+  delivery_recipient_name VARCHAR(255) NULL,
+  delivery_phone_number VARCHAR(32) NULL,
+  delivery_street VARCHAR(255) NULL,
+  delivery_kelurahan VARCHAR(128) NULL,
+  delivery_kecamatan VARCHAR(128) NULL,
+  delivery_city VARCHAR(128) NULL,
+  delivery_province VARCHAR(128) NULL,
+  delivery_postal_code VARCHAR(10) NULL,
+  delivery_latitude DOUBLE NULL,
+  delivery_longitude DOUBLE NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_orders_deleted_at (deleted_at),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+  CONSTRAINT fk_orders_car FOREIGN KEY (car_id) REFERENCES cars (id) ON UPDATE CASCADE ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE transactions (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  order_id BIGINT UNSIGNED NULL,
+  payment_provider LONGTEXT NULL,
+  no_rek VARCHAR(512) NULL,
+  amount DOUBLE NULL,
+  transaction_date DATETIME(3) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_transactions_deleted_at (deleted_at),
+  CONSTRAINT fk_transactions_order FOREIGN KEY (order_id) REFERENCES orders (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE invoices (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  order_id BIGINT UNSIGNED NULL,
+  transaction_id BIGINT UNSIGNED NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  deleted_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_invoices_deleted_at (deleted_at),
+  CONSTRAINT fk_invoices_order FOREIGN KEY (order_id) REFERENCES orders (id),
+  CONSTRAINT fk_invoices_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE rate_limit_buckets (
+  bucket_key VARCHAR(255) NOT NULL,
+  tokens DOUBLE NOT NULL,
+  refilled_at DATETIME(3) NOT NULL,
+  PRIMARY KEY (bucket_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE api_keys (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash VARCHAR(64) NOT NULL,
+  scopes VARCHAR(255) NOT NULL,
+  expires_at DATETIME(3) NULL,
+  last_used_at DATETIME(3) NULL,
+  last_used_ip VARCHAR(64) NULL,
+  revoked_at DATETIME(3) NULL,
+  created_by BIGINT UNSIGNED NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY idx_api_keys_prefix (prefix)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE api_key_audits (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  api_key_id BIGINT UNSIGNED NOT NULL,
+  method VARCHAR(16) NULL,
+  path VARCHAR(255) NULL,
+  status BIGINT NULL,
+  ip VARCHAR(64) NULL,
+  user_agent VARCHAR(255) NULL,
+  created_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_api_key_audits_api_key_id (api_key_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE leads (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  api_key_id BIGINT UNSIGNED NULL,
+  car_id BIGINT UNSIGNED NULL,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NULL,
+  phone_number VARCHAR(255) NULL,
+  message TEXT NULL,
+  source VARCHAR(255) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_leads_api_key_id (api_key_id),
+  CONSTRAINT fk_leads_car FOREIGN KEY (car_id) REFERENCES cars (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_identities (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_user_identities_user_id (user_id),
+  UNIQUE KEY idx_user_identities_provider_subject (provider, subject),
+  CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE kyc_documents (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  type VARCHAR(16) NOT NULL,
+  number VARCHAR(512) NOT NULL,
+  number_bidx VARCHAR(64) NULL,
+  file_key VARCHAR(255) NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  rejection_reason VARCHAR(255) NULL,
+  reviewed_by BIGINT UNSIGNED NULL,
+  reviewed_at DATETIME(3) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY idx_kyc_documents_user_type (user_id, type),
+  KEY idx_kyc_documents_number_bidx (number_bidx),
+  KEY idx_kyc_documents_status (status),
+  CONSTRAINT fk_kyc_documents_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE erasure_requests (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  reason VARCHAR(255) NULL,
+  rejection_reason VARCHAR(255) NULL,
+  processed_by BIGINT UNSIGNED NULL,
+  processed_at DATETIME(3) NULL,
+  created_at DATETIME(3) NULL,
+  updated_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_erasure_requests_user_id (user_id),
+  KEY idx_erasure_requests_status (status),
+  CONSTRAINT fk_erasure_requests_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE audit_logs (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  actor_id BIGINT UNSIGNED NULL,
+  actor_role VARCHAR(50) NULL,
+  api_key_id BIGINT UNSIGNED NULL,
+  action VARCHAR(20) NULL,
+  entity VARCHAR(64) NULL,
+  entity_id VARCHAR(64) NULL,
+  changes TEXT NULL,
+  ip VARCHAR(64) NULL,
+  user_agent VARCHAR(255) NULL,
+  request_id VARCHAR(64) NULL,
+  created_at DATETIME(3) NULL,
+  PRIMARY KEY (id),
+  KEY idx_audit_logs_actor_id (actor_id),
+  KEY idx_audit_logs_api_key_id (api_key_id),
+  KEY idx_audit_logs_action (action),
+  KEY idx_audit_logs_entity (entity, entity_id),
+  KEY idx_audit_logs_request_id (request_id),
+  KEY idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
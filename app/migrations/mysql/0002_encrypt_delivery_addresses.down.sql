@@ -0,0 +1,17 @@
+-- Sealed values do not fit the old sizes, decrypt the columns before rolling back.
+
+ALTER TABLE orders
+  DROP KEY idx_orders_delivery_phone_number_bidx,
+  DROP COLUMN delivery_phone_number_bidx,
+  MODIFY delivery_recipient_name VARCHAR(255) NULL,
+  MODIFY delivery_phone_number VARCHAR(32) NULL,
+  MODIFY delivery_street VARCHAR(255) NULL,
+  MODIFY delivery_kelurahan VARCHAR(128) NULL,
+  MODIFY delivery_kecamatan VARCHAR(128) NULL;
+
+ALTER TABLE user_addresses
+  MODIFY recipient_name VARCHAR(255) NULL,
+  MODIFY phone_number VARCHAR(32) NULL,
+  MODIFY street VARCHAR(255) NULL,
+  MODIFY kelurahan VARCHAR(128) NULL,
+  MODIFY kecamatan VARCHAR(128) NULL;
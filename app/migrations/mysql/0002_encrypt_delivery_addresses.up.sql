@@ -0,0 +1,18 @@
+-- The recipient, phone and street of addresses and order snapshots are encrypted, sealed values
+-- are longer than the plaintext. Existing rows are sealed by POST /api/cms/encryption/reencrypt.
+
+ALTER TABLE user_addresses
+  MODIFY recipient_name VARCHAR(1024) NULL,
+  MODIFY phone_number VARCHAR(512) NULL,
+  MODIFY street VARCHAR(1024) NULL,
+  MODIFY kelurahan VARCHAR(512) NULL,
+  MODIFY kecamatan VARCHAR(512) NULL;
+
+ALTER TABLE orders
+  MODIFY delivery_recipient_name VARCHAR(1024) NULL,
+  MODIFY delivery_phone_number VARCHAR(512) NULL,
+  MODIFY delivery_street VARCHAR(1024) NULL,
+  MODIFY delivery_kelurahan VARCHAR(512) NULL,
+  MODIFY delivery_kecamatan VARCHAR(512) NULL,
+  ADD COLUMN delivery_phone_number_bidx VARCHAR(64) NULL,
+  ADD KEY idx_orders_delivery_phone_number_bidx (delivery_phone_number_bidx);
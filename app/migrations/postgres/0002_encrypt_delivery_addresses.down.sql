@@ -0,0 +1,17 @@
+-- Sealed values do not fit the old sizes, decrypt the columns before rolling back.
+
+DROP INDEX IF EXISTS idx_orders_delivery_phone_number_bidx;
+ALTER TABLE orders
+  DROP COLUMN delivery_phone_number_bidx,
+  ALTER COLUMN delivery_recipient_name TYPE VARCHAR(255),
+  ALTER COLUMN delivery_phone_number TYPE VARCHAR(32),
+  ALTER COLUMN delivery_street TYPE VARCHAR(255),
+  ALTER COLUMN delivery_kelurahan TYPE VARCHAR(128),
+  ALTER COLUMN delivery_kecamatan TYPE VARCHAR(128);
+
+ALTER TABLE user_addresses
+  ALTER COLUMN recipient_name TYPE VARCHAR(255),
+  ALTER COLUMN phone_number TYPE VARCHAR(32),
+  ALTER COLUMN street TYPE VARCHAR(255),
+  ALTER COLUMN kelurahan TYPE VARCHAR(128),
+  ALTER COLUMN kecamatan TYPE VARCHAR(128);
@@ -0,0 +1,18 @@
+-- The recipient, phone and street of addresses and order snapshots are encrypted, sealed values
+-- are longer than the plaintext. Existing rows are sealed by POST /api/cms/encryption/reencrypt.
+
+ALTER TABLE user_addresses
+  ALTER COLUMN recipient_name TYPE VARCHAR(1024),
+  ALTER COLUMN phone_number TYPE VARCHAR(512),
+  ALTER COLUMN street TYPE VARCHAR(1024),
+  ALTER COLUMN kelurahan TYPE VARCHAR(512),
+  ALTER COLUMN kecamatan TYPE VARCHAR(512);
+
+ALTER TABLE orders
+  ALTER COLUMN delivery_recipient_name TYPE VARCHAR(1024),
+  ALTER COLUMN delivery_phone_number TYPE VARCHAR(512),
+  ALTER COLUMN delivery_street TYPE VARCHAR(1024),
+  ALTER COLUMN delivery_kelurahan TYPE VARCHAR(512),
+  ALTER COLUMN delivery_kecamatan TYPE VARCHAR(512),
+  ADD COLUMN delivery_phone_number_bidx VARCHAR(64) NULL;
+CREATE INDEX idx_orders_delivery_phone_number_bidx ON orders (delivery_phone_number_bidx);
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS erasure_requests;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS leads;
+DROP TABLE IF EXISTS api_key_audits;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS rate_limit_buckets;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS user_addresses;
+DROP TABLE IF EXISTS cars;
+DROP TABLE IF EXISTS type_cars;
+DROP TABLE IF EXISTS brand_cars;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,262 @@
+-- The schema of the mysql and postgres migrations for SQLite, used in local development and tests.
+
+CREATE TABLE roles (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  role_name VARCHAR(255) NOT NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL
+);
+CREATE INDEX idx_roles_deleted_at ON roles (deleted_at);
+
+CREATE TABLE users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  phone_number VARCHAR(512) NULL,
+  address VARCHAR(1024) NULL,
+  role_id BIGINT NULL,
+  avatar_url VARCHAR(512) NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  email_verified_at DATETIME NULL,
+  pending_email VARCHAR(255) NULL,
+  email_verification_hash VARCHAR(64) NULL,
+  email_verification_expires_at DATETIME NULL,
+  erased_at DATETIME NULL,
+  phone_number_bidx VARCHAR(64) NULL,
+  CONSTRAINT fk_users_role FOREIGN KEY (role_id) REFERENCES roles (id)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX idx_users_email_verification_hash ON users (email_verification_hash);
+CREATE INDEX idx_users_phone_number_bidx ON users (phone_number_bidx);
+
+CREATE TABLE brand_cars (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL
+);
+CREATE INDEX idx_brand_cars_deleted_at ON brand_cars (deleted_at);
+
+CREATE TABLE type_cars (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL
+);
+CREATE INDEX idx_type_cars_deleted_at ON type_cars (deleted_at);
+
+CREATE TABLE cars (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NULL,
+  description TEXT NULL,
+  image_car TEXT NULL,
+  price REAL NULL,
+  type_id BIGINT NULL,
+  brand_id BIGINT NULL,
+  is_second BOOLEAN NULL,
+  sold BOOLEAN NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  CONSTRAINT fk_cars_type FOREIGN KEY (type_id) REFERENCES type_cars (id),
+  CONSTRAINT fk_cars_brand FOREIGN KEY (brand_id) REFERENCES brand_cars (id)
+);
+CREATE INDEX idx_cars_deleted_at ON cars (deleted_at);
+
+CREATE TABLE user_addresses (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id BIGINT NOT NULL,
+  label VARCHAR(64) NOT NULL,
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  recipient_name VARCHAR(255) NULL,
+  phone_number VARCHAR(32) NULL,
+  street VARCHAR(255) NULL,
+  kelurahan VARCHAR(128) NULL,
+  kecamatan VARCHAR(128) NULL,
+  city VARCHAR(128) NULL,
+  province VARCHAR(128) NULL,
+  postal_code VARCHAR(10) NULL,
+  latitude REAL NULL,
+  longitude REAL NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  CONSTRAINT fk_user_addresses_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX idx_user_addresses_user_id ON user_addresses (user_id);
+
+CREATE TABLE orders (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id BIGINT NULL,
+  car_id BIGINT NULL,
+  total_price REAL NULL,
+  status BOOLEAN NULL,
+  order_image TEXT NULL,
+  address_id BIGINT NULL,
+  delivery_recipient_name VARCHAR(255) NULL,
+  delivery_phone_number VARCHAR(32) NULL,
+  delivery_street VARCHAR(255) NULL,
+  delivery_kelurahan VARCHAR(128) NULL,
+  delivery_kecamatan VARCHAR(128) NULL,
+  delivery_city VARCHAR(128) NULL,
+  delivery_province VARCHAR(128) NULL,
+  delivery_postal_code VARCHAR(10) NULL,
+  delivery_latitude REAL NULL,
+  delivery_longitude REAL NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+  CONSTRAINT fk_orders_car FOREIGN KEY (car_id) REFERENCES cars (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE transactions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  order_id BIGINT NULL,
+  payment_provider TEXT NULL,
+  no_rek VARCHAR(512) NULL,
+  amount REAL NULL,
+  transaction_date DATETIME NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  CONSTRAINT fk_transactions_order FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+CREATE INDEX idx_transactions_deleted_at ON transactions (deleted_at);
+
+CREATE TABLE invoices (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  order_id BIGINT NULL,
+  transaction_id BIGINT NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  CONSTRAINT fk_invoices_order FOREIGN KEY (order_id) REFERENCES orders (id),
+  CONSTRAINT fk_invoices_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+);
+CREATE INDEX idx_invoices_deleted_at ON invoices (deleted_at);
+
+CREATE TABLE rate_limit_buckets (
+  bucket_key VARCHAR(255) NOT NULL,
+  tokens REAL NOT NULL,
+  refilled_at DATETIME NOT NULL,
+  PRIMARY KEY (bucket_key)
+);
+
+CREATE TABLE api_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash VARCHAR(64) NOT NULL,
+  scopes VARCHAR(255) NOT NULL,
+  expires_at DATETIME NULL,
+  last_used_at DATETIME NULL,
+  last_used_ip VARCHAR(64) NULL,
+  revoked_at DATETIME NULL,
+  created_by BIGINT NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys (prefix);
+
+CREATE TABLE api_key_audits (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  api_key_id BIGINT NOT NULL,
+  method VARCHAR(16) NULL,
+  path VARCHAR(255) NULL,
+  status BIGINT NULL,
+  ip VARCHAR(64) NULL,
+  user_agent VARCHAR(255) NULL,
+  created_at DATETIME NULL
+);
+CREATE INDEX idx_api_key_audits_api_key_id ON api_key_audits (api_key_id);
+
+CREATE TABLE leads (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  api_key_id BIGINT NULL,
+  car_id BIGINT NULL,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NULL,
+  phone_number VARCHAR(255) NULL,
+  message TEXT NULL,
+  source VARCHAR(255) NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  CONSTRAINT fk_leads_car FOREIGN KEY (car_id) REFERENCES cars (id)
+);
+CREATE INDEX idx_leads_api_key_id ON leads (api_key_id);
+
+CREATE TABLE user_identities (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id BIGINT NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+CREATE UNIQUE INDEX idx_user_identities_provider_subject ON user_identities (provider, subject);
+
+CREATE TABLE kyc_documents (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id BIGINT NOT NULL,
+  type VARCHAR(16) NOT NULL,
+  number VARCHAR(512) NOT NULL,
+  number_bidx VARCHAR(64) NULL,
+  file_key VARCHAR(255) NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  rejection_reason VARCHAR(255) NULL,
+  reviewed_by BIGINT NULL,
+  reviewed_at DATETIME NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  CONSTRAINT fk_kyc_documents_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_kyc_documents_user_type ON kyc_documents (user_id, type);
+CREATE INDEX idx_kyc_documents_number_bidx ON kyc_documents (number_bidx);
+CREATE INDEX idx_kyc_documents_status ON kyc_documents (status);
+
+CREATE TABLE erasure_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id BIGINT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  reason VARCHAR(255) NULL,
+  rejection_reason VARCHAR(255) NULL,
+  processed_by BIGINT NULL,
+  processed_at DATETIME NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  CONSTRAINT fk_erasure_requests_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX idx_erasure_requests_user_id ON erasure_requests (user_id);
+CREATE INDEX idx_erasure_requests_status ON erasure_requests (status);
+
+CREATE TABLE audit_logs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_id BIGINT NULL,
+  actor_role VARCHAR(50) NULL,
+  api_key_id BIGINT NULL,
+  action VARCHAR(20) NULL,
+  entity VARCHAR(64) NULL,
+  entity_id VARCHAR(64) NULL,
+  changes TEXT NULL,
+  ip VARCHAR(64) NULL,
+  user_agent VARCHAR(255) NULL,
+  request_id VARCHAR(64) NULL,
+  created_at DATETIME NULL
+);
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX idx_audit_logs_api_key_id ON audit_logs (api_key_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity, entity_id);
+CREATE INDEX idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_delivery_phone_number_bidx;
+ALTER TABLE orders DROP COLUMN delivery_phone_number_bidx;
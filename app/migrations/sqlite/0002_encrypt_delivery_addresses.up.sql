@@ -0,0 +1,4 @@
+-- SQLite does not enforce VARCHAR sizes, only the blind index of the delivery phone is new.
+
+ALTER TABLE orders ADD COLUMN delivery_phone_number_bidx VARCHAR(64) NULL;
+CREATE INDEX idx_orders_delivery_phone_number_bidx ON orders (delivery_phone_number_bidx);
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable is returned when something tries to change or remove an audit entry.
+var ErrAuditLogImmutable = errors.New("audit logs are append-only")
+
+// AuditLog records one row changed by a create, update or delete. Entries are written by the
+// audit GORM plugin in the same transaction as the change itself. They are never changed, only
+// the personal data of an erased user is scrubbed from them, see audit.Scrub.
+type AuditLog struct {
+	ID        uint                   `gorm:"primaryKey" json:"id"`
+	ActorID   *uint                  `gorm:"index" json:"actor_id"`
+	ActorRole string                 `gorm:"type:varchar(50)" json:"actor_role"`
+	APIKeyID  *uint                  `gorm:"index" json:"api_key_id"`
+	Action    string                 `gorm:"type:varchar(20);index" json:"action"`
+	Entity    string                 `gorm:"type:varchar(64);index:idx_audit_logs_entity" json:"entity"`
+	EntityID  string                 `gorm:"type:varchar(64);index:idx_audit_logs_entity" json:"entity_id"`
+	Changes   map[string]AuditChange `gorm:"type:text;serializer:json" json:"changes"`
+	IP        string                 `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent string                 `gorm:"type:varchar(255)" json:"user_agent"`
+	RequestID string                 `gorm:"type:varchar(64);index" json:"request_id"`
+	CreatedAt time.Time              `gorm:"index" json:"created_at"`
+}
+
+// AuditChange is the value of a column before and after the change, Old is empty on create and
+// New on delete.
+type AuditChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+func (AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
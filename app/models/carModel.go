@@ -1,19 +1,24 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Car struct {
-	ID          uint
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	ImageCar    string    `json:"image_car"`
-	Price       float64   `json:"price"`
-	TypeID      uint      `json:"type_id"`
-	BrandID     uint      `json:"brand_id"`
-	IsSecond    bool      `json:"is_second"`
-	Sold        bool      `json:"sold"`
-	Type        TypeCar   `json:"type" gorm:"foreignKey:TypeID"`
-	Brand       BrandCar  `json:"brand" gorm:"foreignKey:BrandID"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"ID"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	ImageCar    string         `json:"image_car"`
+	Price       float64        `json:"price"`
+	TypeID      uint           `json:"type_id"`
+	BrandID     uint           `json:"brand_id"`
+	IsSecond    bool           `json:"is_second"`
+	Sold        bool           `json:"sold"`
+	Type        TypeCar        `json:"type" gorm:"foreignKey:TypeID"`
+	Brand       BrandCar       `json:"brand" gorm:"foreignKey:BrandID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
 }
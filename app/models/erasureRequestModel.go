@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ErasureRequest is a customer's request to delete their account under UU PDP. Requests are kept
+// after the account is anonymized as proof the request was handled.
+type ErasureRequest struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"index;not null" json:"user_id"`
+	Status          string     `gorm:"type:varchar(16);not null;index" json:"status"`
+	Reason          string     `gorm:"type:varchar(255)" json:"reason"`
+	RejectionReason string     `gorm:"type:varchar(255)" json:"rejection_reason"`
+	ProcessedBy     *uint      `json:"processed_by"`
+	ProcessedAt     *time.Time `json:"processed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// ErasureRequestInput confirms an erasure request with the account password.
+type ErasureRequestInput struct {
+	Password string `json:"password" validate:"required"`
+	Reason   string `json:"reason" validate:"max=255"`
+}
+
+type ErasureRejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// DataExport is everything stored about a user, returned by the self service data export.
+type DataExport struct {
+	ExportedAt      time.Time             `json:"exported_at"`
+	Profile         UserResponse          `json:"profile"`
+	Addresses       []UserAddress         `json:"addresses"`
+	Orders          []OrderDetail         `json:"orders"`
+	Transactions    []TransactionDetail   `json:"transactions"`
+	Invoices        []InvoiceDetail       `json:"invoices"`
+	LinkedAccounts  []UserIdentity        `json:"linked_accounts"`
+	KYCDocuments    []KYCDocumentResponse `json:"kyc_documents"`
+	ErasureRequests []ErasureRequest      `json:"erasure_requests"`
+}
@@ -2,14 +2,17 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Invoice struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	OrderID       uint      `json:"order_id"`
-	TransactionID uint      `json:"transaction_id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	OrderID       uint           `json:"order_id"`
+	TransactionID uint           `json:"transaction_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
 
 	Order       Order       `json:"order" gorm:"foreignKey:OrderID"`
 	Transaction Transaction `json:"transaction" gorm:"foreignKey:TransactionID"`
@@ -25,3 +28,16 @@ type InvoiceDetail struct {
 	Order       OrderDetail       `json:"order"`
 	Transaction TransactionDetail `json:"transaction"`
 }
+
+func NewInvoiceDetail(invoice Invoice, viewer Viewer) InvoiceDetail {
+	invoice.Transaction.Order = invoice.Order
+	return InvoiceDetail{
+		ID:            invoice.ID,
+		OrderID:       invoice.OrderID,
+		TransactionID: invoice.TransactionID,
+		CreatedAt:     invoice.CreatedAt,
+		UpdatedAt:     invoice.UpdatedAt,
+		Order:         NewOrderDetail(invoice.Order, viewer),
+		Transaction:   NewTransactionDetail(invoice.Transaction, viewer),
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KYCDocument is an identity document a buyer submits for vehicle registration (STNK/BPKB).
+// The scan itself is stored encrypted under FileKey, a user has at most one document per type.
+type KYCDocument struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;uniqueIndex:idx_kyc_documents_user_type" json:"user_id"`
+	Type            string     `gorm:"type:varchar(16);not null;uniqueIndex:idx_kyc_documents_user_type" json:"type"`
+	Number          string     `gorm:"type:varchar(512);not null;serializer:encrypted" json:"number"`
+	NumberIndex     string     `gorm:"column:number_bidx;type:varchar(64);index" json:"-"`
+	FileKey         string     `gorm:"type:varchar(255);not null" json:"-"`
+	ContentType     string     `gorm:"type:varchar(64);not null" json:"content_type"`
+	Status          string     `gorm:"type:varchar(16);not null;index" json:"status"`
+	RejectionReason string     `gorm:"type:varchar(255)" json:"rejection_reason"`
+	ReviewedBy      *uint      `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// KYCNumberBlindIndex returns the blind index of a document number, used to spot the same NIK
+// or NPWP being submitted by more than one account.
+func KYCNumberBlindIndex(docType, number string) (string, error) {
+	return encryption.BlindIndex("kyc_documents.number:"+docType, utils.DigitsOnly(number))
+}
+
+func (d *KYCDocument) BeforeSave(tx *gorm.DB) error {
+	index, err := KYCNumberBlindIndex(d.Type, d.Number)
+	if err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("NumberIndex", index)
+	return nil
+}
+
+// KYCRejectRequest is the body admins send when rejecting a document.
+type KYCRejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type KYCDocumentResponse struct {
+	ID              uint       `json:"id"`
+	UserID          uint       `json:"user_id"`
+	Type            string     `json:"type"`
+	Number          string     `json:"number"`
+	ContentType     string     `json:"content_type"`
+	Status          string     `json:"status"`
+	RejectionReason string     `json:"rejection_reason"`
+	ReviewedBy      *uint      `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	User            *UserList  `json:"user,omitempty"`
+}
+
+func NewKYCDocumentResponse(doc KYCDocument, viewer Viewer) KYCDocumentResponse {
+	res := KYCDocumentResponse{
+		ID:              doc.ID,
+		UserID:          doc.UserID,
+		Type:            doc.Type,
+		Number:          doc.Number,
+		ContentType:     doc.ContentType,
+		Status:          doc.Status,
+		RejectionReason: doc.RejectionReason,
+		ReviewedBy:      doc.ReviewedBy,
+		ReviewedAt:      doc.ReviewedAt,
+		CreatedAt:       doc.CreatedAt,
+		UpdatedAt:       doc.UpdatedAt,
+	}
+	if doc.User.ID != 0 {
+		user := NewUserList(doc.User, viewer)
+		res.User = &user
+	}
+	if !viewer.CanSeePrivate(doc.UserID) {
+		res.Number = utils.MaskAccountNumber(res.Number)
+	}
+	return res
+}
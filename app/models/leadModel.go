@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Lead struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	APIKeyID    uint      `gorm:"index" json:"api_key_id"`
+	CarID       uint      `json:"car_id"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	Email       string    `gorm:"type:varchar(255)" json:"email"`
+	PhoneNumber string    `gorm:"type:varchar(255)" json:"phone_number"`
+	Message     string    `gorm:"type:text" json:"message"`
+	Source      string    `gorm:"type:varchar(255)" json:"source"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Car Car `json:"car" gorm:"foreignKey:CarID"`
+}
+
+type LeadRequest struct {
+	CarID       uint   `json:"car_id" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,idphone"`
+	Message     string `json:"message"`
+}
@@ -1,33 +1,71 @@
 package models
 
 import (
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/utils"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Order struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	UserID     uint      `json:"user_id"`
-	CarID      uint      `json:"car_id"`
-	TotalPrice float64   `json:"total_price"`
-	Status     bool      `json:"status"`
-	OrderImage string    `json:"order_image"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	User       User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Car        Car       `json:"car" gorm:"foreignKey:CarID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	UserID     uint    `json:"user_id"`
+	CarID      uint    `json:"car_id"`
+	TotalPrice float64 `json:"total_price" validate:"omitempty,idr"`
+	Status     bool    `json:"status"`
+	OrderImage string  `json:"order_image"`
+	// AddressID is the address book entry chosen at checkout, DeliveryAddress is a copy of it
+	// so later edits to the address book do not rewrite the order history.
+	AddressID       *uint           `json:"address_id"`
+	DeliveryAddress DeliveryAddress `json:"delivery_address" gorm:"embedded;embeddedPrefix:delivery_"`
+	// DeliveryPhoneIndex is a blind index of the encrypted delivery phone, see DeliveryPhoneBlindIndex.
+	DeliveryPhoneIndex string         `gorm:"column:delivery_phone_number_bidx;type:varchar(64);index" json:"-"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
+	User               User           `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Car                Car            `json:"car" gorm:"foreignKey:CarID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// DeliveryPhoneBlindIndex returns the value stored in delivery_phone_number_bidx for a phone
+// number, used to find the orders delivered to a phone without decrypting every row.
+func DeliveryPhoneBlindIndex(phone string) (string, error) {
+	return encryption.BlindIndex("orders.delivery_phone_number", utils.NormalizePhone(phone))
+}
+
+// BeforeSave keeps the delivery phone blind index in sync, both for saved structs and map updates.
+func (o *Order) BeforeSave(tx *gorm.DB) error {
+	phone := o.DeliveryAddress.PhoneNumber
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		value, ok := updates["delivery_phone_number"]
+		if !ok {
+			return nil
+		}
+		phone, _ = value.(string)
+	}
+
+	index, err := DeliveryPhoneBlindIndex(phone)
+	if err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("DeliveryPhoneIndex", index)
+	return nil
 }
 
 type OrderDetail struct {
-	ID         uint      `json:"id"`
-	UserID     uint      `json:"user_id"`
-	CarID      uint      `json:"car_id"`
-	TotalPrice float64   `json:"total_price"`
-	Status     bool      `json:"status"`
-	OrderImage string    `json:"order_image"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Car        CarDetail `json:"car"`
-	User       UserList  `json:"user"`
+	ID              uint            `json:"id"`
+	UserID          uint            `json:"user_id"`
+	CarID           uint            `json:"car_id"`
+	TotalPrice      float64         `json:"total_price"`
+	Status          bool            `json:"status"`
+	OrderImage      string          `json:"order_image"`
+	AddressID       *uint           `json:"address_id"`
+	DeliveryAddress DeliveryAddress `json:"delivery_address"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Car             CarDetail       `json:"car"`
+	User            UserList        `json:"user"`
 }
 
 type CarDetail struct {
@@ -42,3 +80,39 @@ type CarDetail struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+func NewCarDetail(car Car) CarDetail {
+	return CarDetail{
+		ID:          car.ID,
+		Name:        car.Name,
+		Description: car.Description,
+		ImageCar:    car.ImageCar,
+		Price:       car.Price,
+		TypeID:      car.TypeID,
+		BrandID:     car.BrandID,
+		IsSecond:    car.IsSecond,
+		CreatedAt:   car.CreatedAt,
+		UpdatedAt:   car.UpdatedAt,
+	}
+}
+
+func NewOrderDetail(order Order, viewer Viewer) OrderDetail {
+	detail := OrderDetail{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		CarID:           order.CarID,
+		TotalPrice:      order.TotalPrice,
+		Status:          order.Status,
+		OrderImage:      order.OrderImage,
+		AddressID:       order.AddressID,
+		DeliveryAddress: order.DeliveryAddress,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+		Car:             NewCarDetail(order.Car),
+		User:            NewUserList(order.User, viewer),
+	}
+	if !viewer.CanSeePrivate(order.UserID) {
+		detail.DeliveryAddress = detail.DeliveryAddress.Masked()
+	}
+	return detail
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type RateLimitBucket struct {
+	BucketKey  string    `gorm:"column:bucket_key;type:varchar(255);primaryKey" json:"bucket_key"`
+	Tokens     float64   `gorm:"column:tokens;not null" json:"tokens"`
+	RefilledAt time.Time `gorm:"column:refilled_at;not null" json:"refilled_at"`
+}
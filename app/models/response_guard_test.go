@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// forbiddenFields must never be visible in JSON, whatever struct they end up in.
+var forbiddenFields = []string{"password", "passwordhash", "keyhash", "secret", "clientsecret", "token"}
+
+// responseTypes lists every type that is rendered in API responses, directly or nested.
+var responseTypes = []interface{}{
+	User{},
+	UserResponse{},
+	UserList{},
+	Role{},
+	RoleList{},
+	Car{},
+	CarDetail{},
+	BrandCar{},
+	TypeCar{},
+	Order{},
+	OrderDetail{},
+	Transaction{},
+	TransactionDetail{},
+	Invoice{},
+	InvoiceDetail{},
+	APIKey{},
+	APIKeyCreated{},
+	APIKeyAudit{},
+	Lead{},
+	UserIdentity{},
+	UserAddress{},
+	KYCDocument{},
+	KYCDocumentResponse{},
+	ErasureRequest{},
+	DataExport{},
+	DeliveryAddress{},
+	AuditLog{},
+}
+
+func TestResponseTypesDoNotExposeSecrets(t *testing.T) {
+	for _, v := range responseTypes {
+		checkJSONFields(t, reflect.TypeOf(v), reflect.TypeOf(v).Name(), map[reflect.Type]bool{})
+	}
+}
+
+func checkJSONFields(t *testing.T, typ reflect.Type, path string, seen map[reflect.Type]bool) {
+	t.Helper()
+
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) || seen[typ] {
+		return
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		if field.Anonymous && name == field.Name {
+			checkJSONFields(t, field.Type, path, seen)
+			continue
+		}
+
+		normalized := strings.ToLower(strings.ReplaceAll(name, "_", ""))
+		for _, forbidden := range forbiddenFields {
+			if normalized == forbidden {
+				t.Errorf("%s.%s is serialized as %q, sensitive fields must be tagged json:\"-\"", path, field.Name, name)
+			}
+		}
+
+		checkJSONFields(t, field.Type, path+"."+field.Name, seen)
+	}
+}
+
+func TestPasswordHashIsNeverSerialized(t *testing.T) {
+	const hash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+	user := User{ID: 1, Username: "budi", Password: hash, Role: Role{ID: 1, RoleName: "user"}}
+	order := Order{ID: 1, UserID: 1, User: user}
+	values := []interface{}{
+		user,
+		order,
+		Transaction{Order: order},
+		Invoice{Order: order, Transaction: Transaction{Order: order}},
+		NewUserResponse(user, Viewer{UserID: 1}),
+		NewOrderDetail(order, Viewer{UserID: 1}),
+		UserIdentity{User: user},
+	}
+
+	for _, v := range values {
+		body, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(body), hash) || strings.Contains(string(body), "$2a$") {
+			t.Errorf("%T leaks the password hash: %s", v, body)
+		}
+	}
+}
+
+func TestPersonalDataIsMaskedForOtherUsers(t *testing.T) {
+	user := User{ID: 1, Email: "budi@example.com", PhoneNumber: "081234567890", Address: "Jl. Sudirman No. 1"}
+
+	owner := NewUserResponse(user, Viewer{UserID: 1, Role: "user"})
+	if owner.PhoneNumber != user.PhoneNumber || owner.Address != user.Address || owner.Email != user.Email {
+		t.Errorf("owner should see their own data, got %+v", owner)
+	}
+
+	admin := NewUserList(user, Viewer{UserID: 2, Role: "admin"})
+	if admin.PhoneNumber != user.PhoneNumber {
+		t.Errorf("admin should see the phone number, got %q", admin.PhoneNumber)
+	}
+
+	other := NewUserList(user, Viewer{UserID: 2, Role: "user"})
+	if other.PhoneNumber == user.PhoneNumber || other.Address == user.Address || other.Email == user.Email {
+		t.Errorf("other users should see masked data, got %+v", other)
+	}
+
+	transaction := NewTransactionDetail(Transaction{NoRek: "1234567890", Order: Order{UserID: 1}}, Viewer{UserID: 2, Role: "user"})
+	if transaction.NoRek != "******7890" {
+		t.Errorf("account number = %q, want it masked", transaction.NoRek)
+	}
+}
@@ -1,12 +1,17 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Role struct {
-	ID        uint      `gorm:"column:id;type:int;primaryKey;autoIncrement" json:"id"`
-	RoleName  string    `gorm:"column:role_name;type:varchar;size:255;not null" json:"role_name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `gorm:"column:id;type:int;primaryKey;autoIncrement" json:"id"`
+	RoleName  string         `gorm:"column:role_name;type:varchar;size:255;not null" json:"role_name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
 }
 
 type RoleRequest struct {
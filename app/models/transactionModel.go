@@ -1,30 +1,53 @@
 package models
 
 import (
+	"be-car-zone/app/pkg/utils"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Transaction struct {
-	ID               uint      `gorm:"primaryKey" json:"id"`
-	OrderID          uint      `json:"order_id"`
-	PaymentProvider  string    `json:"payment_provider"`
-	NoRek            string    `json:"no_rek"`
-	Amount           float64   `json:"amount"`
-	TransactionDate  time.Time `json:"transaction_date"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	OrderID         uint           `json:"order_id"`
+	PaymentProvider string         `json:"payment_provider"`
+	NoRek           string         `gorm:"type:varchar(512);serializer:encrypted" json:"no_rek"`
+	Amount          float64        `json:"amount" validate:"omitempty,idr"`
+	TransactionDate time.Time      `json:"transaction_date"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
 
 	Order Order `json:"order" gorm:"foreignKey:OrderID"`
 }
 
 type TransactionDetail struct {
-	ID               uint        `json:"id"`
-	OrderID          uint        `json:"order_id"`
-	PaymentProvider  string      `json:"payment_provider"`
-	NoRek            string      `json:"no_rek"`
-	Amount           float64     `json:"amount"`
-	TransactionDate  time.Time   `json:"transaction_date"`
-	CreatedAt        time.Time   `json:"created_at"`
-	UpdatedAt        time.Time   `json:"updated_at"`
-	Order            OrderDetail `json:"order"`
+	ID              uint        `json:"id"`
+	OrderID         uint        `json:"order_id"`
+	PaymentProvider string      `json:"payment_provider"`
+	NoRek           string      `json:"no_rek"`
+	Amount          float64     `json:"amount"`
+	TransactionDate time.Time   `json:"transaction_date"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	Order           OrderDetail `json:"order"`
+}
+
+// NewTransactionDetail renders a transaction, the account number is masked unless the viewer owns the order.
+func NewTransactionDetail(transaction Transaction, viewer Viewer) TransactionDetail {
+	detail := TransactionDetail{
+		ID:              transaction.ID,
+		OrderID:         transaction.OrderID,
+		PaymentProvider: transaction.PaymentProvider,
+		NoRek:           transaction.NoRek,
+		Amount:          transaction.Amount,
+		TransactionDate: transaction.TransactionDate,
+		CreatedAt:       transaction.CreatedAt,
+		UpdatedAt:       transaction.UpdatedAt,
+		Order:           NewOrderDetail(transaction.Order, viewer),
+	}
+	if !viewer.CanSeePrivate(transaction.Order.UserID) {
+		detail.NoRek = utils.MaskAccountNumber(detail.NoRek)
+	}
+	return detail
 }
@@ -1,11 +1,16 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TypeCar struct {
-	ID        int
-	Name      string    `json:"name"`
-	Cars      []Car     `json:"cars,omitempty" gorm:"foreignKey:TypeID"`
-	CreatedAt time.Time `json:"-"`
-	UpdatedAt time.Time `json:"-"`
+	ID        int            `json:"ID"`
+	Name      string         `json:"name"`
+	Cars      []Car          `json:"cars,omitempty" gorm:"foreignKey:TypeID"`
+	CreatedAt time.Time      `json:"-"`
+	UpdatedAt time.Time      `json:"-"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"be-car-zone/app/pkg/utils"
+	"time"
+)
+
+// DeliveryAddress is the structured Indonesian address shared by the address book and the
+// snapshot stored on orders. Who receives the car and where is encrypted, the city, province
+// and postal code stay readable for reports.
+type DeliveryAddress struct {
+	RecipientName string   `gorm:"type:varchar;size:1024;serializer:encrypted" json:"recipient_name"`
+	PhoneNumber   string   `gorm:"type:varchar;size:512;serializer:encrypted" json:"phone_number"`
+	Street        string   `gorm:"type:varchar;size:1024;serializer:encrypted" json:"street"`
+	Kelurahan     string   `gorm:"type:varchar;size:512;serializer:encrypted" json:"kelurahan"`
+	Kecamatan     string   `gorm:"type:varchar;size:512;serializer:encrypted" json:"kecamatan"`
+	City          string   `gorm:"type:varchar;size:128" json:"city"`
+	Province      string   `gorm:"type:varchar;size:128" json:"province"`
+	PostalCode    string   `gorm:"type:varchar;size:10" json:"postal_code"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+}
+
+// Masked hides everything but the city and province, used when showing someone else's order.
+func (a DeliveryAddress) Masked() DeliveryAddress {
+	return DeliveryAddress{
+		RecipientName: utils.MaskName(a.RecipientName),
+		PhoneNumber:   utils.MaskPhone(a.PhoneNumber),
+		Street:        utils.MaskAddress(a.Street),
+		City:          a.City,
+		Province:      a.Province,
+	}
+}
+
+type UserAddress struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	Label     string `gorm:"type:varchar;size:64;not null" json:"label"`
+	IsDefault bool   `gorm:"not null;default:false" json:"is_default"`
+	DeliveryAddress
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+type UserAddressRequest struct {
+	Label         string   `json:"label" validate:"required,max=64"`
+	RecipientName string   `json:"recipient_name" validate:"required,max=255"`
+	PhoneNumber   string   `json:"phone_number" validate:"required,max=32,idphone"`
+	Street        string   `json:"street" validate:"required,max=255"`
+	Kelurahan     string   `json:"kelurahan" validate:"required,max=128"`
+	Kecamatan     string   `json:"kecamatan" validate:"required,max=128"`
+	City          string   `json:"city" validate:"required,max=128"`
+	Province      string   `json:"province" validate:"required,max=128"`
+	PostalCode    string   `json:"postal_code" validate:"required,numeric,len=5"`
+	Latitude      *float64 `json:"latitude" validate:"omitempty,latitude"`
+	Longitude     *float64 `json:"longitude" validate:"omitempty,longitude"`
+	IsDefault     bool     `json:"is_default"`
+}
+
+func (req UserAddressRequest) DeliveryAddress() DeliveryAddress {
+	return DeliveryAddress{
+		RecipientName: req.RecipientName,
+		PhoneNumber:   req.PhoneNumber,
+		Street:        req.Street,
+		Kelurahan:     req.Kelurahan,
+		Kecamatan:     req.Kecamatan,
+		City:          req.City,
+		Province:      req.Province,
+		PostalCode:    req.PostalCode,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+	}
+}
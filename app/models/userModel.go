@@ -1,18 +1,62 @@
 package models
 
-import "time"
+import (
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
-	ID          uint      `gorm:"column:id;type:int;primaryKey;autoIncrement" json:"id"`
-	Username    string    `gorm:"column:username;type:varchar;size:255;not null" json:"username"`
-	Email       string    `gorm:"column:email;type:varchar;size:255;not null" json:"email"`
-	Password    string    `gorm:"column:password;type:varchar;not null" json:"password"`
-	PhoneNumber string    `gorm:"column:phone_number;type:varchar;size:255" json:"phone_number"`
-	Address     string    `gorm:"column:address;type:varchar;size:255" json:"address"`
-	RoleID      int       `json:"role_id"`
-	Role        Role      `gorm:"foreignKey:RoleID" json:"role,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `gorm:"column:id;type:int;primaryKey;autoIncrement" json:"id"`
+	Username    string         `gorm:"column:username;type:varchar;size:255;not null" json:"username"`
+	Email       string         `gorm:"column:email;type:varchar;size:255;not null" json:"email"`
+	Password    string         `gorm:"column:password;type:varchar;not null" json:"-"`
+	PhoneNumber string         `gorm:"column:phone_number;type:varchar;size:512;serializer:encrypted" json:"phone_number"`
+	Address     string         `gorm:"column:address;type:varchar;size:1024;serializer:encrypted" json:"address"`
+	RoleID      int            `json:"role_id"`
+	Role        Role           `gorm:"foreignKey:RoleID" json:"role,omitempty"`
+	AvatarURL   string         `gorm:"column:avatar_url;type:varchar;size:512" json:"avatar_url"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string"`
+
+	EmailVerifiedAt            *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+	PendingEmail               string     `gorm:"column:pending_email;type:varchar;size:255" json:"-"`
+	EmailVerificationHash      string     `gorm:"column:email_verification_hash;type:varchar;size:64;index" json:"-"`
+	EmailVerificationExpiresAt *time.Time `gorm:"column:email_verification_expires_at" json:"-"`
+
+	// ErasedAt is set once the account has been anonymized on the user's request, see ErasureRequest.
+	ErasedAt *time.Time `gorm:"column:erased_at" json:"-"`
+
+	// PhoneNumberIndex is a blind index of the encrypted phone number, see PhoneBlindIndex.
+	PhoneNumberIndex string `gorm:"column:phone_number_bidx;type:varchar(64);index" json:"-"`
+}
+
+// PhoneBlindIndex returns the value stored in phone_number_bidx for a phone number, used to find
+// users by phone without decrypting every row.
+func PhoneBlindIndex(phone string) (string, error) {
+	return encryption.BlindIndex("users.phone_number", utils.NormalizePhone(phone))
+}
+
+// BeforeSave keeps the phone blind index in sync, both for saved structs and map updates.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	phone := u.PhoneNumber
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		value, ok := updates["phone_number"]
+		if !ok {
+			return nil
+		}
+		phone, _ = value.(string)
+	}
+
+	index, err := PhoneBlindIndex(phone)
+	if err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("PhoneNumberIndex", index)
+	return nil
 }
 
 type RegisterRequest struct {
@@ -31,6 +75,114 @@ type InputChangePassword struct {
 	NewPassword string `json:"new_password" validate:"required"`
 }
 
+// UserRequest is the body admins use to create or update a user.
+// Password is optional on update, the current one is kept when it is empty.
+type UserRequest struct {
+	Username    string `json:"username" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,idphone"`
+	Address     string `json:"address"`
+	RoleID      int    `json:"role_id" validate:"required"`
+}
+
+// ProfileRequest is the body users send to update their own profile. The email cannot be
+// changed with it, a new address must be verified through ProfilePatchRequest first.
+type ProfileRequest struct {
+	Username    string `json:"username" validate:"required"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	Password    string `json:"password"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,idphone"`
+	Address     string `json:"address"`
+}
+
+// ProfilePatchRequest is a partial profile update, omitted fields are left unchanged.
+// A new email is only applied once it has been verified.
+type ProfilePatchRequest struct {
+	Username    *string `json:"username" validate:"omitempty,min=3,max=255"`
+	Email       *string `json:"email" validate:"omitempty,email,max=255"`
+	PhoneNumber *string `json:"phone_number" validate:"omitempty,max=32,idphone"`
+	Address     *string `json:"address" validate:"omitempty,max=255"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// UserResponse is how a user is rendered in API responses, it never carries the password hash.
+type UserResponse struct {
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	PhoneNumber string    `json:"phone_number"`
+	Address     string    `json:"address"`
+	RoleID      int       `json:"role_id"`
+	Role        *RoleList `json:"role,omitempty"`
+	AvatarURL   string    `json:"avatar_url"`
+	// EmailVerified is true once the current email has been confirmed.
+	EmailVerified bool `json:"email_verified"`
+	// PendingEmail is the new email waiting for verification, only shown to its owner and admins.
+	PendingEmail string    `json:"pending_email,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Viewer is the authenticated user a response is rendered for.
+type Viewer struct {
+	UserID uint
+	Role   string
+}
+
+// CanSeePrivate reports whether the viewer may see unmasked personal data of ownerID.
+// Owners see their own data, admins need it to process orders and deliveries.
+func (v Viewer) CanSeePrivate(ownerID uint) bool {
+	return v.Role == utils.RoleAdmin || (v.UserID != 0 && v.UserID == ownerID)
+}
+
+func NewUserResponse(user User, viewer Viewer) UserResponse {
+	res := UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		RoleID:      user.RoleID,
+		AvatarURL:   user.AvatarURL,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+
+		EmailVerified: user.EmailVerifiedAt != nil,
+		PendingEmail:  user.PendingEmail,
+	}
+	if user.Role.ID != 0 {
+		res.Role = &RoleList{ID: user.Role.ID, RoleName: user.Role.RoleName}
+	}
+	if !viewer.CanSeePrivate(user.ID) {
+		res.PhoneNumber = utils.MaskPhone(res.PhoneNumber)
+		res.Email = utils.MaskEmail(res.Email)
+		res.Address = utils.MaskAddress(res.Address)
+		res.PendingEmail = ""
+	}
+	return res
+}
+
+func NewUserList(user User, viewer Viewer) UserList {
+	list := UserList{
+		ID:          user.ID,
+		Username:    user.Username,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		Email:       user.Email,
+		RoleName:    user.Role.RoleName,
+	}
+	if !viewer.CanSeePrivate(user.ID) {
+		list.PhoneNumber = utils.MaskPhone(list.PhoneNumber)
+		list.Email = utils.MaskEmail(list.Email)
+		list.Address = utils.MaskAddress(list.Address)
+	}
+	return list
+}
+
 type UserList struct {
 	ID          uint   `json:"id"`
 	Username    string `json:"username"`
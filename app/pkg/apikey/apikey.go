@@ -0,0 +1,52 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// Keys look like "cz_<prefix>_<secret>". The prefix is stored in clear to find the key,
+// only the SHA-256 of the whole key is stored so a database leak does not leak usable keys.
+const keyPrefix = "cz_"
+
+// Generate returns a new plaintext key, its lookup prefix and its hash.
+func Generate() (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 24)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = keyPrefix + prefix + "_" + hex.EncodeToString(secretBytes)
+	return key, prefix, Hash(key), nil
+}
+
+// Hash returns the hex encoded SHA-256 of key.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prefix extracts the lookup prefix from a plaintext key.
+func Prefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, keyPrefix), "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Matches reports whether key hashes to hash, in constant time.
+func Matches(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
@@ -0,0 +1,70 @@
+package apikey
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	format := regexp.MustCompile(`^cz_([0-9a-f]{8})_[0-9a-f]{48}$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		key, prefix, hash, err := Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		match := format.FindStringSubmatch(key)
+		if match == nil || match[1] != prefix {
+			t.Fatalf("key %q with prefix %q", key, prefix)
+		}
+		if hash != Hash(key) || len(hash) != 64 {
+			t.Fatalf("hash %q of %q", hash, key)
+		}
+		if seen[key] {
+			t.Fatalf("key %q generated twice", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	key, want, _, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix, ok := Prefix(key); !ok || prefix != want {
+		t.Fatalf("Prefix(%q) = %q, %v, want %q", key, prefix, ok, want)
+	}
+
+	for _, key := range []string{"", "cz_", "cz__secret", "cz_1234abcd", "cz_1234abcd_", "xx_1234abcd_secret", "1234abcd_secret"} {
+		if prefix, ok := Prefix(key); ok {
+			t.Errorf("Prefix(%q) = %q, should be malformed", key, prefix)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Matches(key, hash) {
+		t.Fatal("a key must match its own hash")
+	}
+
+	// Guessing the secret of a known prefix
+	other, _, _, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	guess := "cz_" + prefix + other[len("cz_")+len(prefix):]
+	for _, key := range []string{guess, key + "0", key[:len(key)-1], ""} {
+		if Matches(key, hash) {
+			t.Errorf("%q should not match the hash of another key", key)
+		}
+	}
+	if Matches(key, "") {
+		t.Error("a key must not match an empty hash")
+	}
+}
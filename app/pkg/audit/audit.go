@@ -0,0 +1,72 @@
+// Package audit records who changed what. The HTTP layer attaches a Request to the context, the
+// GORM plugin reads it back when it writes audit_logs entries for creates, updates and deletes.
+package audit
+
+import "context"
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+
+	// RoleSystem is the actor of changes made outside a request, such as CLI commands and seeders.
+	RoleSystem = "system"
+	// RoleAnonymous is the actor of changes made by unauthenticated requests, such as registration.
+	RoleAnonymous = "anonymous"
+	RoleAPIKey    = "api_key"
+)
+
+// Request describes where a change comes from. The actor is filled in by the authentication
+// middlewares once they know it, which is why the context holds a pointer.
+type Request struct {
+	IP        string
+	UserAgent string
+	RequestID string
+
+	ActorID   *uint
+	ActorRole string
+	APIKeyID  *uint
+}
+
+// SetUser records the authenticated user.
+func (r *Request) SetUser(id uint, role string) {
+	r.ActorID = &id
+	r.ActorRole = role
+}
+
+// SetAPIKey records the API key a partner integration authenticated with.
+func (r *Request) SetAPIKey(id uint) {
+	r.APIKeyID = &id
+	r.ActorRole = RoleAPIKey
+}
+
+type contextKey struct{}
+
+// WithRequest returns a copy of ctx carrying r.
+func WithRequest(ctx context.Context, r *Request) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the request attached to ctx, or nil.
+func FromContext(ctx context.Context) *Request {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(contextKey{}).(*Request)
+	return r
+}
+
+type skipKey struct{}
+
+// WithoutAudit returns a copy of ctx whose changes are not logged, for bookkeeping writes such as
+// usage counters.
+func WithoutAudit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"be-car-zone/app/models"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Redacted replaces the values of secret and encrypted columns in the log.
+const Redacted = "[redacted]"
+
+const snapshotKey = "audit:snapshot"
+
+// Plugin writes an audit_logs entry for every row created, updated or deleted through GORM. The
+// entries are inserted in the transaction of the change, a failing insert rolls the change back.
+type Plugin struct {
+	// Skip lists tables that are not audited, audit_logs itself is always skipped.
+	Skip []string
+}
+
+func (Plugin) Name() string {
+	return "audit"
+}
+
+func (p Plugin) Initialize(db *gorm.DB) error {
+	const commit = "gorm:commit_or_rollback_transaction"
+
+	create := db.Callback().Create()
+	if err := create.After("gorm:after_create").Before(commit).Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+
+	update := db.Callback().Update()
+	if err := update.Before("gorm:update").After("gorm:before_update").Register("audit:before_update", p.snapshot); err != nil {
+		return err
+	}
+	if err := update.After("gorm:after_update").Before(commit).Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+
+	del := db.Callback().Delete()
+	if err := del.Before("gorm:delete").After("gorm:before_delete").Register("audit:before_delete", p.snapshot); err != nil {
+		return err
+	}
+	return del.After("gorm:after_delete").Before(commit).Register("audit:after_delete", p.afterDelete)
+}
+
+func (p Plugin) audited(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	if db.Statement.Context != nil && skipped(db.Statement.Context) {
+		return false
+	}
+	table := db.Statement.Schema.Table
+	if table == "audit_logs" {
+		return false
+	}
+	for _, skip := range p.Skip {
+		if skip == table {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Plugin) afterCreate(db *gorm.DB) {
+	// Associations saved along with their parent are upserted, nothing is written when they exist
+	if !p.audited(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	var entries []models.AuditLog
+	eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+		changes := map[string]models.AuditChange{}
+		for _, field := range auditedFields(db.Statement.Schema) {
+			value, zero := field.ValueOf(db.Statement.Context, row)
+			if zero {
+				continue
+			}
+			changes[field.DBName] = models.AuditChange{New: loggedValue(field, value)}
+		}
+		entries = append(entries, newEntry(db, ActionCreate, row, changes))
+	})
+	write(db, entries)
+}
+
+// snapshot loads the rows an update or delete is about to touch, so they can be compared with
+// the result afterwards.
+func (p Plugin) snapshot(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	var ids []interface{}
+	eachRow(stmt.ReflectValue, func(row reflect.Value) {
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row); !zero {
+			ids = append(ids, id)
+		}
+	})
+	if len(ids) > 0 {
+		conditions = append(conditions, clause.IN{Column: clause.PrimaryColumn, Values: ids})
+	}
+	// Global updates are refused by GORM anyway
+	if len(conditions) == 0 {
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	query := session(db).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+	if err := query.Clauses(clause.Where{Exprs: conditions}).Find(rows.Interface()).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(snapshotKey, rows.Elem())
+}
+
+func (p Plugin) afterUpdate(db *gorm.DB) {
+	before, ok := snapshotOf(db)
+	if !ok || !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+
+	ids := make([]interface{}, before.Len())
+	for i := range ids {
+		ids[i], _ = stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, before.Index(i))
+	}
+	after := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	query := session(db).Unscoped().Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if err := query.Clauses(clause.IN{Column: clause.PrimaryColumn, Values: ids}).Find(after.Interface()).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	afterByID := map[string]reflect.Value{}
+	eachRow(after.Elem(), func(row reflect.Value) {
+		afterByID[primaryKey(db, row)] = row
+	})
+
+	deletedAt := deletedAtField(stmt.Schema)
+	var entries []models.AuditLog
+	eachRow(before, func(old reflect.Value) {
+		current, ok := afterByID[primaryKey(db, old)]
+		if !ok {
+			return
+		}
+
+		changes := map[string]models.AuditChange{}
+		for _, field := range auditedFields(stmt.Schema) {
+			if field.DBName == "updated_at" {
+				continue
+			}
+			oldValue, _ := field.ValueOf(stmt.Context, old)
+			newValue, _ := field.ValueOf(stmt.Context, current)
+			if reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			changes[field.DBName] = models.AuditChange{Old: loggedValue(field, oldValue), New: loggedValue(field, newValue)}
+		}
+		if len(changes) == 0 {
+			return
+		}
+
+		action := ActionUpdate
+		if deletedAt != nil {
+			if change, ok := changes[deletedAt.DBName]; ok && change.New == nil {
+				action = ActionRestore
+			}
+		}
+		entries = append(entries, newEntry(db, action, old, changes))
+	})
+	write(db, entries)
+}
+
+func (p Plugin) afterDelete(db *gorm.DB) {
+	before, ok := snapshotOf(db)
+	if !ok || !p.audited(db) {
+		return
+	}
+
+	// Soft deletes keep the row, purges remove it for good
+	action := ActionDelete
+	if db.Statement.Unscoped || deletedAtField(db.Statement.Schema) == nil {
+		action = ActionPurge
+	}
+
+	var entries []models.AuditLog
+	eachRow(before, func(old reflect.Value) {
+		changes := map[string]models.AuditChange{}
+		for _, field := range auditedFields(db.Statement.Schema) {
+			value, zero := field.ValueOf(db.Statement.Context, old)
+			if zero {
+				continue
+			}
+			changes[field.DBName] = models.AuditChange{Old: loggedValue(field, value)}
+		}
+		entries = append(entries, newEntry(db, action, old, changes))
+	})
+	write(db, entries)
+}
+
+func newEntry(db *gorm.DB, action string, row reflect.Value, changes map[string]models.AuditChange) models.AuditLog {
+	entry := models.AuditLog{
+		ActorRole: RoleSystem,
+		Action:    action,
+		Entity:    db.Statement.Schema.Table,
+		EntityID:  primaryKey(db, row),
+		Changes:   changes,
+	}
+	if r := FromContext(db.Statement.Context); r != nil {
+		entry.IP = r.IP
+		entry.UserAgent = r.UserAgent
+		entry.RequestID = r.RequestID
+		entry.ActorID = r.ActorID
+		entry.APIKeyID = r.APIKeyID
+		entry.ActorRole = RoleAnonymous
+		if r.ActorRole != "" {
+			entry.ActorRole = r.ActorRole
+		}
+	}
+	return entry
+}
+
+func write(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := session(db).Create(&entries).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// session runs statements on the connection of db, inside its transaction when there is one.
+func session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+}
+
+func snapshotOf(db *gorm.DB) (reflect.Value, bool) {
+	value, ok := db.InstanceGet(snapshotKey)
+	if !ok || db.Error != nil {
+		return reflect.Value{}, false
+	}
+	return value.(reflect.Value), true
+}
+
+func primaryKey(db *gorm.DB, row reflect.Value) string {
+	id, _ := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, row)
+	return fmt.Sprint(id)
+}
+
+func eachRow(value reflect.Value, fn func(reflect.Value)) {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			row := reflect.Indirect(value.Index(i))
+			if row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
+
+func auditedFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName != "" && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func deletedAtField(s *schema.Schema) *schema.Field {
+	field := s.LookUpField("deleted_at")
+	if field == nil || field.FieldType != reflect.TypeOf(gorm.DeletedAt{}) {
+		return nil
+	}
+	return field
+}
+
+// loggedValue hides passwords, token hashes and encrypted personal data. Columns that never
+// leave the API (json:"-") and encrypted columns are only recorded as changed.
+func loggedValue(field *schema.Field, value interface{}) interface{} {
+	if field.TagSettings["SERIALIZER"] == "encrypted" || field.Tag.Get("json") == "-" {
+		return Redacted
+	}
+	if deletedAt, ok := value.(gorm.DeletedAt); ok {
+		if !deletedAt.Valid {
+			return nil
+		}
+		return deletedAt.Time
+	}
+	return value
+}
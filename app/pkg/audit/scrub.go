@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"be-car-zone/app/models"
+	"encoding/json"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Erased replaces the logged values of personal data once its owner has been erased.
+const Erased = "[erased]"
+
+// Scrub replaces the logged values of columns with Erased in the entries about the rows ids of
+// table, every column when none are given. Entries are never changed otherwise, erasing a user
+// must not leave the personal data behind in the history of the rows.
+func Scrub(db *gorm.DB, table string, ids []uint, columns ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	entityIDs := make([]string, len(ids))
+	for i, id := range ids {
+		entityIDs[i] = strconv.FormatUint(uint64(id), 10)
+	}
+
+	var entries []models.AuditLog
+	if err := db.Where("entity = ? AND entity_id IN ?", table, entityIDs).Find(&entries).Error; err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		scrubbed := false
+		for column, change := range entry.Changes {
+			if len(columns) > 0 && !contains(columns, column) {
+				continue
+			}
+			if change.Old != nil {
+				change.Old = Erased
+			}
+			if change.New != nil {
+				change.New = Erased
+			}
+			entry.Changes[column] = change
+			scrubbed = true
+		}
+		if !scrubbed {
+			continue
+		}
+
+		// Through the table, the model refuses updates
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		if err := db.Table("audit_logs").Where("id = ?", entry.ID).Update("changes", string(changes)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScrubActor clears the IP address and the user agent of the entries of the changes actorID made.
+func ScrubActor(db *gorm.DB, actorID uint) error {
+	return db.Table("audit_logs").Where("actor_id = ?", actorID).
+		Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used in model tags: `gorm:"serializer:encrypted"`.
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer transparently encrypts string fields with the default keyring. Values are bound to
+// their table and column. Empty strings are stored as is so "not set" checks keep working.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("encryption: unsupported value %T for %s", dbValue, field.Name)
+	}
+
+	plaintext := stored
+	if stored != "" {
+		ring, err := Default()
+		if err != nil {
+			return err
+		}
+		plaintext, err = ring.Decrypt(stored, fieldContext(field))
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", field.Schema.Table, field.DBName, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+func (Serializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encryption: %s must be a string", field.Name)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+
+	ring, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Encrypt(plaintext, fieldContext(field))
+}
+
+func fieldContext(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
+
+// Reencrypt rewrites every row of model that still holds plaintext or values sealed with an old
+// key, so they end up under the primary key. Rows in the trash are rewritten too, an old key is
+// only safe to drop once nothing uses it. Model hooks run, which keeps blind indexes in sync.
+// It returns the number of rows rewritten.
+func Reencrypt(db *gorm.DB, model interface{}) (int64, error) {
+	ring, err := Default()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+
+	var conditions []clause.Expression
+	for _, field := range stmt.Schema.Fields {
+		if field.TagSettings["SERIALIZER"] != SerializerName {
+			continue
+		}
+		column := clause.Column{Name: field.DBName}
+		conditions = append(conditions, clause.And(
+			clause.Neq{Column: column, Value: ""},
+			clause.Not(clause.Like{Column: column, Value: ring.primaryPrefix() + "%"}),
+		))
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+
+	// The query runs against the raw column values, rows are decrypted when they are scanned
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem())).Interface()
+	var count int64
+	result := db.Unscoped().Model(model).Clauses(clause.Where{Exprs: []clause.Expression{clause.Or(conditions...)}}).
+		FindInBatches(rows, 100, func(tx *gorm.DB, _ int) error {
+			batch := reflect.ValueOf(rows).Elem()
+			for i := 0; i < batch.Len(); i++ {
+				row := batch.Index(i).Addr().Interface()
+				err := db.Unscoped().Model(row).Select("*").Omit(clause.Associations, "created_at", "updated_at").Updates(row).Error
+				if err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		})
+	return count, result.Error
+}
+
+// Plugin seals encrypted columns in map updates such as db.Model(&user).Update("address", v).
+// GORM only runs serializers for struct values, without the plugin those updates would be
+// written in plaintext.
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "encryption"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	update := db.Callback().Update()
+	// After the model hooks, so BeforeSave still sees the plaintext to compute blind indexes
+	if err := update.Before("gorm:update").After("gorm:before_update").Register("encryption:seal_map_updates", sealMapUpdates); err != nil {
+		return err
+	}
+	return update.After("gorm:update").Register("encryption:restore_map_updates", restoreMapUpdates)
+}
+
+const plaintextUpdatesKey = "encryption:plaintext_updates"
+
+func sealMapUpdates(db *gorm.DB) {
+	updates, ok := db.Statement.Dest.(map[string]interface{})
+	if db.Error != nil || !ok || db.Statement.Schema == nil {
+		return
+	}
+
+	plaintexts := map[string]interface{}{}
+	for key, value := range updates {
+		field := db.Statement.Schema.LookUpField(key)
+		if field == nil || field.TagSettings["SERIALIZER"] != SerializerName {
+			continue
+		}
+		sealed, err := Serializer{}.Value(db.Statement.Context, field, reflect.Value{}, value)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		plaintexts[key] = value
+		updates[key] = sealed
+	}
+	if len(plaintexts) > 0 {
+		db.InstanceSet(plaintextUpdatesKey, plaintexts)
+	}
+}
+
+// restoreMapUpdates puts the plaintext back into the map and the model, GORM copied the sealed
+// values into the model while building the query.
+func restoreMapUpdates(db *gorm.DB) {
+	value, ok := db.InstanceGet(plaintextUpdatesKey)
+	if !ok {
+		return
+	}
+	updates := db.Statement.Dest.(map[string]interface{})
+	for key, plaintext := range value.(map[string]interface{}) {
+		updates[key] = plaintext
+		if db.Statement.ReflectValue.Kind() == reflect.Struct && db.Statement.ReflectValue.CanAddr() {
+			field := db.Statement.Schema.LookUpField(key)
+			field.Set(db.Statement.Context, db.Statement.ReflectValue, plaintext)
+		}
+	}
+}
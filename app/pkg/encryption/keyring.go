@@ -0,0 +1,263 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// envelopePrefix marks values sealed by a Keyring, anything else is treated as legacy plaintext.
+const envelopePrefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("encryption: unknown key id")
+
+// Keyring seals database fields with envelope encryption. Every value gets its own random data
+// key, which is wrapped with the primary key encryption key (KEK) and stored next to the value:
+//
+//	enc:v1:<kek id>:<wrapped data key>:<ciphertext>
+//
+// Rotating means adding a new KEK as primary, old KEKs stay in the ring so existing values can
+// still be read until they are re-encrypted.
+type Keyring struct {
+	primary  string
+	keys     map[string]*Cipher
+	indexKey []byte
+}
+
+// NewKeyring builds a keyring from KEKs by id. indexKey is the HMAC key used for blind indexes,
+// it is separate from the KEKs because rotating it would invalidate every index.
+func NewKeyring(primary string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	ring := &Keyring{primary: primary, keys: make(map[string]*Cipher, len(keys)), indexKey: indexKey}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("encryption: invalid key id %q", id)
+		}
+		c, err := New(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: key %q: %w", id, err)
+		}
+		ring.keys[id] = c
+	}
+	if _, ok := ring.keys[primary]; !ok {
+		return nil, fmt.Errorf("encryption: primary key %q is not in the keyring", primary)
+	}
+	if len(indexKey) == 0 {
+		return nil, errors.New("encryption: blind index key is empty")
+	}
+	return ring, nil
+}
+
+// Primary returns the id of the KEK used for new values.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Encrypt seals a field value. context is authenticated with it, callers pass the table and
+// column so a value cannot be copied into another column.
+func (k *Keyring) Encrypt(plaintext, context string) (string, error) {
+	header, ciphertext, err := k.seal([]byte(plaintext), context)
+	if err != nil {
+		return "", err
+	}
+	return header + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values without the envelope prefix were written before
+// encryption was enabled and are returned unchanged.
+func (k *Keyring) Decrypt(value, context string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", ErrDecrypt
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	plaintext, err := k.open(parts[0], parts[1], ciphertext, context)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Seal encrypts binary content such as a file like Encrypt does, the ciphertext follows the
+// envelope header as raw bytes instead of base64.
+func (k *Keyring) Seal(plaintext []byte, context string) ([]byte, error) {
+	header, ciphertext, err := k.seal(plaintext, context)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(header), ciphertext...), nil
+}
+
+// Open opens content sealed by Seal, see IsSealed for content sealed some other way.
+func (k *Keyring) Open(sealed []byte, context string) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, ErrDecrypt
+	}
+	parts := bytes.SplitN(sealed[len(envelopePrefix):], []byte(":"), 3)
+	if len(parts) != 3 {
+		return nil, ErrDecrypt
+	}
+	return k.open(string(parts[0]), string(parts[1]), parts[2], context)
+}
+
+// seal encrypts plaintext with a new data key wrapped by the primary KEK. header is the
+// envelope up to the ciphertext.
+func (k *Keyring) seal(plaintext []byte, context string) (string, []byte, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", nil, err
+	}
+	data, err := New(dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ciphertext, err := data.Encrypt(plaintext, []byte(context))
+	if err != nil {
+		return "", nil, err
+	}
+	wrapped, err := k.keys[k.primary].Encrypt(dataKey, []byte(k.primary))
+	if err != nil {
+		return "", nil, err
+	}
+	return k.primaryPrefix() + base64.RawStdEncoding.EncodeToString(wrapped) + ":", ciphertext, nil
+}
+
+// open unwraps the data key with the KEK kekID and decrypts ciphertext with it.
+func (k *Keyring) open(kekID, wrapped string, ciphertext []byte, context string) ([]byte, error) {
+	kek, ok := k.keys[kekID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kekID)
+	}
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	dataKey, err := kek.Decrypt(wrappedKey, []byte(kekID))
+	if err != nil {
+		return nil, err
+	}
+	data, err := New(dataKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return data.Decrypt(ciphertext, []byte(context))
+}
+
+// NeedsRotation reports whether a stored value is plaintext or sealed with an old KEK.
+func (k *Keyring) NeedsRotation(value string) bool {
+	return value != "" && !strings.HasPrefix(value, k.primaryPrefix())
+}
+
+// SealedWithPrimary reports whether content was sealed by Seal with the primary KEK, anything
+// else needs to be sealed again before an old key is dropped.
+func (k *Keyring) SealedWithPrimary(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(k.primaryPrefix()))
+}
+
+func (k *Keyring) primaryPrefix() string {
+	return envelopePrefix + k.primary + ":"
+}
+
+// BlindIndex returns a keyed hash of value for exact match lookups on encrypted columns.
+// purpose separates indexes of different columns so equal values do not share a hash.
+func (k *Keyring) BlindIndex(purpose, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// IsEncrypted reports whether value was sealed by a Keyring.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// IsSealed reports whether content was sealed by Keyring.Seal.
+func IsSealed(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(envelopePrefix))
+}
+
+// ParseKeys parses FIELD_ENCRYPTION_KEYS, a comma separated list of id:key pairs with the primary
+// key first. Keys are 32 bytes encoded as base64 or hex, see ParseKey.
+func ParseKeys(value string) (string, map[string][]byte, error) {
+	keys := map[string][]byte{}
+	primary := ""
+	for _, entry := range strings.Split(value, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return "", nil, errors.New("entries must look like id:key")
+		}
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", id, err)
+		}
+		if _, ok := keys[id]; ok {
+			return "", nil, fmt.Errorf("key %q is listed twice", id)
+		}
+		keys[id] = key
+		if primary == "" {
+			primary = id
+		}
+	}
+	return primary, keys, nil
+}
+
+// DeriveKey derives the key called name from secret. It is meant for development setups without
+// keys of their own, anything that derives from the signing secret falls with it.
+func DeriveKey(secret, name string) []byte {
+	sum := sha256.Sum256([]byte(secret + ":" + name))
+	return sum[:]
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultKeyring *Keyring
+)
+
+// ErrNoKeyring is returned while SetDefault has not been called.
+var ErrNoKeyring = errors.New("encryption: no keyring is configured")
+
+// Default returns the keyring used by the GORM serializer, see SetDefault.
+func Default() (*Keyring, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultKeyring == nil {
+		return nil, ErrNoKeyring
+	}
+	return defaultKeyring, nil
+}
+
+// SetDefault replaces the keyring used by the GORM serializer.
+func SetDefault(ring *Keyring) {
+	defaultMu.Lock()
+	defaultKeyring = ring
+	defaultMu.Unlock()
+}
+
+// BlindIndex computes a blind index with the default keyring.
+func BlindIndex(purpose, value string) (string, error) {
+	ring, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return ring.BlindIndex(purpose, value), nil
+}
@@ -0,0 +1,114 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	ring, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := ring.Encrypt("081234567890", "users.phone_number")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "081234567890") {
+		t.Fatalf("unexpected envelope %q", sealed)
+	}
+
+	again, _ := ring.Encrypt("081234567890", "users.phone_number")
+	if again == sealed {
+		t.Fatal("encrypting twice must not produce the same ciphertext")
+	}
+
+	plain, err := ring.Decrypt(sealed, "users.phone_number")
+	if err != nil || plain != "081234567890" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	if _, err := ring.Decrypt(sealed, "users.address"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("decrypting in another column: err = %v, want ErrDecrypt", err)
+	}
+
+	if plain, err := ring.Decrypt("Jl. Merdeka 1", "users.address"); err != nil || plain != "Jl. Merdeka 1" {
+		t.Fatalf("legacy plaintext = %q, %v", plain, err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	sealed, _ := old.Encrypt("1234567890", "transactions.no_rek")
+
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.NeedsRotation(sealed) || !rotated.NeedsRotation("plaintext") || rotated.NeedsRotation("") {
+		t.Fatal("NeedsRotation must flag plaintext and values sealed with an old key")
+	}
+	if plain, err := rotated.Decrypt(sealed, "transactions.no_rek"); err != nil || plain != "1234567890" {
+		t.Fatalf("old value after rotation = %q, %v", plain, err)
+	}
+
+	resealed, _ := rotated.Encrypt("1234567890", "transactions.no_rek")
+	if rotated.NeedsRotation(resealed) {
+		t.Fatal("new values must use the primary key")
+	}
+
+	retired, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)}, testKey(9))
+	if _, err := retired.Decrypt(sealed, "transactions.no_rek"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyringSealFile(t *testing.T) {
+	old, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	scan := []byte{0xff, 0xd8, 0x00, ':', 0xd9}
+
+	sealed, err := old.Seal(scan, "kyc/1.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || !old.SealedWithPrimary(sealed) || IsSealed(scan) {
+		t.Fatal("IsSealed and SealedWithPrimary must recognise sealed content only")
+	}
+	if _, err := old.Open(sealed, "kyc/2.jpg"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("opening under another key: err = %v, want ErrDecrypt", err)
+	}
+
+	rotated, _ := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	if rotated.SealedWithPrimary(sealed) {
+		t.Fatal("content sealed with an old key must be sealed again")
+	}
+	plain, err := rotated.Open(sealed, "kyc/1.jpg")
+	if err != nil || !bytes.Equal(plain, scan) {
+		t.Fatalf("Open = %v, %v", plain, err)
+	}
+	if _, err := rotated.Open(scan, "kyc/1.jpg"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("opening plain content: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	a, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	b, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)}, testKey(9))
+
+	if a.BlindIndex("phone", "0812") != b.BlindIndex("phone", "0812") {
+		t.Fatal("blind indexes must not depend on the encryption keys")
+	}
+	if a.BlindIndex("phone", "0812") == a.BlindIndex("nik", "0812") {
+		t.Fatal("blind indexes of different purposes must differ")
+	}
+	if a.BlindIndex("phone", "") != "" {
+		t.Fatal("empty values have no index")
+	}
+}
@@ -0,0 +1,146 @@
+// Package i18n picks the language of a response from the Accept-Language header and
+// translates validation errors to English or Bahasa Indonesia.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+	// Default is the language of clients that do not ask for one we speak.
+	Default = English
+)
+
+var translators = ut.New(en.New(), en.New(), id.New())
+
+// Language returns the supported language a client prefers, from an Accept-Language header
+// such as "id-ID,id;q=0.9,en;q=0.8".
+func Language(acceptLanguage string) string {
+	type choice struct {
+		lang    string
+		quality float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		// "in" is the code Indonesian had before 1989, old Java clients still send it
+		if primary == "in" {
+			primary = Indonesian
+		}
+		if (primary == English || primary == Indonesian) && quality > 0 {
+			choices = append(choices, choice{primary, quality})
+		}
+	}
+	if len(choices) == 0 {
+		return Default
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].quality > choices[j].quality })
+	return choices[0].lang
+}
+
+// messages are the texts of the API that are not validation messages, by key and language.
+var messages = map[string]map[string]string{
+	"invalid_fields": {
+		English:    "the request has invalid fields",
+		Indonesian: "permintaan memiliki isian yang tidak valid",
+	},
+	"invalid_type": {
+		English:    "%s must be a %s",
+		Indonesian: "%s harus bertipe %s",
+	},
+	"invalid_field": {
+		English:    "%s is invalid",
+		Indonesian: "%s tidak valid",
+	},
+}
+
+// T returns the message key in lang, formatted with args.
+func T(lang, key string, args ...interface{}) string {
+	text, ok := messages[key][lang]
+	if !ok {
+		text = messages[key][Default]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// customMessages are the messages of the validation tags registered by utils.NewValidator.
+var customMessages = map[string]map[string]string{
+	"idphone": {
+		English:    "{0} must be an Indonesian phone number, such as 081234567890",
+		Indonesian: "{0} harus berupa nomor telepon Indonesia, contohnya 081234567890",
+	},
+	"nik": {
+		English:    "{0} must be a valid 16 digit NIK",
+		Indonesian: "{0} harus berupa NIK 16 digit yang valid",
+	},
+	"platno": {
+		English:    "{0} must be a vehicle plate number, such as B 1234 ABC",
+		Indonesian: "{0} harus berupa nomor polisi kendaraan, contohnya B 1234 ABC",
+	},
+	"idr": {
+		English:    "{0} must be a whole, non negative amount of rupiah",
+		Indonesian: "{0} harus berupa jumlah rupiah bulat dan tidak negatif",
+	},
+}
+
+// RegisterValidator adds the messages of every language to v, FieldMessage translates the
+// errors v returns.
+func RegisterValidator(v *validator.Validate) error {
+	english, _ := translators.GetTranslator(English)
+	indonesian, _ := translators.GetTranslator(Indonesian)
+	if err := en_translations.RegisterDefaultTranslations(v, english); err != nil {
+		return err
+	}
+	if err := id_translations.RegisterDefaultTranslations(v, indonesian); err != nil {
+		return err
+	}
+
+	for tag, texts := range customMessages {
+		for lang, text := range texts {
+			trans, _ := translators.GetTranslator(lang)
+			register := func(trans ut.Translator) error { return trans.Add(tag, text, true) }
+			translate := func(trans ut.Translator, fe validator.FieldError) string {
+				message, _ := trans.T(fe.Tag(), fe.Field())
+				return message
+			}
+			if err := v.RegisterTranslation(tag, trans, register, translate); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FieldMessage translates a validation error of a validator set up with RegisterValidator.
+func FieldMessage(lang string, fe validator.FieldError) string {
+	trans, _ := translators.GetTranslator(lang)
+	// Translate falls back to the raw Go message for tags without a translation
+	if message := fe.Translate(trans); message != fe.Error() {
+		return message
+	}
+	return T(lang, "invalid_field", fe.Field())
+}
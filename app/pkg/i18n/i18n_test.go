@@ -0,0 +1,22 @@
+package i18n
+
+import "testing"
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                        English,
+		"id":                      Indonesian,
+		"id-ID,id;q=0.9,en;q=0.8": Indonesian,
+		"en-US,en;q=0.9,id;q=0.8": English,
+		"fr-FR,id;q=0.5":          Indonesian, // the first one we speak
+		"en;q=0.4,id;q=0.6":       Indonesian,
+		"id;q=0,en":               English, // q=0 means not acceptable
+		"in-ID":                   Indonesian,
+		"ja,zh;q=0.8":             Default,
+	}
+	for header, want := range cases {
+		if got := Language(header); got != want {
+			t.Errorf("Language(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
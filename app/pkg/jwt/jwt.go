@@ -1,7 +1,7 @@
 package jwt
 
 import (
-	"be-car-zone/app/pkg/utils"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -11,20 +11,31 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var API_SECRET = utils.Getenv("API_SECRET", "secret_key")
+// API_SECRET signs the access tokens, it is empty until Configure is called.
+var API_SECRET string
 
-func GenerateToken(user_id, role_id uint) (string, error) {
-	token_lifespan, err := strconv.Atoi(utils.Getenv("TOKEN_HOUR_LIFESPAN", "1"))
+// errNotConfigured keeps a missing Configure from signing tokens with an empty key.
+var errNotConfigured = errors.New("jwt: the signing secret is not configured")
 
-	if err != nil {
-		return "", err
-	}
+// TokenLifespan is how long an access token stays valid.
+var TokenLifespan = time.Hour
+
+// Configure sets the signing secret and the lifetime of access tokens from the loaded
+// configuration, see config.Auth.
+func Configure(secret string, lifespan time.Duration) {
+	API_SECRET = secret
+	TokenLifespan = lifespan
+}
 
+func GenerateToken(user_id, role_id uint) (string, error) {
+	if API_SECRET == "" {
+		return "", errNotConfigured
+	}
 	claims := jwt.MapClaims{
 		"authorized": true,
 		"user_id":    user_id,
 		"role":       role_id,
-		"exp":        time.Now().Add(time.Hour * time.Duration(token_lifespan)).Unix(),
+		"exp":        time.Now().Add(TokenLifespan).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -34,6 +45,9 @@ func GenerateToken(user_id, role_id uint) (string, error) {
 }
 
 func TokenValid(c *gin.Context) error {
+	if API_SECRET == "" {
+		return errNotConfigured
+	}
 	tokenString := ExtractToken(c)
 	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -63,6 +77,9 @@ func ExtractToken(c *gin.Context) string {
 }
 
 func ExtractTokenID(c *gin.Context) (uint, error) {
+	if API_SECRET == "" {
+		return 0, errNotConfigured
+	}
 
 	tokenString := ExtractToken(c)
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -86,3 +103,56 @@ func ExtractTokenID(c *gin.Context) (uint, error) {
 
 	return 0, nil
 }
+
+// OIDCState is carried in a signed cookie between the OIDC login redirect and its callback.
+type OIDCState struct {
+	Provider     string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// oidcStateSecret is derived from API_SECRET so a state cookie can never be used as an access token.
+func oidcStateSecret() []byte {
+	return []byte(API_SECRET + ":oidc-state")
+}
+
+func GenerateOIDCStateToken(state OIDCState, lifespan time.Duration) (string, error) {
+	if API_SECRET == "" {
+		return "", errNotConfigured
+	}
+	claims := jwt.MapClaims{
+		"provider":      state.Provider,
+		"state":         state.State,
+		"nonce":         state.Nonce,
+		"code_verifier": state.CodeVerifier,
+		"exp":           time.Now().Add(lifespan).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString(oidcStateSecret())
+}
+
+func ParseOIDCStateToken(tokenString string) (OIDCState, error) {
+	if API_SECRET == "" {
+		return OIDCState{}, errNotConfigured
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return oidcStateSecret(), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return OIDCState{}, err
+	}
+
+	var state OIDCState
+	state.Provider, _ = claims["provider"].(string)
+	state.State, _ = claims["state"].(string)
+	state.Nonce, _ = claims["nonce"].(string)
+	state.CodeVerifier, _ = claims["code_verifier"].(string)
+	return state, nil
+}
@@ -0,0 +1,124 @@
+// Package logging builds the structured logger of the application and carries a logger per
+// request in the context, so every line logged while handling a request has its request ID.
+//
+// Attributes named like a credential, such as password, authorization or api_key, are redacted
+// wherever they appear, in groups too. Headers and query strings go through Headers and Query,
+// which redact the values of the sensitive ones.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Formats for LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Redacted replaces the value of sensitive attributes, headers and query parameters.
+const Redacted = "[redacted]"
+
+// New returns a logger writing to w in format, json or text, from level on: debug, info, warn
+// or error.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("logging: unknown level %q", level)
+	}
+	options := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("logging: unknown format %q", format)
+}
+
+// Discard returns a logger that drops everything, for tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// sensitive are the attribute, header and query parameter names whose values are never logged,
+// compared in lower case with dashes as underscores.
+var sensitive = map[string]bool{
+	"authorization":       true,
+	"proxy_authorization": true,
+	"cookie":              true,
+	"set_cookie":          true,
+	"x_api_key":           true,
+	"api_key":             true,
+	"password":            true,
+	"secret":              true,
+	"token":               true,
+	"access_token":        true,
+	"refresh_token":       true,
+	"id_token":            true,
+	"code":                true,
+	"code_verifier":       true,
+}
+
+// Sensitive reports whether the value of name must not be logged.
+func Sensitive(name string) bool {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	return sensitive[name] || strings.HasSuffix(name, "_password") || strings.HasSuffix(name, "_secret")
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() != slog.KindGroup && Sensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// Headers returns the headers as a group attribute with the sensitive values redacted.
+func Headers(header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if Sensitive(name) {
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// Query returns the encoded query with the sensitive values redacted, such as the token of an
+// email verification link.
+func Query(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	clean := url.Values{}
+	for name, values := range query {
+		if Sensitive(name) {
+			values = []string{Redacted}
+		}
+		clean[name] = values
+	}
+	return clean.Encode()
+}
+
+type contextKey struct{}
+
+// WithLogger returns a context carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the request, slog.Default outside of one.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,134 @@
+// Package metrics exposes the Prometheus metrics of the API: the latency of the HTTP requests
+// by route and status, the connection pool of the database and counters of the business, such
+// as orders created and cars sold.
+//
+// Every Metrics has its own registry, so tests can build as many routers as they need.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "carzone"
+
+// UnmatchedRoute is the route label of requests no route matched, the raw path would let
+// scanners create a series per URL they try.
+const UnmatchedRoute = "unmatched"
+
+// Metrics holds the collectors of the application. Its recording methods do nothing on a nil
+// Metrics, for the code paths and tests running without one.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration  *prometheus.HistogramVec
+	requestsInFlight prometheus.Gauge
+
+	ordersCreated        prometheus.Counter
+	transactionsRecorded prometheus.Counter
+	carsSold             prometheus.Counter
+}
+
+// New returns metrics registered with a new registry, along with the Go runtime and process
+// collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of the HTTP requests by method, route and status.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+		requestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served.",
+		}),
+		ordersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_created_total",
+			Help:      "Orders placed.",
+		}),
+		transactionsRecorded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transactions_recorded_total",
+			Help:      "Transactions entered against an order, not payments confirmed by a provider.",
+		}),
+		carsSold: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cars_sold_total",
+			Help:      "Cars sold, counted when their order is paid.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.requestsInFlight,
+		m.ordersCreated,
+		m.transactionsRecorded,
+		m.carsSold,
+	)
+	return m
+}
+
+// RegisterDB adds the connection pool statistics of db, labelled with name.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted counts a request in flight, until RequestDone is called with its outcome.
+func (m *Metrics) RequestStarted() {
+	if m == nil {
+		return
+	}
+	m.requestsInFlight.Inc()
+}
+
+// RequestDone observes a request answered with status after duration. An empty route is a
+// request no route matched.
+func (m *Metrics) RequestDone(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	m.requestsInFlight.Dec()
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// OrderCreated counts an order placed.
+func (m *Metrics) OrderCreated() {
+	if m == nil {
+		return
+	}
+	m.ordersCreated.Inc()
+}
+
+// TransactionRecorded counts a transaction entered against an order. It is not a payment
+// confirmed by a payment provider, transactions are entered by hand.
+func (m *Metrics) TransactionRecorded() {
+	if m == nil {
+		return
+	}
+	m.transactionsRecorded.Inc()
+}
+
+// CarSold counts a car sold.
+func (m *Metrics) CarSold() {
+	if m == nil {
+		return
+	}
+	m.carsSold.Inc()
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNilMetricsRecordNothing(t *testing.T) {
+	var m *Metrics
+	m.RequestStarted()
+	m.RequestDone(http.MethodGet, "/api/cms/cars", http.StatusOK, time.Millisecond)
+	m.OrderCreated()
+	m.TransactionRecorded()
+	m.CarSold()
+}
+
+func TestRequestDone(t *testing.T) {
+	m := New()
+	m.RequestStarted()
+	m.RequestStarted()
+	m.RequestDone(http.MethodGet, "/api/cms/cars/:id", http.StatusOK, 30*time.Millisecond)
+	if got := testutil.ToFloat64(m.requestsInFlight); got != 1 {
+		t.Errorf("in flight = %v, want 1", got)
+	}
+	m.RequestDone(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+
+	if got := testutil.CollectAndCount(m.requestDuration); got != 2 {
+		t.Errorf("%d series, want 2", got)
+	}
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`http_request_duration_seconds_bucket{method="GET",route="/api/cms/cars/:id",status="200",le="0.05"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("no %s in\n%s", want, recorder.Body)
+		}
+	}
+}
+
+func TestBusinessCounters(t *testing.T) {
+	m := New()
+	m.OrderCreated()
+	m.OrderCreated()
+	m.TransactionRecorded()
+	m.CarSold()
+	if testutil.ToFloat64(m.ordersCreated) != 2 || testutil.ToFloat64(m.transactionsRecorded) != 1 || testutil.ToFloat64(m.carsSold) != 1 {
+		t.Error("the counters do not match the events")
+	}
+}
@@ -0,0 +1,383 @@
+// Package migrate applies versioned SQL migrations and records them in the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Table records the applied versions.
+const Table = "schema_migrations"
+
+var (
+	// ErrPending is returned by Check when the database is behind the migrations of the build.
+	ErrPending = errors.New("migrate: database schema is out of date")
+	// ErrUnknown is returned by Check when the database has versions this build does not know,
+	// it was migrated by a newer release.
+	ErrUnknown = errors.New("migrate: database schema is newer than this build")
+	// ErrUnversioned is returned by Up when the database has tables but no recorded versions,
+	// its schema was created some other way, such as by AutoMigrate. See Baseline.
+	ErrUnversioned = errors.New("migrate: database has tables but no recorded migrations")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one version, Up and Down hold the SQL statements.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with the time it was applied, AppliedAt is nil while it is pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return Table
+}
+
+// Migrator runs the migrations of one dialect against a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations for the dialect of db from the <dialect> directory of fsys.
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads the migrations of dir, sorted by version. Every version needs both an up and a down file.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: no migrations for %q: %w", dir, err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migrate: version %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest is the version the build expects, 0 when there are no migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists the known migrations followed by applied versions this build does not know.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range sortedRows(applied) {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{Migration: Migration{Version: row.Version, Name: row.Name}, AppliedAt: &appliedAt})
+	}
+	return statuses, nil
+}
+
+// Check verifies that every migration is applied and that the database has no unknown versions.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			return fmt.Errorf("%w, version %d_%s is pending", ErrPending, status.Version, status.Name)
+		}
+		if status.Up == "" {
+			return fmt.Errorf("%w, version %d_%s is applied", ErrUnknown, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies the pending migrations, at most steps of them when steps is positive.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		// The first migration would only partly apply to tables that exist already
+		if len(applied) == 0 {
+			tables, err := m.tables(db)
+			if err != nil {
+				return err
+			}
+			if len(tables) > 0 {
+				return fmt.Errorf("%w, found %s", ErrUnversioned, strings.Join(tables, ", "))
+			}
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			err := m.run(db, migration.Up, func(tx *gorm.DB) error {
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: up %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		rows := sortedRows(applied)
+		for i := len(rows) - 1; i >= 0 && len(done) < steps; i-- {
+			migration, ok := m.find(rows[i].Version)
+			if !ok {
+				return fmt.Errorf("%w, version %d_%s cannot be rolled back by this build", ErrUnknown, rows[i].Version, rows[i].Name)
+			}
+			err := m.run(db, migration.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: down %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records the migrations up to version as applied without running them, for a database
+// whose schema was created some other way and was checked to match them. Only a database without
+// recorded versions can be baselined.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	if _, ok := m.find(version); !ok {
+		return nil, fmt.Errorf("migrate: unknown version %d", version)
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			return errors.New("migrate: the database already has recorded migrations, baseline is for unversioned databases")
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				if err := tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error; err != nil {
+					return err
+				}
+				done = append(done, migration)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// run executes the statements of a migration and records the result. Postgres and SQLite run it in
+// one transaction, MySQL commits DDL implicitly so a failure there leaves the statements that ran.
+func (m *Migrator) run(db *gorm.DB, sql string, record func(tx *gorm.DB) error) error {
+	execute := func(tx *gorm.DB) error {
+		for _, statement := range Statements(sql) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	}
+	if db.Dialector.Name() == "mysql" {
+		return execute(db)
+	}
+	return db.Transaction(execute)
+}
+
+// locked runs fn on a single connection holding a database lock, so instances starting at the
+// same time do not migrate concurrently. SQLite serves a single process and needs no lock.
+func (m *Migrator) locked(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(db *gorm.DB) error {
+		if err := db.Exec("CREATE TABLE IF NOT EXISTS " + Table + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)").Error; err != nil {
+			return err
+		}
+
+		switch db.Dialector.Name() {
+		case "mysql":
+			var acquired int
+			if err := db.Raw("SELECT GET_LOCK(?, 60)", Table).Scan(&acquired).Error; err != nil {
+				return err
+			}
+			if acquired != 1 {
+				return errors.New("migrate: timed out waiting for the migration lock")
+			}
+			defer db.Exec("SELECT RELEASE_LOCK(?)", Table)
+		case "postgres":
+			if err := db.Exec("SELECT pg_advisory_lock(hashtext(?))", Table).Error; err != nil {
+				return err
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(hashtext(?))", Table)
+		}
+		return fn(db)
+	})
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]schemaMigration, error) {
+	applied := map[int64]schemaMigration{}
+	if !db.Migrator().HasTable(Table) {
+		return applied, nil
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// tables lists the tables of the database besides the versions and those of the database itself.
+func (m *Migrator) tables(db *gorm.DB) ([]string, error) {
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, table := range all {
+		if table != Table && !strings.HasPrefix(table, "sqlite_") {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func sortedRows(applied map[int64]schemaMigration) []schemaMigration {
+	rows := make([]schemaMigration, 0, len(applied))
+	for _, row := range applied {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Version < rows[j].Version })
+	return rows
+}
+
+// Statements splits a migration into statements. A statement ends with a semicolon at the end
+// of a line, full line "--" comments are dropped.
+func Statements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"be-car-zone/app/migrations"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDialectsHaveTheSameVersions(t *testing.T) {
+	versions := map[string][]string{}
+	for _, dialect := range []string{"mysql", "postgres", "sqlite"} {
+		loaded, err := Load(migrations.FS, dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded) == 0 {
+			t.Fatalf("no %s migrations", dialect)
+		}
+		for _, migration := range loaded {
+			versions[dialect] = append(versions[dialect], migration.Name)
+		}
+	}
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		if !reflect.DeepEqual(versions["mysql"], versions[dialect]) {
+			t.Fatalf("mysql has %v, %s has %v", versions["mysql"], dialect, versions[dialect])
+		}
+	}
+}
+
+func TestLoadRequiresBothDirections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mysql/0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"mysql/0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"mysql/0002_next.up.sql":   {Data: []byte("ALTER TABLE a ADD b INT;")},
+	}
+	if _, err := Load(fsys, "mysql"); err == nil {
+		t.Fatal("a version without a down file must be rejected")
+	}
+
+	fsys["mysql/0002_next.down.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE a DROP b;")}
+	loaded, err := Load(fsys, "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].Version != 1 || loaded[1].Name != "next" {
+		t.Fatalf("Load = %+v", loaded)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	sql := "-- a comment\nCREATE TABLE a (\n  id INT\n);\n\nCREATE INDEX idx ON a (id);\nDROP TABLE b"
+	want := []string{"CREATE TABLE a (\n  id INT\n)", "CREATE INDEX idx ON a (id)", "DROP TABLE b"}
+	if got := Statements(sql); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Statements = %q, want %q", got, want)
+	}
+}
+
+func TestUpRefusesUnversionedDatabases(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sqlite/0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"sqlite/0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"sqlite/0002_next.up.sql":   {Data: []byte("ALTER TABLE a ADD b INT;")},
+		"sqlite/0002_next.down.sql": {Data: []byte("ALTER TABLE a DROP b;")},
+	}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A table created by AutoMigrate
+	if err := db.Exec("CREATE TABLE a (id INT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := New(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrUnversioned) {
+		t.Fatalf("Up on an unversioned database: err = %v, want ErrUnversioned", err)
+	}
+	if _, err := migrator.Baseline(ctx, 3); err == nil {
+		t.Fatal("an unknown version must not be recorded")
+	}
+
+	recorded, err := migrator.Baseline(ctx, 1)
+	if err != nil || len(recorded) != 1 || recorded[0].Name != "init" {
+		t.Fatalf("Baseline = %+v, %v", recorded, err)
+	}
+	if _, err := migrator.Baseline(ctx, 1); err == nil {
+		t.Fatal("a versioned database must not be baselined again")
+	}
+
+	applied, err := migrator.Up(ctx, 0)
+	if err != nil || len(applied) != 1 || applied[0].Name != "next" {
+		t.Fatalf("Up after the baseline = %+v, %v", applied, err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"be-car-zone/app/pkg/oidc"
+	"be-car-zone/app/pkg/oidc/mockidp"
+	"be-car-zone/app/pkg/utils"
+)
+
+const redirectURL = "http://app.test/api/auth/oidc/mock/callback"
+
+func newMockProvider(t *testing.T) (*oidc.Provider, *http.Client) {
+	t.Helper()
+
+	var idp *mockidp.Server
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.Handler().ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	idp = mockidp.New(srv.URL+"/idp", "client", "secret")
+
+	client := srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	return oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       idp.Issuer,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	}, client), client
+}
+
+// authorize follows the login redirect and returns the code and state sent back to our callback.
+func authorize(t *testing.T, client *http.Client, authURL string) (code, state string) {
+	t.Helper()
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	ctx := context.Background()
+	provider, client := newMockProvider(t)
+
+	state, nonce, verifier := utils.RandomToken(), utils.RandomToken(), utils.RandomToken()
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier, url.Values{"login_hint": {"budi@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, gotState := authorize(t, client, authURL)
+	if gotState != state {
+		t.Fatalf("state = %q, want %q", gotState, state)
+	}
+
+	tokens, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Email != "budi@example.com" || !claims.EmailVerified || claims.Subject == "" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider, client := newMockProvider(t)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", utils.RandomToken(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := authorize(t, client, authURL)
+
+	if _, err := provider.Exchange(ctx, code, utils.RandomToken()); err == nil {
+		t.Fatal("exchange with a different code verifier succeeded")
+	}
+}
+
+func TestVerifyIDTokenRejectsWrongNonce(t *testing.T) {
+	ctx := context.Background()
+	provider, client := newMockProvider(t)
+
+	verifier := utils.RandomToken()
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", verifier, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := authorize(t, client, authURL)
+
+	tokens, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, tokens.IDToken, "other"); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Fatalf("err = %v, want ErrNonceMismatch", err)
+	}
+}
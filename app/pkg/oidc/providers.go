@@ -0,0 +1,15 @@
+package oidc
+
+import (
+	"net/http"
+)
+
+// NewProviders builds a provider for every config, keyed by its name. The configs come from
+// OIDC_PROVIDERS, see config.OIDC.
+func NewProviders(configs []Config, client *http.Client) map[string]*Provider {
+	providers := make(map[string]*Provider, len(configs))
+	for _, cfg := range configs {
+		providers[cfg.Name] = NewProvider(cfg, client)
+	}
+	return providers
+}
@@ -0,0 +1,231 @@
+// Package problem writes API errors as RFC 7807 problem details. Every error response has the
+// same envelope, a stable machine readable code, the request ID and, for invalid input, the
+// fields at fault:
+//
+//	{
+//	  "type": "urn:carzone:problem:validation_failed",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "detail": "the request has invalid fields",
+//	  "instance": "/api/auth/register",
+//	  "code": "validation_failed",
+//	  "request_id": "3f6c...",
+//	  "errors": [{"field": "email", "rule": "email", "message": "email must be a valid email address"}]
+//	}
+//
+// Validation messages are in English or Bahasa Indonesia, following the Accept-Language header.
+// Error maps the errors of the database and of the validator to the right status, anything it
+// does not know is a 500 whose message is not shown to the client.
+package problem
+
+import (
+	"be-car-zone/app/pkg/i18n"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// typePrefix makes the type URI of a problem from its code.
+const typePrefix = "urn:carzone:problem:"
+
+// Code identifies a kind of error, clients switch on it rather than on the detail message.
+type Code string
+
+const (
+	CodeInvalidRequest   Code = "invalid_request"
+	CodeValidationFailed Code = "validation_failed"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	// CodeDuplicate means a unique column already holds the value.
+	CodeDuplicate Code = "duplicate"
+	// CodeReferenced means a foreign key rejected the change, the row is still in use or the
+	// row it points at does not exist.
+	CodeReferenced  Code = "referenced"
+	CodeTooLarge    Code = "payload_too_large"
+	CodeRateLimited Code = "rate_limited"
+	CodeUpstream    Code = "upstream_error"
+	CodeUnavailable Code = "unavailable"
+	CodeInternal    Code = "internal_error"
+)
+
+// codes is the code of a status when the caller does not give one.
+var codes = map[int]Code{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeTooLarge,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusBadGateway:            CodeUpstream,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// Problem is the body of every error response.
+type Problem struct {
+	Type     string `json:"type" example:"urn:carzone:problem:not_found"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"car not found"`
+	Instance string `json:"instance,omitempty" example:"/api/cms/cars/42"`
+	Code     Code   `json:"code" example:"not_found"`
+	// RequestID is the X-Request-ID of the request, the audit log and the server logs carry it too.
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of a validation_failed problem.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Data is the partial result of an operation that failed halfway, such as a batch job.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// FieldError is one invalid field of the request.
+type FieldError struct {
+	// Field is the JSON name of the field, nested fields are joined with dots.
+	Field string `json:"field" example:"email"`
+	// Rule is the validation tag that failed, such as required or email.
+	Rule    string `json:"rule" example:"email"`
+	Message string `json:"message" example:"email must be a valid email address"`
+}
+
+// New builds the problem of a status, with the default code of the status when code is empty.
+func New(status int, code Code, detail string) *Problem {
+	if code == "" {
+		code = codes[status]
+		if code == "" {
+			code = CodeInternal
+		}
+	}
+	return &Problem{
+		Type:   typePrefix + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Error makes a Problem usable as an error, for functions that decide the response of their
+// caller.
+func (p *Problem) Error() string {
+	return string(p.Code) + ": " + p.Detail
+}
+
+// Write sends p, filling in the request ID and path, and aborts the handler chain.
+func Write(c *gin.Context, p *Problem) {
+	p.Instance = c.Request.URL.Path
+	p.RequestID = c.GetString("request_id")
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// Abort sends a problem with the default code of status.
+func Abort(c *gin.Context, status int, detail string) {
+	Write(c, New(status, "", detail))
+}
+
+// AbortWithCode sends a problem with a specific code, for errors clients need to tell apart.
+func AbortWithCode(c *gin.Context, status int, code Code, detail string) {
+	Write(c, New(status, code, detail))
+}
+
+// Invalid sends a 400 for request input that could not be read or validated. Validation errors
+// list their fields in the language of the Accept-Language header, other errors, such as
+// malformed JSON, keep their message.
+func Invalid(c *gin.Context, err error) {
+	if p := fromInput(c, err); p != nil {
+		Write(c, p)
+		return
+	}
+	Abort(c, http.StatusBadRequest, err.Error())
+}
+
+// Error sends the problem matching err: the problem itself, invalid input, a missing row or a
+// constraint violation. Other errors are server errors, they are recorded on the context for
+// the logs and their message is not sent.
+func Error(c *gin.Context, err error) {
+	Write(c, From(c, err))
+}
+
+// From maps err like Error without sending it.
+func From(c *gin.Context, err error) *Problem {
+	var p *Problem
+	switch {
+	case errors.As(err, &p):
+		clone := *p
+		return &clone
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return New(http.StatusNotFound, CodeNotFound, "record not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return New(http.StatusConflict, CodeDuplicate, "a record with the same value already exists")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return New(http.StatusConflict, CodeReferenced, "the record is referenced by, or references, another record")
+	}
+	if p := fromInput(c, err); p != nil {
+		return p
+	}
+	_ = c.Error(err)
+	return New(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+}
+
+// fromInput maps the errors of binding and validating a request, nil for other errors.
+// Validation messages are translated, the Content-Language header tells in which language.
+func fromInput(c *gin.Context, err error) *Problem {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	var timeErr *time.ParseError
+	lang := i18n.Language(c.GetHeader("Accept-Language"))
+	switch {
+	case errors.As(err, &validationErrs):
+		c.Header("Content-Language", lang)
+		p := New(http.StatusBadRequest, CodeValidationFailed, i18n.T(lang, "invalid_fields"))
+		for _, fieldErr := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fieldName(fieldErr),
+				Rule:    fieldErr.Tag(),
+				Message: i18n.FieldMessage(lang, fieldErr),
+			})
+		}
+		return p
+	case errors.As(err, &typeErr):
+		c.Header("Content-Language", lang)
+		p := New(http.StatusBadRequest, CodeValidationFailed, i18n.T(lang, "invalid_fields"))
+		p.Errors = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: i18n.T(lang, "invalid_type", typeErr.Field, typeErr.Type.String()),
+		}}
+		return p
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return New(http.StatusBadRequest, CodeInvalidRequest, "the request body is not valid JSON")
+	case errors.Is(err, io.EOF):
+		return New(http.StatusBadRequest, CodeInvalidRequest, "the request body is empty")
+	case errors.As(err, &numErr), errors.As(err, &timeErr):
+		return New(http.StatusBadRequest, CodeInvalidRequest, err.Error())
+	}
+	return nil
+}
+
+// fieldName is the path of a field without the name of the validated struct, e.g.
+// "delivery_address.city" for "UserAddressRequest.delivery_address.city".
+func fieldName(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return err.Field()
+}
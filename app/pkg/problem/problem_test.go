@@ -0,0 +1,109 @@
+package problem
+
+import (
+	"be-car-zone/app/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func respond(t *testing.T, write func(c *gin.Context), headers ...string) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/cms/cars", nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		c.Request.Header.Set(headers[i], headers[i+1])
+	}
+	c.Set("request_id", "req-1")
+	write(c)
+
+	var p Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &p); err != nil {
+		t.Fatalf("body %q: %v", recorder.Body, err)
+	}
+	return recorder, p
+}
+
+func TestErrorMapsDatabaseErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   Code
+	}{
+		{gorm.ErrRecordNotFound, http.StatusNotFound, CodeNotFound},
+		{fmt.Errorf("create user: %w", gorm.ErrDuplicatedKey), http.StatusConflict, CodeDuplicate},
+		{gorm.ErrForeignKeyViolated, http.StatusConflict, CodeReferenced},
+		{New(http.StatusConflict, "car_sold", "the car is already sold"), http.StatusConflict, "car_sold"},
+		{errors.New("dial tcp 10.0.0.1:3306: connection refused"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tc := range cases {
+		recorder, p := respond(t, func(c *gin.Context) { Error(c, tc.err) })
+		if recorder.Code != tc.status || p.Status != tc.status || p.Code != tc.code {
+			t.Errorf("%v: got %d %q, want %d %q", tc.err, recorder.Code, p.Code, tc.status, tc.code)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != ContentType {
+			t.Errorf("%v: content type %q", tc.err, got)
+		}
+		if p.Type != typePrefix+string(tc.code) || p.Instance != "/api/cms/cars" || p.RequestID != "req-1" {
+			t.Errorf("%v: incomplete problem %+v", tc.err, p)
+		}
+	}
+}
+
+func TestErrorHidesServerErrors(t *testing.T) {
+	_, p := respond(t, func(c *gin.Context) { Error(c, errors.New("Error 1146: Table 'carzone.cars' doesn't exist")) })
+	if p.Detail != "an unexpected error occurred" {
+		t.Fatalf("the database error leaked: %q", p.Detail)
+	}
+}
+
+func TestInvalidListsFieldsInTheLanguageOfTheClient(t *testing.T) {
+	type address struct {
+		City  string `json:"city" validate:"required"`
+		Phone string `json:"phone_number" validate:"idphone"`
+	}
+	type request struct {
+		Email   string  `json:"email" validate:"required,email"`
+		Address address `json:"address"`
+	}
+	err := utils.NewValidator().Struct(request{Email: "budi", Address: address{Phone: "12345"}})
+
+	cases := map[string][]FieldError{
+		"": {
+			{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			{Field: "address.city", Rule: "required", Message: "city is a required field"},
+			{Field: "address.phone_number", Rule: "idphone", Message: "phone_number must be an Indonesian phone number, such as 081234567890"},
+		},
+		"id-ID,id;q=0.9,en;q=0.8": {
+			{Field: "email", Rule: "email", Message: "email harus berupa alamat email yang valid"},
+			{Field: "address.city", Rule: "required", Message: "city wajib diisi"},
+			{Field: "address.phone_number", Rule: "idphone", Message: "phone_number harus berupa nomor telepon Indonesia, contohnya 081234567890"},
+		},
+	}
+	for acceptLanguage, want := range cases {
+		recorder, p := respond(t, func(c *gin.Context) { Invalid(c, err) }, "Accept-Language", acceptLanguage)
+		if recorder.Code != http.StatusBadRequest || p.Code != CodeValidationFailed {
+			t.Fatalf("%q: got %d %q", acceptLanguage, recorder.Code, p.Code)
+		}
+		if fmt.Sprint(p.Errors) != fmt.Sprint(want) {
+			t.Errorf("%q: errors = %+v, want %+v", acceptLanguage, p.Errors, want)
+		}
+	}
+}
+
+func TestInvalidMalformedJSON(t *testing.T) {
+	var target map[string]interface{}
+	err := json.Unmarshal([]byte(`{"name":`), &target)
+	_, p := respond(t, func(c *gin.Context) { Invalid(c, err) })
+	if p.Code != CodeInvalidRequest || p.Detail != "the request body is not valid JSON" {
+		t.Fatalf("got %+v", p)
+	}
+}
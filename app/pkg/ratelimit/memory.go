@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// full reports whether the bucket has refilled to its burst by now, dropping it then loses nothing.
+// A bucket that never refills is never full.
+func (b *bucket) full(now time.Time) bool {
+	if b.limit.Rate <= 0 {
+		return false
+	}
+	return now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst)-b.tokens
+}
+
+// MemoryStore keeps buckets in process memory. State is lost on restart and is not
+// shared between instances, so use SQLStore when running more than one replica.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{}
+		s.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, b.last, now, limit)
+	b.tokens = tokens
+	b.last = now
+	b.limit = limit
+
+	s.calls++
+	if s.calls%1000 == 0 {
+		s.sweep(now)
+	}
+
+	return result, nil
+}
+
+// sweep drops the buckets that have refilled, a new bucket starts full so no budget is reset early.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.full(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit describes a token bucket: Burst tokens at most, refilled at Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. Implementations must be safe for concurrent use.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Every returns a limit allowing n requests per period with a burst of n.
+func Every(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// periods are the units of ParseLimit, units lists their short names from the shortest period.
+var (
+	periods = map[string]time.Duration{
+		"s": time.Second, "sec": time.Second, "second": time.Second,
+		"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+		"h": time.Hour, "hour": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour,
+	}
+	units = []string{"s", "m", "h", "d"}
+)
+
+// ParseLimit parses limits like "10/s", "60/m", "1000/h" with an optional burst suffix ("60/m:20").
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	spec, burstPart, hasBurst := strings.Cut(value, ":")
+
+	countPart, unitPart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(countPart))
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	period, ok := periods[strings.TrimSpace(unitPart)]
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q", value)
+	}
+
+	limit := Every(count, period)
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstPart))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit burst in %q", value)
+		}
+		limit.Burst = burst
+	}
+
+	return limit, nil
+}
+
+// UnmarshalText parses the limit with ParseLimit, for the typed configuration.
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+// String formats the limit the way ParseLimit reads it, in the unit whose count is the burst
+// when there is one.
+func (l Limit) String() string {
+	spec := ""
+	for _, unit := range units {
+		count := l.Rate * periods[unit].Seconds()
+		rounded := math.Round(count)
+		if rounded < 1 || math.Abs(count-rounded) > 1e-9 {
+			continue
+		}
+		if int(rounded) == l.Burst {
+			return fmt.Sprintf("%d/%s", l.Burst, unit)
+		}
+		if spec == "" {
+			spec = fmt.Sprintf("%d/%s", int(rounded), unit)
+		}
+	}
+	if spec == "" {
+		return fmt.Sprintf("%g/s:%d", l.Rate, l.Burst)
+	}
+	return fmt.Sprintf("%s:%d", spec, l.Burst)
+}
+
+// take refills a bucket holding tokens since last and tries to consume one token.
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	if last.IsZero() {
+		tokens = burst
+	} else if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*limit.Rate)
+	}
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else if limit.Rate > 0 {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	if limit.Rate > 0 {
+		result.ResetAfter = seconds((burst - tokens) / limit.Rate)
+	}
+
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
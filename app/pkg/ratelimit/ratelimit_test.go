@@ -0,0 +1,220 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"be-car-zone/app/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// clock is a fake time source the tests advance by hand.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func memoryStore(clock *clock) Store {
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store
+}
+
+func sqlStore(t *testing.T, clock *clock) Store {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=busy_timeout(5000)", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite has no row locks, one connection serialises the transactions like FOR UPDATE does
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.RateLimitBucket{}); err != nil {
+		t.Fatal(err)
+	}
+	store := NewSQLStore(db)
+	store.now = clock.Now
+	return store
+}
+
+var stores = map[string]func(t *testing.T, clock *clock) Store{
+	"memory": func(t *testing.T, clock *clock) Store { return memoryStore(clock) },
+	"sql":    sqlStore,
+}
+
+func take1(t *testing.T, store Store, key string, limit Limit) Result {
+	t.Helper()
+	result, err := store.Take(context.Background(), key, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStoreRefills(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 3}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			clock := newClock()
+			store := newStore(t, clock)
+
+			for i := 2; i >= 0; i-- {
+				result := take1(t, store, "ip:1", limit)
+				if !result.Allowed || result.Remaining != i || result.Limit != 3 {
+					t.Fatalf("take %d: %+v", 3-i, result)
+				}
+			}
+			result := take1(t, store, "ip:1", limit)
+			if result.Allowed || result.RetryAfter != time.Second || result.ResetAfter != 3*time.Second {
+				t.Fatalf("an empty bucket: %+v", result)
+			}
+			if other := take1(t, store, "ip:2", limit); !other.Allowed {
+				t.Fatal("keys must not share a bucket")
+			}
+
+			clock.Advance(time.Second)
+			if result := take1(t, store, "ip:1", limit); !result.Allowed || result.Remaining != 0 {
+				t.Fatalf("after a second one token is back: %+v", result)
+			}
+
+			// Refilling stops at the burst
+			clock.Advance(time.Hour)
+			if result := take1(t, store, "ip:1", limit); !result.Allowed || result.Remaining != 2 {
+				t.Fatalf("after an hour the bucket is full: %+v", result)
+			}
+		})
+	}
+}
+
+func TestStoreConcurrentTakes(t *testing.T) {
+	limit := Limit{Rate: 1.0 / 3600, Burst: 20}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t, newClock())
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			allowed := 0
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, err := store.Take(context.Background(), "ip:1", limit)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if result.Allowed {
+						mu.Lock()
+						allowed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if allowed != limit.Burst {
+				t.Fatalf("%d of 50 concurrent requests allowed, want %d", allowed, limit.Burst)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	clock := newClock()
+	store := NewMemoryStore()
+	store.now = clock.Now
+
+	take1(t, store, "ip:idle", Every(10, time.Minute))
+	clock.Advance(2 * time.Hour)
+	for i := 0; i < 999; i++ {
+		take1(t, store, "ip:busy", Every(10, time.Minute))
+	}
+	if _, ok := store.buckets["ip:idle"]; ok {
+		t.Fatal("a bucket idle for two hours should be dropped")
+	}
+	if _, ok := store.buckets["ip:busy"]; !ok {
+		t.Fatal("a bucket in use was dropped")
+	}
+}
+
+func TestMemoryStoreKeepsBucketsUntilRefilled(t *testing.T) {
+	clock := newClock()
+	store := NewMemoryStore()
+	store.now = clock.Now
+	daily := Every(10, 24*time.Hour)
+
+	for i := 0; i < 10; i++ {
+		take1(t, store, "ip:register", daily)
+	}
+	sweep := func() {
+		for i := 0; i < 1000; i++ {
+			take1(t, store, "ip:busy", Every(10, time.Minute))
+		}
+	}
+
+	// Dropping the empty bucket after an hour would hand out a fresh budget of 10
+	clock.Advance(2 * time.Hour)
+	sweep()
+	if _, ok := store.buckets["ip:register"]; !ok {
+		t.Fatal("a bucket still refilling was dropped")
+	}
+	if result := take1(t, store, "ip:register", daily); result.Allowed {
+		t.Fatalf("the daily budget was reset after two hours: %+v", result)
+	}
+
+	clock.Advance(24 * time.Hour)
+	sweep()
+	if _, ok := store.buckets["ip:register"]; ok {
+		t.Fatal("a refilled bucket should be dropped")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	for value, want := range map[string]Limit{
+		"10/s":     {Rate: 10, Burst: 10},
+		"60/m":     {Rate: 1, Burst: 60},
+		" 30/m:5 ": {Rate: 0.5, Burst: 5},
+		"24/day":   {Rate: 24.0 / 86400, Burst: 24},
+	} {
+		limit, err := ParseLimit(value)
+		if err != nil || limit != want {
+			t.Errorf("ParseLimit(%q) = %+v, %v, want %+v", value, limit, err, want)
+		}
+	}
+	for _, value := range []string{"", "10", "0/m", "-1/m", "10/week", "10/m:0", "10/m:x"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("ParseLimit(%q) should fail", value)
+		}
+	}
+
+	for _, value := range []string{"600/m", "30/m:5", "1000/h", "24/d"} {
+		limit, _ := ParseLimit(value)
+		if limit.String() != value {
+			t.Errorf("String() of %q = %q", value, limit.String())
+		}
+	}
+}
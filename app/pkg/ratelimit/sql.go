@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"be-car-zone/app/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQLStore keeps buckets in the rate_limit_buckets table so limits are shared
+// between every instance talking to the same database.
+type SQLStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{DB: db, now: time.Now}
+}
+
+func (s *SQLStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var result Result
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+
+		var row models.RateLimitBucket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key = ?", key).
+			First(&row).Error
+
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			tokens, res := take(0, time.Time{}, now, limit)
+			result = res
+			return tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "bucket_key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"tokens", "refilled_at"}),
+			}).Create(&models.RateLimitBucket{BucketKey: key, Tokens: tokens, RefilledAt: now}).Error
+		case err != nil:
+			return err
+		}
+
+		tokens, res := take(row.Tokens, row.RefilledAt, now, limit)
+		result = res
+		return tx.Model(&models.RateLimitBucket{}).
+			Where("bucket_key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "refilled_at": now}).Error
+	})
+
+	return result, err
+}
+
+// Stores for RATE_LIMIT_STORE.
+const (
+	StoreMemory = "memory"
+	StoreSQL    = "sql"
+)
+
+// NewStore returns the store selected by name, StoreMemory or StoreSQL.
+func NewStore(name string, db *gorm.DB) Store {
+	if name == StoreSQL && db != nil {
+		return NewSQLStore(db)
+	}
+	return NewMemoryStore()
+}
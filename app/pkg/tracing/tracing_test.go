@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// record installs a provider keeping the spans in memory, with the propagator of Setup.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	if _, err := Setup(context.Background(), Options{Exporter: ExporterNone}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestPluginRecordsQueriesOfATrace(t *testing.T) {
+	recorder := record(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	type car struct {
+		ID   uint
+		Name string
+	}
+	// Outside of a trace
+	if err := db.AutoMigrate(&car{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := Tracer().Start(context.Background(), "GET /api/cms/cars/:id")
+	traced := db.WithContext(ctx)
+	traced.Create(&car{Name: "Avanza"})
+	var found car
+	traced.First(&found, 99)
+	traced.Exec("SELECT * FROM missing_table")
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("%d spans, want 3 queries and their parent", len(spans))
+	}
+	for i, want := range []string{"INSERT cars", "SELECT cars", "SELECT"} {
+		span := spans[i]
+		if span.Name() != want {
+			t.Errorf("span %d is %q, want %q", i, span.Name(), want)
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the request", span.Name())
+		}
+		if attr(span, "db.system") != "sqlite" {
+			t.Errorf("%s: db.system = %q", span.Name(), attr(span, "db.system"))
+		}
+	}
+	if query := attr(spans[1], "db.query.text"); query != "SELECT * FROM `cars` WHERE `cars`.`id` = ? ORDER BY `cars`.`id` LIMIT 1" {
+		t.Errorf("query %q", query)
+	}
+	// A missing row is no error, a missing table is
+	if spans[1].Status().Code == codes.Error || spans[2].Status().Code != codes.Error {
+		t.Errorf("statuses %v and %v", spans[1].Status(), spans[2].Status())
+	}
+}
+
+func TestNewClientPropagatesTheTrace(t *testing.T) {
+	recorder := record(t)
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "GET /api/auth/oidc/:provider/callback")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/token", nil)
+	res, err := NewClient(time.Second).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("the call is not a span of the request: %v", spans)
+	}
+	if want := "00-" + parent.SpanContext().TraceID().String() + "-" + spans[0].SpanContext().SpanID().String() + "-01"; traceparent != want {
+		t.Errorf("traceparent %q, want %q", traceparent, want)
+	}
+}
+
+func TestSetupRejectsUnknownExporters(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("exporter zipkin accepted")
+	}
+}
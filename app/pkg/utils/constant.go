@@ -10,4 +10,20 @@ const (
 	RoleUser    = "user"
 	IDRoleAdmin = 10101
 	IDRoleUser  = 20202
+
+	ScopeCarsRead   = "cars:read"
+	ScopeLeadsWrite = "leads:write"
+
+	KYCTypeKTP  = "ktp"
+	KYCTypeNPWP = "npwp"
+	KYCTypeSIM  = "sim"
+
+	KYCStatusPending  = "pending"
+	KYCStatusApproved = "approved"
+	KYCStatusRejected = "rejected"
+
+	ErasureStatusPending   = "pending"
+	ErasureStatusCompleted = "completed"
+	ErasureStatusRejected  = "rejected"
+	ErasureStatusCancelled = "cancelled"
 )
@@ -1,6 +1,9 @@
 package utils
 
-import "os"
+import (
+	"os"
+	"strings"
+)
 
 func Getenv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -8,3 +11,67 @@ func Getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// MaskPhone keeps the first four and last three digits of a phone number, e.g. 0812*****890.
+func MaskPhone(phone string) string {
+	return maskMiddle(phone, 4, 3)
+}
+
+// MaskAccountNumber keeps only the last four digits of a bank account number.
+func MaskAccountNumber(number string) string {
+	return maskMiddle(number, 0, 4)
+}
+
+// MaskAddress keeps the first word of an address, enough to recognise it without revealing it.
+func MaskAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return ""
+	}
+	first := strings.Fields(address)[0]
+	return first + " ***"
+}
+
+func maskMiddle(value string, keepStart, keepEnd int) string {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return ""
+	}
+	if len(runes) <= keepStart+keepEnd {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keepStart]) + strings.Repeat("*", len(runes)-keepStart-keepEnd) + string(runes[len(runes)-keepEnd:])
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g. b***@example.com.
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskMiddle(email, 0, 0)
+	}
+	return string([]rune(local)[:1]) + "***@" + domain
+}
+
+// MaskName keeps the first letter of each word of a name, e.g. B*** S*****.
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = maskMiddle(word, 1, 0)
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizePhone reduces an Indonesian phone number to its digits in local form, so
+// "+62 812-3456-7890" and "081234567890" compare equal.
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	return digits
+}
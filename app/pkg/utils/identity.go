@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DigitsOnly strips the dots, dashes and spaces people use when writing identity numbers.
+func DigitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if r == '.' || r == '-' || r == ' ' {
+			return -1
+		}
+		// Anything else makes the number invalid
+		return 'x'
+	}, value)
+}
+
+// ValidNIK checks a 16 digit Nomor Induk Kependudukan from a KTP: province code, birth date
+// (day + 40 for women) and a non zero serial.
+func ValidNIK(nik string) bool {
+	nik = DigitsOnly(nik)
+	if len(nik) != 16 || !isDigits(nik) {
+		return false
+	}
+
+	province, _ := strconv.Atoi(nik[0:2])
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	if day > 40 {
+		day -= 40
+	}
+
+	return province >= 11 && province <= 94 &&
+		day >= 1 && day <= 31 &&
+		month >= 1 && month <= 12 &&
+		nik[12:] != "0000"
+}
+
+// ValidNPWP checks a tax number. The 15 digit format carries a Luhn check digit in the ninth
+// position, since 2024 individuals may also use their 16 digit NIK as NPWP.
+func ValidNPWP(npwp string) bool {
+	npwp = DigitsOnly(npwp)
+	switch len(npwp) {
+	case 15:
+		return isDigits(npwp) && luhnValid(npwp[:9])
+	case 16:
+		return ValidNIK(npwp)
+	}
+	return false
+}
+
+// ValidSIM checks the length of a driver license number, 12 digits on older cards and 14 on newer ones.
+func ValidSIM(sim string) bool {
+	sim = DigitsOnly(sim)
+	return (len(sim) == 12 || len(sim) == 14) && isDigits(sim)
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,52 @@
+package utils
+
+import "testing"
+
+func TestValidNIK(t *testing.T) {
+	cases := map[string]bool{
+		"3171011708450001":    true,  // Jakarta, 17-08-1945
+		"3171015708450001":    true,  // women add 40 to the birth day
+		"31.7101.170845.0001": true,  // punctuation is ignored
+		"317101170845000":     false, // 15 digits
+		"0171011708450001":    false, // unknown province
+		"3171013208450001":    false, // day 32
+		"3171011713450001":    false, // month 13
+		"3171011708450000":    false, // zero serial
+		"31710117084500a1":    false,
+	}
+	for nik, want := range cases {
+		if got := ValidNIK(nik); got != want {
+			t.Errorf("ValidNIK(%q) = %v, want %v", nik, got, want)
+		}
+	}
+}
+
+func TestValidNPWP(t *testing.T) {
+	cases := map[string]bool{
+		"09.254.294.3-407.000": true,
+		"092542943407000":      true,
+		"09.254.294.4-407.000": false, // wrong check digit
+		"09.254.294.3-407.00":  false,
+		"3171011708450001":     true, // NIK used as NPWP
+		"3171011708450000":     false,
+	}
+	for npwp, want := range cases {
+		if got := ValidNPWP(npwp); got != want {
+			t.Errorf("ValidNPWP(%q) = %v, want %v", npwp, got, want)
+		}
+	}
+}
+
+func TestValidSIM(t *testing.T) {
+	cases := map[string]bool{
+		"123456789012":   true,
+		"12345678901234": true,
+		"1234567890123":  false,
+		"12345678901a":   false,
+	}
+	for sim, want := range cases {
+		if got := ValidSIM(sim); got != want {
+			t.Errorf("ValidSIM(%q) = %v, want %v", sim, got, want)
+		}
+	}
+}
@@ -1,15 +1,108 @@
 package utils
 
 import (
+	"be-car-zone/app/pkg/i18n"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
 	"github.com/go-playground/validator/v10"
 )
 
-// NewValidator creates a new validator instance
+var (
+	sharedValidator     *validator.Validate
+	sharedValidatorOnce sync.Once
+)
+
+// NewValidator returns the validator of the request bodies. routes.SetupRouter makes it the
+// validator of gin, ShouldBindJSON validates with it and the handlers do not validate again.
+// It is built once and safe for concurrent use.
 func NewValidator() *validator.Validate {
-	return validator.New()
+	sharedValidatorOnce.Do(func() {
+		sharedValidator = validator.New()
+		configureValidator(sharedValidator)
+	})
+	return sharedValidator
+}
+
+// configureValidator names fields by their JSON name, adds the Indonesian rules below and the
+// English and Indonesian messages.
+//
+//	idphone  mobile or landline number, e.g. 081234567890, +62 812-3456-7890 or (022) 4231234
+//	nik      16 digit NIK of a KTP, see ValidNIK
+//	platno   vehicle plate number, e.g. B 1234 ABC or D 123 XY
+//	idr      whole, non negative rupiah amount, rupiah has no cents in practice
+func configureValidator(v *validator.Validate) {
+	useJSONFieldNames(v)
+	// The rules and messages are fixed, registering them only fails on a programming error
+	rules := map[string]validator.Func{
+		"idphone": func(fl validator.FieldLevel) bool { return ValidPhone(fl.Field().String()) },
+		"nik":     func(fl validator.FieldLevel) bool { return ValidNIK(fl.Field().String()) },
+		"platno":  func(fl validator.FieldLevel) bool { return ValidPlateNumber(fl.Field().String()) },
+		"idr":     validRupiah,
+	}
+	for tag, rule := range rules {
+		if err := v.RegisterValidation(tag, rule); err != nil {
+			panic(err)
+		}
+	}
+	if err := i18n.RegisterValidator(v); err != nil {
+		panic(err)
+	}
+}
+
+// useJSONFieldNames makes v report fields by their JSON name, the one clients send, instead of
+// the Go field name.
+func useJSONFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+
+// ValidPhone checks an Indonesian phone number: a trunk prefix 0 or country code 62, then 8 to
+// 12 digits not starting with 0.
+func ValidPhone(phone string) bool {
+	if !phonePattern.MatchString(phone) {
+		return false
+	}
+	digits := NormalizePhone(phone)
+	return len(digits) >= 9 && len(digits) <= 13 && digits[0] == '0' && digits[1] != '0'
+}
+
+var platePattern = regexp.MustCompile(`^[A-Z]{1,2} ?[0-9]{1,4}( ?[A-Z]{1,3})?$`)
+
+// ValidPlateNumber checks a plate number: a one or two letter region code, up to four digits and
+// up to three letters, e.g. B 1234 ABC. Case and spacing do not matter.
+func ValidPlateNumber(plate string) bool {
+	plate = strings.Join(strings.Fields(strings.ToUpper(plate)), " ")
+	return platePattern.MatchString(plate)
 }
 
-// ValidateStruct validates a struct using the provided validator instance
-func ValidateStruct(v *validator.Validate, s interface{}) error {
-	return v.Struct(s)
+func validRupiah(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int() >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Float32, reflect.Float64:
+		amount := field.Float()
+		return amount >= 0 && amount == math.Trunc(amount) && amount <= 1e15
+	case reflect.String:
+		_, err := strconv.ParseUint(field.String(), 10, 64)
+		return err == nil
+	}
+	return false
 }
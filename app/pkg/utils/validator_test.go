@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestValidPhone(t *testing.T) {
+	cases := map[string]bool{
+		"081234567890":      true,
+		"+62 812-3456-7890": true,
+		"(022) 4231234":     true,  // Bandung landline
+		"6281234567890":     true,  // country code without +
+		"12345":             false, // no trunk prefix
+		"0012345678":        false,
+		"08123456789012345": false, // too long
+		"0812-3456-78ab":    false,
+	}
+	for phone, want := range cases {
+		if got := ValidPhone(phone); got != want {
+			t.Errorf("ValidPhone(%q) = %v, want %v", phone, got, want)
+		}
+	}
+}
+
+func TestValidPlateNumber(t *testing.T) {
+	cases := map[string]bool{
+		"B 1234 ABC":  true,
+		"d 123 xy":    true,
+		"AB1234CD":    true,
+		"L 1":         true,
+		"B 12345 A":   false, // five digits
+		"1234 ABC":    false, // no region code
+		"B 1234 ABCD": false,
+	}
+	for plate, want := range cases {
+		if got := ValidPlateNumber(plate); got != want {
+			t.Errorf("ValidPlateNumber(%q) = %v, want %v", plate, got, want)
+		}
+	}
+}
+
+func TestValidatorRules(t *testing.T) {
+	type request struct {
+		NIK   string  `json:"nik" validate:"omitempty,nik"`
+		Plate string  `json:"plate" validate:"omitempty,platno"`
+		Price float64 `json:"price" validate:"omitempty,idr"`
+		Fee   int     `json:"fee" validate:"idr"`
+	}
+	valid := request{NIK: "3171011708450001", Plate: "B 1234 ABC", Price: 265_000_000, Fee: 0}
+	if err := NewValidator().Struct(valid); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+	for name, invalid := range map[string]request{
+		"nik":   {NIK: "3171011708450000"},
+		"plate": {Plate: "B 12345"},
+		"cents": {Price: 1500.5},
+		"minus": {Fee: -1},
+	} {
+		if err := NewValidator().Struct(invalid); err == nil {
+			t.Errorf("%s: invalid request accepted", name)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Package fake implements the repositories in memory for unit tests of the services. Rows are
+// kept in the maps of Data by ID. Deleted orders move to Data.DeletedOrders so they can be
+// restored, other deleted rows are removed.
+package fake
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/repositories"
+	"context"
+	"sort"
+	"sync"
+)
+
+// Data is the content of a Store, tests fill it directly.
+type Data struct {
+	Orders map[uint]models.Order
+	// DeletedOrders is the trash of Orders.
+	DeletedOrders map[uint]models.Order
+	Transactions  map[uint]models.Transaction
+	Invoices      map[uint]models.Invoice
+	Cars          map[uint]models.Car
+	Users         map[uint]models.User
+	Addresses     map[uint]models.UserAddress
+	// ApprovedKTP holds the users whose KTP was approved.
+	ApprovedKTP map[uint]bool
+}
+
+func (d Data) clone() Data {
+	return Data{
+		Orders:        cloneMap(d.Orders),
+		DeletedOrders: cloneMap(d.DeletedOrders),
+		Transactions:  cloneMap(d.Transactions),
+		Invoices:      cloneMap(d.Invoices),
+		Cars:          cloneMap(d.Cars),
+		Users:         cloneMap(d.Users),
+		Addresses:     cloneMap(d.Addresses),
+		ApprovedKTP:   cloneMap(d.ApprovedKTP),
+	}
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	clone := make(map[K]V, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+	return clone
+}
+
+// Store is an in-memory repositories.Store. Atomic runs one function at a time and restores the
+// data when it fails.
+type Store struct {
+	Data Data
+	// Fail, when set, is asked before every write and its error is returned instead, to test
+	// rollbacks. The operation is named like "orders.create" or "cars.set_sold".
+	Fail func(operation string) error
+
+	atomic sync.Mutex
+	mu     sync.Mutex
+	nextID uint
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{Data: Data{}.clone(), nextID: 1000}
+}
+
+func (s *Store) Orders() repositories.OrderRepository             { return orders{s} }
+func (s *Store) Transactions() repositories.TransactionRepository { return transactions{s} }
+func (s *Store) Invoices() repositories.InvoiceRepository         { return invoices{s} }
+func (s *Store) Cars() repositories.CarRepository                 { return cars{s} }
+func (s *Store) Addresses() repositories.AddressRepository        { return addresses{s} }
+func (s *Store) KYC() repositories.KYCRepository                  { return kyc{s} }
+
+func (s *Store) Atomic(ctx context.Context, fn func(store repositories.Store) error) error {
+	s.atomic.Lock()
+	defer s.atomic.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.Data.clone()
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.Data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write locks the store for a write and assigns an ID to new rows.
+func (s *Store) write(operation string, id *uint) (func(), error) {
+	if s.Fail != nil {
+		if err := s.Fail(operation); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.Lock()
+	if id != nil && *id == 0 {
+		s.nextID++
+		*id = s.nextID
+	}
+	return s.mu.Unlock, nil
+}
+
+func sorted[T any](rows map[uint]T, keep func(T) bool, newestFirst bool) []T {
+	ids := make([]uint, 0, len(rows))
+	for id, row := range rows {
+		if keep == nil || keep(row) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if newestFirst {
+			return ids[i] > ids[j]
+		}
+		return ids[i] < ids[j]
+	})
+	result := make([]T, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, rows[id])
+	}
+	return result
+}
+
+type orders struct{ s *Store }
+
+// load fills the relations the GORM repository preloads, the caller holds the lock.
+func (r orders) load(order models.Order) models.Order {
+	order.Car = r.s.Data.Cars[order.CarID]
+	order.User = r.s.Data.Users[order.UserID]
+	return order
+}
+
+func (r orders) list(keep func(models.Order) bool) []models.Order {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rows := sorted(r.s.Data.Orders, keep, true)
+	for i := range rows {
+		rows[i] = r.load(rows[i])
+	}
+	return rows
+}
+
+func (r orders) List(ctx context.Context) ([]models.Order, error) {
+	return r.list(nil), nil
+}
+
+func (r orders) ListByDeliveryPhone(ctx context.Context, phone string) ([]models.Order, error) {
+	phone = utils.NormalizePhone(phone)
+	return r.list(func(order models.Order) bool { return utils.NormalizePhone(order.DeliveryAddress.PhoneNumber) == phone }), nil
+}
+
+func (r orders) ListByUser(ctx context.Context, userID uint) ([]models.Order, error) {
+	return r.list(func(order models.Order) bool { return order.UserID == userID }), nil
+}
+
+func (r orders) Get(ctx context.Context, id uint) (models.Order, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	order, ok := r.s.Data.Orders[id]
+	if !ok {
+		return models.Order{}, repositories.ErrNotFound
+	}
+	return r.load(order), nil
+}
+
+func (r orders) Create(ctx context.Context, order *models.Order) error {
+	return r.Save(ctx, order)
+}
+
+func (r orders) Save(ctx context.Context, order *models.Order) error {
+	unlock, err := r.s.write("orders.save", &order.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	row := *order
+	row.Car, row.User = models.Car{}, models.User{}
+	r.s.Data.Orders[order.ID] = row
+	return nil
+}
+
+func (r orders) Delete(ctx context.Context, order *models.Order) error {
+	unlock, err := r.s.write("orders.delete", nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	r.s.Data.DeletedOrders[order.ID] = r.s.Data.Orders[order.ID]
+	delete(r.s.Data.Orders, order.ID)
+	return nil
+}
+
+func (r orders) GetDeleted(ctx context.Context, id uint) (models.Order, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	order, ok := r.s.Data.DeletedOrders[id]
+	if !ok {
+		return models.Order{}, repositories.ErrNotFound
+	}
+	return r.load(order), nil
+}
+
+func (r orders) Restore(ctx context.Context, order *models.Order) error {
+	unlock, err := r.s.write("orders.restore", nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	r.s.Data.Orders[order.ID] = r.s.Data.DeletedOrders[order.ID]
+	delete(r.s.Data.DeletedOrders, order.ID)
+	return nil
+}
+
+func (r orders) HasOpen(ctx context.Context, carID, exceptID uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, order := range r.s.Data.Orders {
+		if order.CarID == carID && !order.Status && order.ID != exceptID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r orders) HasPayments(ctx context.Context, id uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, transaction := range r.s.Data.Transactions {
+		if transaction.OrderID == id {
+			return true, nil
+		}
+	}
+	for _, invoice := range r.s.Data.Invoices {
+		if invoice.OrderID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type transactions struct{ s *Store }
+
+func (r transactions) load(transaction models.Transaction) models.Transaction {
+	transaction.Order = orders(r).load(r.s.Data.Orders[transaction.OrderID])
+	return transaction
+}
+
+func (r transactions) list(keep func(models.Transaction) bool) []models.Transaction {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rows := sorted(r.s.Data.Transactions, keep, true)
+	for i := range rows {
+		rows[i] = r.load(rows[i])
+	}
+	return rows
+}
+
+func (r transactions) List(ctx context.Context) ([]models.Transaction, error) {
+	return r.list(nil), nil
+}
+
+func (r transactions) ListByOrder(ctx context.Context, orderID uint) ([]models.Transaction, error) {
+	return r.list(func(transaction models.Transaction) bool { return transaction.OrderID == orderID }), nil
+}
+
+func (r transactions) Get(ctx context.Context, id uint) (models.Transaction, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	transaction, ok := r.s.Data.Transactions[id]
+	if !ok {
+		return models.Transaction{}, repositories.ErrNotFound
+	}
+	return r.load(transaction), nil
+}
+
+func (r transactions) Create(ctx context.Context, transaction *models.Transaction) error {
+	return r.Save(ctx, transaction)
+}
+
+func (r transactions) Save(ctx context.Context, transaction *models.Transaction) error {
+	unlock, err := r.s.write("transactions.save", &transaction.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	row := *transaction
+	row.Order = models.Order{}
+	r.s.Data.Transactions[transaction.ID] = row
+	return nil
+}
+
+func (r transactions) Delete(ctx context.Context, transaction *models.Transaction) error {
+	unlock, err := r.s.write("transactions.delete", nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(r.s.Data.Transactions, transaction.ID)
+	return nil
+}
+
+func (r transactions) IsInvoiced(ctx context.Context, id uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, invoice := range r.s.Data.Invoices {
+		if invoice.TransactionID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type invoices struct{ s *Store }
+
+func (r invoices) load(invoice models.Invoice) models.Invoice {
+	invoice.Order = orders(r).load(r.s.Data.Orders[invoice.OrderID])
+	invoice.Transaction = r.s.Data.Transactions[invoice.TransactionID]
+	return invoice
+}
+
+func (r invoices) list(keep func(models.Invoice) bool) []models.Invoice {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rows := sorted(r.s.Data.Invoices, keep, true)
+	for i := range rows {
+		rows[i] = r.load(rows[i])
+	}
+	return rows
+}
+
+func (r invoices) List(ctx context.Context) ([]models.Invoice, error) {
+	return r.list(nil), nil
+}
+
+func (r invoices) ListByOrder(ctx context.Context, orderID uint) ([]models.Invoice, error) {
+	return r.list(func(invoice models.Invoice) bool { return invoice.OrderID == orderID }), nil
+}
+
+func (r invoices) Get(ctx context.Context, id uint) (models.Invoice, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	invoice, ok := r.s.Data.Invoices[id]
+	if !ok {
+		return models.Invoice{}, repositories.ErrNotFound
+	}
+	return r.load(invoice), nil
+}
+
+func (r invoices) Create(ctx context.Context, invoice *models.Invoice) error {
+	return r.Save(ctx, invoice)
+}
+
+func (r invoices) Save(ctx context.Context, invoice *models.Invoice) error {
+	unlock, err := r.s.write("invoices.save", &invoice.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	row := *invoice
+	row.Order, row.Transaction = models.Order{}, models.Transaction{}
+	r.s.Data.Invoices[invoice.ID] = row
+	return nil
+}
+
+func (r invoices) Delete(ctx context.Context, invoice *models.Invoice) error {
+	unlock, err := r.s.write("invoices.delete", nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	delete(r.s.Data.Invoices, invoice.ID)
+	return nil
+}
+
+type cars struct{ s *Store }
+
+func (r cars) Lock(ctx context.Context, id uint) (models.Car, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	car, ok := r.s.Data.Cars[id]
+	if !ok {
+		return models.Car{}, repositories.ErrNotFound
+	}
+	return car, nil
+}
+
+func (r cars) SetSold(ctx context.Context, id uint, sold bool) error {
+	unlock, err := r.s.write("cars.set_sold", nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	car := r.s.Data.Cars[id]
+	car.Sold = sold
+	r.s.Data.Cars[id] = car
+	return nil
+}
+
+type addresses struct{ s *Store }
+
+func (r addresses) Get(ctx context.Context, userID, id uint) (models.UserAddress, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	address, ok := r.s.Data.Addresses[id]
+	if !ok || address.UserID != userID {
+		return models.UserAddress{}, repositories.ErrNotFound
+	}
+	return address, nil
+}
+
+func (r addresses) Default(ctx context.Context, userID uint) (models.UserAddress, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, address := range r.s.Data.Addresses {
+		if address.UserID == userID && address.IsDefault {
+			return address, nil
+		}
+	}
+	return models.UserAddress{}, repositories.ErrNotFound
+}
+
+type kyc struct{ s *Store }
+
+func (r kyc) KTPApproved(ctx context.Context, userID uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.s.Data.ApprovedKTP[userID], nil
+}
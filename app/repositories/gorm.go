@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/utils"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore returns the store backed by db.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Orders() OrderRepository             { return gormOrders{s.db} }
+func (s *gormStore) Transactions() TransactionRepository { return gormTransactions{s.db} }
+func (s *gormStore) Invoices() InvoiceRepository         { return gormInvoices{s.db} }
+func (s *gormStore) Cars() CarRepository                 { return gormCars{s.db} }
+func (s *gormStore) Addresses() AddressRepository        { return gormAddresses{s.db} }
+func (s *gormStore) KYC() KYCRepository                  { return gormKYC{s.db} }
+
+func (s *gormStore) Atomic(ctx context.Context, fn func(store Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// notFound translates the GORM error so callers do not depend on GORM.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func exists(db *gorm.DB, model interface{}, column string, id interface{}) (bool, error) {
+	var count int64
+	err := db.Model(model).Where(column+" = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+type gormOrders struct{ db *gorm.DB }
+
+func (r gormOrders) preloaded(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Car").Preload("User.Role")
+}
+
+func (r gormOrders) List(ctx context.Context) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.preloaded(ctx).Order("created_at DESC").Find(&orders).Error
+	return orders, err
+}
+
+// ListByDeliveryPhone matches the blind index, the delivery phone is encrypted.
+func (r gormOrders) ListByDeliveryPhone(ctx context.Context, phone string) ([]models.Order, error) {
+	index, err := models.DeliveryPhoneBlindIndex(phone)
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	err = r.preloaded(ctx).Where("delivery_phone_number_bidx = ?", index).Order("created_at DESC").Find(&orders).Error
+	return orders, err
+}
+
+func (r gormOrders) ListByUser(ctx context.Context, userID uint) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.preloaded(ctx).Where("user_id = ?", userID).Find(&orders).Error
+	return orders, err
+}
+
+func (r gormOrders) Get(ctx context.Context, id uint) (models.Order, error) {
+	var order models.Order
+	err := r.preloaded(ctx).First(&order, id).Error
+	return order, notFound(err)
+}
+
+func (r gormOrders) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(order).Error
+}
+
+func (r gormOrders) Save(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(order).Error
+}
+
+func (r gormOrders) Delete(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Delete(order).Error
+}
+
+func (r gormOrders) GetDeleted(ctx context.Context, id uint) (models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&order, id).Error
+	return order, notFound(err)
+}
+
+func (r gormOrders) Restore(ctx context.Context, order *models.Order) error {
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Order{}).Where("id = ?", order.ID).Update("deleted_at", nil).Error
+	if err == nil {
+		order.DeletedAt = gorm.DeletedAt{}
+	}
+	return err
+}
+
+func (r gormOrders) HasOpen(ctx context.Context, carID, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("car_id = ? AND status = ? AND id <> ?", carID, false, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormOrders) HasPayments(ctx context.Context, id uint) (bool, error) {
+	for _, dependent := range []interface{}{&models.Transaction{}, &models.Invoice{}} {
+		found, err := exists(r.db.WithContext(ctx), dependent, "order_id", id)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+type gormTransactions struct{ db *gorm.DB }
+
+func (r gormTransactions) preloaded(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Order.Car").Preload("Order.User.Role")
+}
+
+func (r gormTransactions) List(ctx context.Context) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.preloaded(ctx).Order("created_at DESC").Find(&transactions).Error
+	return transactions, err
+}
+
+func (r gormTransactions) ListByOrder(ctx context.Context, orderID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.preloaded(ctx).Where("order_id = ?", orderID).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r gormTransactions) Get(ctx context.Context, id uint) (models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.preloaded(ctx).First(&transaction, id).Error
+	return transaction, notFound(err)
+}
+
+func (r gormTransactions) Create(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(transaction).Error
+}
+
+func (r gormTransactions) Save(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(transaction).Error
+}
+
+func (r gormTransactions) Delete(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Delete(transaction).Error
+}
+
+func (r gormTransactions) IsInvoiced(ctx context.Context, id uint) (bool, error) {
+	return exists(r.db.WithContext(ctx), &models.Invoice{}, "transaction_id", id)
+}
+
+type gormInvoices struct{ db *gorm.DB }
+
+func (r gormInvoices) preloaded(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Order.Car").Preload("Order.User.Role").Preload("Transaction")
+}
+
+func (r gormInvoices) List(ctx context.Context) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.preloaded(ctx).Order("created_at DESC").Find(&invoices).Error
+	return invoices, err
+}
+
+func (r gormInvoices) ListByOrder(ctx context.Context, orderID uint) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.preloaded(ctx).Where("order_id = ?", orderID).Find(&invoices).Error
+	return invoices, err
+}
+
+func (r gormInvoices) Get(ctx context.Context, id uint) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.preloaded(ctx).First(&invoice, id).Error
+	return invoice, notFound(err)
+}
+
+func (r gormInvoices) Create(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(invoice).Error
+}
+
+func (r gormInvoices) Save(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(invoice).Error
+}
+
+func (r gormInvoices) Delete(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Delete(invoice).Error
+}
+
+type gormCars struct{ db *gorm.DB }
+
+func (r gormCars) Lock(ctx context.Context, id uint) (models.Car, error) {
+	var car models.Car
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&car, id).Error
+	return car, notFound(err)
+}
+
+func (r gormCars) SetSold(ctx context.Context, id uint, sold bool) error {
+	return r.db.WithContext(ctx).Model(&models.Car{ID: id}).Update("sold", sold).Error
+}
+
+type gormAddresses struct{ db *gorm.DB }
+
+func (r gormAddresses) Get(ctx context.Context, userID, id uint) (models.UserAddress, error) {
+	var address models.UserAddress
+	err := r.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).First(&address).Error
+	return address, notFound(err)
+}
+
+func (r gormAddresses) Default(ctx context.Context, userID uint) (models.UserAddress, error) {
+	var address models.UserAddress
+	err := r.db.WithContext(ctx).Where("user_id = ? AND is_default = ?", userID, true).First(&address).Error
+	return address, notFound(err)
+}
+
+type gormKYC struct{ db *gorm.DB }
+
+func (r gormKYC) KTPApproved(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.KYCDocument{}).
+		Where("user_id = ? AND type = ? AND status = ?", userID, utils.KYCTypeKTP, utils.KYCStatusApproved).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,92 @@
+// Package repositories hides GORM behind the interfaces the services need. Reads return rows with
+// the relations the API renders preloaded. The fake package implements the same interfaces in
+// memory for unit tests.
+package repositories
+
+import (
+	"be-car-zone/app/models"
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when a row does not exist or is in the trash.
+var ErrNotFound = errors.New("record not found")
+
+// Store gives access to every repository. Repositories of the store passed to Atomic share one
+// database transaction.
+type Store interface {
+	Orders() OrderRepository
+	Transactions() TransactionRepository
+	Invoices() InvoiceRepository
+	Cars() CarRepository
+	Addresses() AddressRepository
+	KYC() KYCRepository
+
+	// Atomic runs fn in a transaction, an error rolls back everything fn wrote.
+	Atomic(ctx context.Context, fn func(store Store) error) error
+}
+
+// OrderRepository loads orders with their car and their user and role.
+type OrderRepository interface {
+	// List returns every order, newest first.
+	List(ctx context.Context) ([]models.Order, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.Order, error)
+	// ListByDeliveryPhone returns the orders delivered to a phone number, newest first.
+	ListByDeliveryPhone(ctx context.Context, phone string) ([]models.Order, error)
+	Get(ctx context.Context, id uint) (models.Order, error)
+	Create(ctx context.Context, order *models.Order) error
+	Save(ctx context.Context, order *models.Order) error
+	Delete(ctx context.Context, order *models.Order) error
+	// GetDeleted returns an order in the trash, ErrNotFound when it is live or purged.
+	GetDeleted(ctx context.Context, id uint) (models.Order, error)
+	// Restore takes an order out of the trash.
+	Restore(ctx context.Context, order *models.Order) error
+	// HasOpen reports whether another unpaid order reserves the car, order exceptID is ignored.
+	HasOpen(ctx context.Context, carID, exceptID uint) (bool, error)
+	// HasPayments reports whether transactions or invoices point at the order.
+	HasPayments(ctx context.Context, id uint) (bool, error)
+}
+
+// TransactionRepository loads transactions with their order, its car and its user.
+type TransactionRepository interface {
+	// List returns every transaction, newest first.
+	List(ctx context.Context) ([]models.Transaction, error)
+	ListByOrder(ctx context.Context, orderID uint) ([]models.Transaction, error)
+	Get(ctx context.Context, id uint) (models.Transaction, error)
+	Create(ctx context.Context, transaction *models.Transaction) error
+	Save(ctx context.Context, transaction *models.Transaction) error
+	Delete(ctx context.Context, transaction *models.Transaction) error
+	IsInvoiced(ctx context.Context, id uint) (bool, error)
+}
+
+// InvoiceRepository loads invoices with their order and transaction.
+type InvoiceRepository interface {
+	// List returns every invoice, newest first.
+	List(ctx context.Context) ([]models.Invoice, error)
+	ListByOrder(ctx context.Context, orderID uint) ([]models.Invoice, error)
+	Get(ctx context.Context, id uint) (models.Invoice, error)
+	Create(ctx context.Context, invoice *models.Invoice) error
+	Save(ctx context.Context, invoice *models.Invoice) error
+	Delete(ctx context.Context, invoice *models.Invoice) error
+}
+
+// CarRepository covers what ordering needs from the catalogue.
+type CarRepository interface {
+	// Lock returns the car and, inside Atomic, keeps other transactions from ordering it until
+	// the transaction ends.
+	Lock(ctx context.Context, id uint) (models.Car, error)
+	SetSold(ctx context.Context, id uint, sold bool) error
+}
+
+// AddressRepository reads the address book of a user.
+type AddressRepository interface {
+	Get(ctx context.Context, userID, id uint) (models.UserAddress, error)
+	// Default returns ErrNotFound when the user has no default address.
+	Default(ctx context.Context, userID uint) (models.UserAddress, error)
+}
+
+// KYCRepository reads identity verification results.
+type KYCRepository interface {
+	// KTPApproved reports whether the user's KTP was approved.
+	KTPApproved(ctx context.Context, userID uint) (bool, error)
+}
@@ -0,0 +1,286 @@
+package routes_test
+
+import (
+	"be-car-zone/docs"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// middlewareStatuses are answered by the authentication and rate limit middlewares before any
+// handler runs, operations do not document them one by one.
+var middlewareStatuses = map[int]bool{
+	http.StatusUnauthorized:    true,
+	http.StatusForbidden:       true,
+	http.StatusTooManyRequests: true,
+}
+
+// contract is the Swagger document served at /swagger, the responses of the end to end tests
+// are checked against it so the annotations cannot drift from the handlers.
+type contract struct {
+	Paths       map[string]map[string]*operation `json:"paths"`
+	Definitions map[string]*schema               `json:"definitions"`
+}
+
+type operation struct {
+	Responses map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []interface{}      `json:"enum"`
+}
+
+func loadContract(t *testing.T) *contract {
+	t.Helper()
+	var c contract
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &c); err != nil {
+		t.Fatalf("docs: %v", err)
+	}
+	return &c
+}
+
+// documented reports whether path is part of the documented API. The root, the Swagger UI,
+// uploads and the mock identity provider are not.
+func documented(path string) bool {
+	return strings.HasPrefix(path, "/api/")
+}
+
+// operation finds the documented operation serving a request path. Literal segments win over
+// parameters, like the router does for /cars/sales-data and /cars/{id}.
+func (c *contract) operation(method, path string) (string, *operation) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestLiterals := "", -1
+	for template := range c.Paths {
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		literals := 0
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") {
+				continue
+			}
+			if part != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			best, bestLiterals = template, literals
+		}
+	}
+	if best == "" {
+		return "", nil
+	}
+	return best, c.Paths[best][strings.ToLower(method)]
+}
+
+// check validates a response against the documented operation and returns every mismatch.
+func (c *contract) check(method, path string, status int, contentType string, body []byte) []string {
+	if !documented(path) {
+		return nil
+	}
+	template, op := c.operation(method, path)
+	if op == nil {
+		return []string{fmt.Sprintf("%s %s is not documented", method, path)}
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if middlewareStatuses[status] {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s %s: status %d is not documented", method, template, status)}
+	}
+	if response.Schema == nil || response.Schema.Type == "file" || !strings.Contains(contentType, "json") {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("%s %s: %d body is not JSON: %v", method, template, status, err)}
+	}
+	var problems []string
+	c.validate(response.Schema, value, fmt.Sprintf("%s %s %d: body", method, template, status), &problems)
+	return problems
+}
+
+// validate checks value against s, at names the value in problems. Null is accepted anywhere
+// a value is not required, Go encodes nil slices, maps and pointers as null.
+func (c *contract) validate(s *schema, value interface{}, at string, problems *[]string) {
+	s = c.resolve(s)
+	if value == nil {
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s: %v is not one of %v", at, value, s.Enum))
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: %s, documented as an object", at, kind(value)))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: required field %q is missing", at, name))
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if ok {
+				c.validate(property, object[name], at+"."+name, problems)
+				continue
+			}
+			if extra := s.additional(); extra != nil {
+				c.validate(extra, object[name], at+"."+name, problems)
+			} else if len(s.Properties) > 0 || string(s.AdditionalProperties) == "false" {
+				*problems = append(*problems, fmt.Sprintf("%s: field %q is not documented", at, name))
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: %s, documented as an array", at, kind(value)))
+			return
+		}
+		if s.Items == nil {
+			return
+		}
+		for i, item := range items {
+			c.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i), problems)
+		}
+	case "string", "boolean", "number", "integer":
+		if got := kind(value); got != s.Type && !(s.Type == "number" && got == "integer") {
+			*problems = append(*problems, fmt.Sprintf("%s: %s, documented as %s", at, got, s.Type))
+		}
+	}
+}
+
+// additional returns the schema of undocumented fields of a map, nil when they are not allowed
+// or not constrained.
+func (s *schema) additional() *schema {
+	if len(s.AdditionalProperties) == 0 || s.AdditionalProperties[0] != '{' {
+		return nil
+	}
+	var extra schema
+	if err := json.Unmarshal(s.AdditionalProperties, &extra); err != nil {
+		return nil
+	}
+	return &extra
+}
+
+// resolve follows references and merges the parts of allOf, which swag generates for
+// compositions such as object{data=models.Car}.
+func (c *contract) resolve(s *schema) *schema {
+	for s.Ref != "" {
+		definition, ok := c.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+		if !ok {
+			return &schema{}
+		}
+		s = definition
+	}
+	if len(s.AllOf) == 0 {
+		return s
+	}
+
+	merged := &schema{Type: s.Type, Properties: map[string]*schema{}, Required: s.Required}
+	for _, part := range s.AllOf {
+		part = c.resolve(part)
+		if part.Type != "" {
+			merged.Type = part.Type
+		}
+		for name, property := range part.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+	return merged
+}
+
+func kind(value interface{}) string {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// undocumented returns the API routes of the router without a documented operation and the
+// documented operations the router does not serve.
+func (c *contract) undocumented(routes []string) (missing, unknown []string) {
+	served := map[string]bool{}
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if !documented(path) {
+			continue
+		}
+		// Swagger writes path parameters as {id}, gin as :id
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		key := method + " " + strings.Join(segments, "/")
+		served[key] = true
+		if op := c.Paths[strings.Join(segments, "/")][strings.ToLower(method)]; op == nil {
+			missing = append(missing, key)
+		}
+	}
+
+	for path, operations := range c.Paths {
+		for method := range operations {
+			if key := strings.ToUpper(method) + " " + path; !served[key] {
+				unknown = append(unknown, key)
+			}
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	return missing, unknown
+}
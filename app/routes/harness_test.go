@@ -0,0 +1,544 @@
+package routes_test
+
+import (
+	"be-car-zone/app/config"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/jwt"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/mailer"
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/pkg/ratelimit"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/routes"
+	"be-car-zone/app/seeders"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the current responses")
+
+// now is the clock of the application under test, timestamps it writes are stable across runs.
+var now = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+const (
+	apiSecret     = "e2e-secret"
+	cronSecret    = "e2e-cron"
+	metricsToken  = "e2e-metrics"
+	adminPassword = "admin-secret"
+	userPassword  = "budi-secret"
+)
+
+// scrubbed are the response fields and query parameters holding random or wall clock values,
+// they are replaced by a placeholder before comparing with the golden files.
+var scrubbed = map[string]bool{
+	"token":          true,
+	"key":            true,
+	"prefix":         true,
+	"avatar_url":     true,
+	"state":          true,
+	"nonce":          true,
+	"code_challenge": true,
+	"request_id":     true,
+}
+
+// scrubbedQuery are query parameters with random values whose name is also a stable response
+// field, the code of an authorization response and the code of a problem.
+var scrubbedQuery = map[string]bool{
+	"code": true,
+}
+
+// suite is the application served over HTTP against an in-memory SQLite database seeded with
+// the roles, an admin and the demo catalog.
+type suite struct {
+	server *httptest.Server
+	client *http.Client
+	db     *gorm.DB
+	mails  *mailbox
+	// contract is checked against every response
+	contract *contract
+
+	// admin and user are bearer tokens of the seeded admin and of budi, a registered user
+	admin, user string
+	userID      uint
+
+	routes  gin.RoutesInfo
+	mu      sync.Mutex
+	covered map[string]bool
+}
+
+func newSuite(t *testing.T) *suite {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// The mock identity provider calls back into the same server, its address must be known
+	// before the routes are set up
+	server := httptest.NewUnstartedServer(nil)
+	baseURL := "http://" + server.Listener.Addr().String()
+
+	settings := config.Default()
+	settings.Environment = "development"
+	settings.Auth.APISecret = apiSecret
+	settings.RateLimit.Auth = ratelimit.Every(1000, time.Minute)
+	settings.OIDC.MockEnabled = true
+	settings.OIDC.MockBaseURL = baseURL
+	ring, err := settings.Keyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.SetDefault(ring)
+
+	db := config.OpenDataBase(config.Database{Provider: config.ProviderSQLite, Name: config.InMemory, MaxOpenConns: 4, MaxIdleConns: 2})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	// Timestamps GORM fills in follow the same clock as the handlers, like app.New does
+	db = db.Session(&gorm.Session{NowFunc: func() time.Time { return now }, Logger: logger.Discard})
+
+	migrator, err := config.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seeders.Run(db, seeders.Options{AdminUsername: "admin", AdminEmail: "admin@carzone.test", AdminPassword: adminPassword, Demo: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	jwt.Configure(apiSecret, time.Hour)
+
+	appMetrics := metrics.New()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appMetrics.RegisterDB(sqlDB, config.ProviderSQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &suite{server: server, db: db, mails: &mailbox{}, contract: loadContract(t), covered: map[string]bool{}}
+
+	engine := gin.New()
+	engine.Use(s.cover)
+	routes.SetupRouter(engine, routes.Dependencies{
+		DB:           db,
+		Now:          func() time.Time { return now },
+		Storage:      storage.NewLocalStorage(t.TempDir(), "/uploads"),
+		Mailer:       s.mails,
+		Keyring:      ring,
+		Logger:       logging.Discard(),
+		Metrics:      appMetrics,
+		MetricsToken: metricsToken,
+		App:          settings.App,
+		RateLimit:    settings.RateLimit,
+		OIDC:         settings.OIDC,
+		Trash:        settings.Trash,
+		CronSecret:   cronSecret,
+	})
+	server.Config.Handler = engine
+	server.Start()
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.client = &http.Client{
+		Jar: jar,
+		// Redirects are part of the responses under test
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	s.routes = engine.Routes()
+
+	setup := s.transcript(t, "")
+	s.admin = s.login(setup, "admin", adminPassword)
+	setup.call("POST", "/api/auth/register", "", map[string]string{"username": "budi", "email": "budi@carzone.test", "password": userPassword}).expect(http.StatusOK)
+	s.user = s.login(setup, "budi", userPassword)
+	var me struct {
+		Data struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	setup.call("GET", "/api/auth/me", s.user, nil).expect(http.StatusOK).decode(&me)
+	s.userID = me.Data.ID
+	return s
+}
+
+// cover records the route pattern every request was matched to.
+func (s *suite) cover(c *gin.Context) {
+	c.Next()
+	if c.FullPath() == "" {
+		return
+	}
+	s.mu.Lock()
+	s.covered[c.Request.Method+" "+c.FullPath()] = true
+	s.mu.Unlock()
+}
+
+// uncovered returns the registered routes no request was matched to. Routes registered for
+// every method with Any count as covered once any method was used.
+func (s *suite) uncovered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methods := map[string]int{}
+	hit := map[string]bool{}
+	for _, route := range s.routes {
+		methods[route.Path]++
+		if s.covered[route.Method+" "+route.Path] {
+			hit[route.Path] = true
+		}
+	}
+
+	var missing []string
+	for _, route := range s.routes {
+		everyMethod := methods[route.Path] == len(anyMethods)
+		if s.covered[route.Method+" "+route.Path] || (everyMethod && hit[route.Path]) {
+			continue
+		}
+		missing = append(missing, route.Method+" "+route.Path)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// anyMethods are the methods gin registers for RouterGroup.Any.
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodHead,
+	http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+func (s *suite) login(tr *transcript, username, password string) string {
+	var res struct {
+		Token string `json:"token"`
+	}
+	tr.call("POST", "/api/auth/login", "", map[string]string{"username": username, "password": password}).expect(http.StatusOK).decode(&res)
+	return "Bearer " + res.Token
+}
+
+// mailbox keeps the emails the application sends.
+type mailbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *mailbox) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var tokenParam = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// lastToken returns the token of the last link mailed to to.
+func (m *mailbox) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To != to {
+			continue
+		}
+		if match := tokenParam.FindStringSubmatch(m.messages[i].Body); match != nil {
+			return match[1]
+		}
+	}
+	t.Fatalf("no link was mailed to %s", to)
+	return ""
+}
+
+// transcript records the requests of a test and compares them with testdata/<name>.golden when
+// the test ends. A transcript without a name is not compared.
+type transcript struct {
+	t      *testing.T
+	s      *suite
+	name   string
+	buf    bytes.Buffer
+	hidden []string
+}
+
+func (s *suite) transcript(t *testing.T, name string) *transcript {
+	tr := &transcript{t: t, s: s, name: name}
+	if name != "" {
+		t.Cleanup(tr.compare)
+	}
+	return tr
+}
+
+// hide replaces value with placeholder in the following entries, for random values that end up
+// in paths such as generated file names.
+func (tr *transcript) hide(value, placeholder string) {
+	tr.hidden = append(tr.hidden, value, placeholder)
+}
+
+func (tr *transcript) compare() {
+	path := filepath.Join("testdata", tr.name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			tr.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, tr.buf.Bytes(), 0o644); err != nil {
+			tr.t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tr.t.Fatalf("%v, run go test ./app/routes -update to create it", err)
+	}
+	if got := tr.buf.String(); got != string(want) {
+		tr.t.Errorf("responses differ from %s, run go test ./app/routes -update and review the diff\n%s", path, firstDifference(string(want), got))
+	}
+}
+
+// firstDifference shows the first line that differs with a little context.
+func firstDifference(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, w, g)
+		}
+	}
+	return ""
+}
+
+// form is a multipart body, files maps a field to its file name and content.
+type form struct {
+	fields map[string]string
+	files  map[string]file
+}
+
+type file struct {
+	name    string
+	content []byte
+}
+
+// call sends a request with token as Authorization header. body is marshalled to JSON unless it
+// is a form; headers are extra name, value pairs. A path may also be an absolute URL of the
+// server, such as a redirect location.
+func (tr *transcript) call(method, path, token string, body interface{}, headers ...string) *response {
+	tr.t.Helper()
+
+	var reader io.Reader
+	contentType := ""
+	describe := ""
+	switch body := body.(type) {
+	case nil:
+	case form:
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for _, name := range sortedKeys(body.fields) {
+			writer.WriteField(name, body.fields[name])
+		}
+		for _, name := range sortedKeys(body.files) {
+			part, err := writer.CreateFormFile(name, body.files[name].name)
+			if err != nil {
+				tr.t.Fatal(err)
+			}
+			part.Write(body.files[name].content)
+		}
+		writer.Close()
+		reader, contentType = &buf, writer.FormDataContentType()
+		describe = fmt.Sprintf("multipart %v files %v", body.fields, sortedKeys(body.files))
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+		reader, contentType = bytes.NewReader(encoded), "application/json"
+		describe = render(encoded, "")
+	}
+
+	target := path
+	if !strings.HasPrefix(target, "http") {
+		target = tr.s.server.URL + path
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	res, err := tr.s.client.Do(req)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+
+	r := &response{t: tr.t, method: method, path: req.URL.Path, status: res.StatusCode, header: res.Header, body: content}
+	for _, problem := range tr.s.contract.check(method, req.URL.Path, res.StatusCode, res.Header.Get("Content-Type"), content) {
+		tr.t.Errorf("swagger drift: %s", problem)
+	}
+	entry := fmt.Sprintf("%s %s%s\n", method, req.URL.Path, scrubQuery(req.URL.Query()))
+	if describe != "" {
+		entry += describe + "\n"
+	}
+	entry += fmt.Sprintf("--> %d\n%s\n\n", res.StatusCode, r.snapshot())
+	// The server listens on a random port
+	replacer := strings.NewReplacer(append([]string{tr.s.server.URL, "http://server"}, tr.hidden...)...)
+	tr.buf.WriteString(replacer.Replace(entry))
+	return r
+}
+
+func scrubQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	for name := range query {
+		if scrubbed[name] || scrubbedQuery[name] {
+			query.Set(name, "<"+name+">")
+		}
+	}
+	decoded, _ := url.QueryUnescape(query.Encode())
+	return "?" + decoded
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type response struct {
+	t      *testing.T
+	method string
+	path   string
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (r *response) expect(status int) *response {
+	r.t.Helper()
+	if r.status != status {
+		r.t.Errorf("%s %s: status %d, want %d: %s", r.method, r.path, r.status, status, r.body)
+	}
+	return r
+}
+
+func (r *response) decode(v interface{}) *response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("%s %s: %v: %s", r.method, r.path, err, r.body)
+	}
+	return r
+}
+
+// id returns data.id of the response, or id for the handlers returning the bare row.
+func (r *response) id() uint {
+	r.t.Helper()
+	var res struct {
+		ID   uint `json:"id"`
+		Data struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	r.decode(&res)
+	if res.Data.ID != 0 {
+		return res.Data.ID
+	}
+	if res.ID == 0 {
+		r.t.Fatalf("%s %s: no id in %s", r.method, r.path, r.body)
+	}
+	return res.ID
+}
+
+// snapshot renders the body for the golden file: JSON, problem details included, with the
+// scrubbed fields replaced, anything else by its content type only as archives embed random
+// values.
+func (r *response) snapshot() string {
+	contentType := r.header.Get("Content-Type")
+	if len(r.body) == 0 {
+		return "<empty>"
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return "<" + contentType + ">"
+	}
+	return render(r.body, "  ")
+}
+
+// render formats a JSON document with the scrubbed fields replaced, on one line unless indent
+// is set. Anything else is returned as is.
+func render(content []byte, indent string) string {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return string(content)
+	}
+	var rendered bytes.Buffer
+	encoder := json.NewEncoder(&rendered)
+	// Keep the placeholders readable
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(scrub(value)); err != nil {
+		return string(content)
+	}
+	return strings.TrimSuffix(rendered.String(), "\n")
+}
+
+func scrub(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if scrubbed[key] && field != nil && field != "" {
+				value[key] = "<" + key + ">"
+				continue
+			}
+			value[key] = scrub(field)
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = scrub(value[i])
+		}
+	}
+	return value
+}
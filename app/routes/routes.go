@@ -1,23 +1,91 @@
 package routes
 
 import (
+	"be-car-zone/app/config"
 	"be-car-zone/app/controllers"
 	"be-car-zone/app/middlewares"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/mailer"
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/pkg/oidc"
+	"be-car-zone/app/pkg/oidc/mockidp"
+	"be-car-zone/app/pkg/ratelimit"
+	"be-car-zone/app/pkg/storage"
+	"be-car-zone/app/pkg/tracing"
 	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/repositories"
+	"be-car-zone/app/services"
+	"log/slog"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"gorm.io/gorm"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(db *gorm.DB, r *gin.Engine) {
+// Dependencies are the services the handlers are built with, see app.New for the defaults.
+type Dependencies struct {
+	DB      *gorm.DB
+	Now     func() time.Time
+	Storage storage.Storage
+	Mailer  mailer.Mailer
+	// Keyring seals the KYC scans, LegacyKYCCipher opens the ones sealed with KYC_ENCRYPTION_KEY
+	Keyring         *encryption.Keyring
+	LegacyKYCCipher *encryption.Cipher
+	// Logger writes the access logs and the logs of the handlers, slog.Default when nil.
+	Logger *slog.Logger
+	// Metrics records the requests and the business counters, nothing is recorded when nil.
+	// MetricsToken serves them at /metrics to the bearer of the token, when set.
+	Metrics      *metrics.Metrics
+	MetricsToken string
+
+	// The settings of the handlers and middlewares, see config.Config.
+	App        config.App
+	RateLimit  config.RateLimit
+	OIDC       config.OIDC
+	Trash      config.Trash
+	CronSecret string
+}
+
+func SetupRouter(r *gin.Engine, deps Dependencies) {
+	db, now := deps.DB, deps.Now
+	// Handlers pass the gin context to GORM, values of the request context such as the audit
+	// request must be reachable through it
+	r.ContextWithFallback = true
+	// Requests are validated by the validate tags, with the Indonesian rules and translated
+	// messages of utils.NewValidator
+	binding.Validator = structValidator{utils.NewValidator()}
+
+	logger := deps.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	// The span first so the logs carry its trace ID, the request ID next so everything after
+	// logs with it, recovery inside the access log so a panic is logged as the 500 it answers
+	r.Use(middlewares.TracingMiddleware(), middlewares.RequestIDMiddleware(logger), middlewares.AccessLogMiddleware())
+	if deps.Metrics != nil {
+		// Outside of the recovery, a panic is observed as the 500 it answers too
+		r.Use(middlewares.MetricsMiddleware(deps.Metrics))
+	}
+	r.Use(middlewares.RecoveryMiddleware())
+
+	// The rate limits key on the client IP, forwarded headers are only read from trusted proxies.
+	// The proxies are validated with the configuration, should they still be rejected none is
+	// trusted
+	r.TrustedPlatform = deps.App.ClientIPHeader
+	if err := r.SetTrustedProxies(deps.App.Proxies()); err != nil {
+		logger.Error("Trusted proxies", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = []string{"Content-Type", "X-XSRF-TOKEN", "Accept", "Origin", "X-Requested-With", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Content-Type", "X-XSRF-TOKEN", "Accept", "Origin", "X-Requested-With", "Authorization", middlewares.RequestIDHeader}
+	corsConfig.ExposeHeaders = []string{middlewares.RequestIDHeader}
 
 	// To be able to send tokens to the server.
 	corsConfig.AllowCredentials = true
@@ -25,6 +93,17 @@ func SetupRouter(db *gorm.DB, r *gin.Engine) {
 	corsConfig.AddAllowMethods("OPTIONS")
 
 	r.Use(cors.New(corsConfig))
+	r.Use(middlewares.AuditMiddleware())
+
+	// Rate limiting, store is "memory" (per instance) or "sql" (shared)
+	limits := deps.RateLimit
+	limiterStore := ratelimit.NewStore(limits.Store, db)
+	authRateLimit := middlewares.RateLimitMiddleware(limiterStore, "auth", limits.Auth, middlewares.RateLimitByIP)
+	publicRateLimit := middlewares.RateLimitMiddleware(limiterStore, "public", limits.Public, middlewares.RateLimitByIP)
+	cmsRateLimit := middlewares.RateLimitMiddleware(limiterStore, "cms", limits.CMS, middlewares.RateLimitByUserID)
+	partnerRateLimit := middlewares.RateLimitMiddleware(limiterStore, "partner", limits.Partner, middlewares.RateLimitByAPIKey)
+
+	r.Use(middlewares.RateLimitMiddleware(limiterStore, "global", limits.Global, middlewares.RateLimitByIP))
 
 	r.GET("/", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{
@@ -32,17 +111,26 @@ func SetupRouter(db *gorm.DB, r *gin.Engine) {
 		})
 	})
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if deps.Metrics != nil && deps.MetricsToken != "" {
+		r.GET("/metrics", middlewares.MetricsAuthMiddleware(deps.MetricsToken), gin.WrapH(deps.Metrics.Handler()))
+	}
 
 	// set db to gin context
 	r.Use(func(c *gin.Context) {
 		c.Set("db", db)
 	})
 
+	// Services of the order flow
+	store := repositories.NewGormStore(db)
+	orderService := &services.OrderService{Store: store, Now: now, Metrics: deps.Metrics}
+	transactionService := &services.TransactionService{Store: store, Now: now, Metrics: deps.Metrics}
+	invoiceService := &services.InvoiceService{Store: store, Now: now}
+
 	// Init controllers
-	carController := &controllers.CarController{DB: db}
+	carController := &controllers.CarController{DB: db, Now: now}
 	brandCarController := &controllers.BrandCarController{DB: db}
 	typeCarController := &controllers.TypeCarController{DB: db}
-	orderController := &controllers.OrderController{DB: db}
+	orderController := &controllers.OrderController{Orders: orderService}
 
 	// set db to gin context
 	r.Use(func(c *gin.Context) {
@@ -50,21 +138,91 @@ func SetupRouter(db *gorm.DB, r *gin.Engine) {
 	})
 
 	authController := &controllers.AuthController{DB: db}
-	userController := &controllers.UserController{DB: db}
+	userController := &controllers.UserController{DB: db, Now: now}
 	roleController := &controllers.RoleController{DB: db}
-	transactionController := &controllers.TransactionController{DB: db}
-	invoiceController := &controllers.InvoiceController{DB: db}
+	transactionController := &controllers.TransactionController{Transactions: transactionService}
+	invoiceController := &controllers.InvoiceController{Invoices: invoiceService}
+	apiKeyController := &controllers.APIKeyController{DB: db, Now: now}
+	partnerController := &controllers.PartnerController{DB: db}
+	profileController := &controllers.ProfileController{DB: db, Now: now, Storage: deps.Storage, Mailer: deps.Mailer, AppURL: deps.App.URL}
+	addressController := &controllers.AddressController{DB: db}
+	kycFiles := controllers.KYCFiles{Storage: deps.Storage, Keyring: deps.Keyring, Legacy: deps.LegacyKYCCipher}
+	kycController := &controllers.KYCController{DB: db, Now: now, Storage: deps.Storage, Files: kycFiles}
+	encryptionController := &controllers.EncryptionController{DB: db, Files: kycFiles}
+	privacyController := &controllers.PrivacyController{DB: db, Now: now, Storage: deps.Storage, Files: kycFiles}
+	trashController := &controllers.TrashController{
+		DB:                     db,
+		Now:                    now,
+		Storage:                deps.Storage,
+		Orders:                 orderService,
+		RetentionDays:          deps.Trash.RetentionDays,
+		FinancialRetentionDays: deps.Trash.FinancialRetentionDays,
+	}
+	auditLogController := &controllers.AuditLogController{DB: db}
+	// Calls to the identity providers are spans of the login they serve
+	oidcClient := tracing.NewClient(10 * time.Second)
+	oidcController := &controllers.OIDCController{
+		DB:              db,
+		Now:             now,
+		Providers:       oidc.NewProviders(deps.OIDC.Clients, oidcClient),
+		SuccessRedirect: deps.OIDC.SuccessRedirect,
+	}
+
+	// Local mock identity provider so social login can be tried without a Google client
+	if deps.OIDC.MockEnabled {
+		baseURL := deps.OIDC.MockBaseURL
+		if baseURL == "" {
+			baseURL = "http://" + deps.App.Host
+		}
+		mockIdP := mockidp.New(baseURL+"/mock-idp", "car-zone-mock", "car-zone-mock-secret")
+		r.Any("/mock-idp/*path", gin.WrapH(mockIdP.Handler()))
+		oidcController.Providers["mock"] = oidc.NewProvider(oidc.Config{
+			Name:         "mock",
+			Issuer:       mockIdP.Issuer,
+			ClientID:     mockIdP.ClientID,
+			ClientSecret: mockIdP.ClientSecret,
+			RedirectURL:  baseURL + "/api/auth/oidc/mock/callback",
+		}, oidcClient)
+	}
 
 	// Authentication User
-	authRoute := r.Group("/api/auth")
+	authRoute := r.Group("/api/auth", authRateLimit)
 	authRoute.POST("/login", authController.Login)
 	authRoute.POST("/register", authController.Register)
-	authRoute.GET("/me", authController.GetCurrentUser, middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin))
-	authRoute.POST("/change-password", authController.ChangePassword, middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin))
+	authRoute.POST("/verify-email", profileController.VerifyEmail)
+	authRoute.GET("/oidc/:provider/login", oidcController.Login)
+	authRoute.GET("/oidc/:provider/callback", oidcController.Callback)
+	// Signed in, throttled by user like the CMS instead of the anonymous budget of the client IP
+	accountRoute := r.Group("/api/auth", middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin), cmsRateLimit)
+	accountRoute.GET("/me", authController.GetCurrentUser)
+	accountRoute.POST("/change-password", authController.ChangePassword)
+
+	// Self service, always bound to the user owning the token
+	meRoute := r.Group("/api/me", middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin), cmsRateLimit)
+	meRoute.GET("/profile", profileController.Get)
+	meRoute.PATCH("/profile", profileController.Patch)
+	meRoute.POST("/profile/avatar", profileController.UploadAvatar)
+	meRoute.GET("/addresses", addressController.FindAll)
+	meRoute.POST("/addresses", addressController.Create)
+	meRoute.PUT("/addresses/:id", addressController.Update)
+	meRoute.POST("/addresses/:id/default", addressController.SetDefault)
+	meRoute.DELETE("/addresses/:id", addressController.Delete)
+	meRoute.GET("/kyc", kycController.FindMine)
+	meRoute.POST("/kyc", kycController.Submit)
+	meRoute.GET("/kyc/:id/file", kycController.DownloadMine)
+	meRoute.GET("/export", privacyController.Export)
+	meRoute.GET("/erasure-requests", privacyController.FindMyErasureRequests)
+	meRoute.POST("/erasure-requests", privacyController.RequestErasure)
+	meRoute.DELETE("/erasure-requests/:id", privacyController.CancelErasure)
+
+	// Public uploads such as avatars, private files are never served from here
+	if local, ok := deps.Storage.(interface{ PublicDir() string }); ok {
+		r.Static("/uploads", local.PublicDir())
+	}
 
 	// CMS Route
-	cmsRouteAdmin := r.Group("/api/cms/", middlewares.JwtAuthMiddleware(utils.RoleAdmin))
-	cmsRouteAllRole := r.Group("/api/cms/", middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin))
+	cmsRouteAdmin := r.Group("/api/cms/", middlewares.JwtAuthMiddleware(utils.RoleAdmin), cmsRateLimit)
+	cmsRouteAllRole := r.Group("/api/cms/", middlewares.JwtAuthMiddleware(utils.RoleUser, utils.RoleAdmin), cmsRateLimit)
 
 	// CMS User
 	cmsRouteAdmin.GET("/users", userController.FindAll)
@@ -104,24 +262,63 @@ func SetupRouter(db *gorm.DB, r *gin.Engine) {
 
 	// Car
 	cmsRouteAdmin.POST("/cars", carController.Create)
-	r.GET("/api/cms/cars", carController.GetAll)
-	r.GET("/api/cms/cars/:id", carController.GetByID)
+	r.GET("/api/cms/cars", publicRateLimit, carController.GetAll)
+	r.GET("/api/cms/cars/:id", publicRateLimit, carController.GetByID)
 	cmsRouteAdmin.GET("/cars/sales-data", carController.GetCarChartData)
 	cmsRouteAdmin.PUT("/cars/:id", carController.Update)
 	cmsRouteAdmin.DELETE("/cars/:id", carController.Delete)
 
 	// BrandCar
 	cmsRouteAdmin.POST("/brand-cars", brandCarController.Create)
-	r.GET("/api/cms/brand-cars", brandCarController.GetAll)
-	r.GET("/api/cms/brand-cars/:id", brandCarController.GetByID)
+	r.GET("/api/cms/brand-cars", publicRateLimit, brandCarController.GetAll)
+	r.GET("/api/cms/brand-cars/:id", publicRateLimit, brandCarController.GetByID)
 	cmsRouteAdmin.PUT("/brand-cars/:id", brandCarController.Update)
 	cmsRouteAdmin.DELETE("/brand-cars/:id", brandCarController.Delete)
 
 	// TypeCar
 	cmsRouteAdmin.POST("/type-cars", typeCarController.Create)
-	r.GET("/api/cms/type-cars", typeCarController.GetAll)
-	r.GET("/api/cms/type-cars/:id", typeCarController.GetByID)
+	r.GET("/api/cms/type-cars", publicRateLimit, typeCarController.GetAll)
+	r.GET("/api/cms/type-cars/:id", publicRateLimit, typeCarController.GetByID)
 	cmsRouteAdmin.PUT("/type-cars/:id", typeCarController.Update)
 	cmsRouteAdmin.DELETE("/type-cars/:id", typeCarController.Delete)
 
+	// CMS KYC review
+	cmsRouteAdmin.GET("/kyc", kycController.FindAll)
+	cmsRouteAdmin.GET("/kyc/:id/file", kycController.Download)
+	cmsRouteAdmin.POST("/kyc/:id/approve", kycController.Approve)
+	cmsRouteAdmin.POST("/kyc/:id/reject", kycController.Reject)
+
+	// CMS Personal data erasure
+	cmsRouteAdmin.GET("/erasure-requests", privacyController.FindErasureRequests)
+	cmsRouteAdmin.POST("/erasure-requests/:id/approve", privacyController.ApproveErasure)
+	cmsRouteAdmin.POST("/erasure-requests/:id/reject", privacyController.RejectErasure)
+
+	// CMS Trash
+	cmsRouteAdmin.GET("/trash", trashController.Summary)
+	cmsRouteAdmin.GET("/trash/:entity", trashController.FindAll)
+	cmsRouteAdmin.POST("/trash/:entity/:id/restore", trashController.Restore)
+	cmsRouteAdmin.DELETE("/trash/:entity/:id", trashController.Purge)
+
+	// Scheduled jobs, see crons in vercel.json
+	r.GET("/api/cron/purge-trash", middlewares.CronAuthMiddleware(deps.CronSecret), trashController.PurgeExpired)
+
+	// CMS Audit log
+	cmsRouteAdmin.GET("/audit-logs", auditLogController.FindAll)
+
+	// CMS Encryption key rotation
+	cmsRouteAdmin.POST("/encryption/reencrypt", encryptionController.Reencrypt)
+
+	// CMS API Key
+	cmsRouteAdmin.GET("/api-keys", apiKeyController.FindAll)
+	cmsRouteAdmin.POST("/api-keys", apiKeyController.Create)
+	cmsRouteAdmin.DELETE("/api-keys/:id", apiKeyController.Revoke)
+	cmsRouteAdmin.GET("/api-keys/:id/audits", apiKeyController.FindAudits)
+	cmsRouteAdmin.GET("/leads", partnerController.FindAllLeads)
+
+	// Partner, authenticated with API keys instead of JWT
+	partnerRoute := r.Group("/api/partner", partnerRateLimit)
+	partnerRoute.GET("/cars", middlewares.APIKeyAuthMiddleware(utils.ScopeCarsRead), partnerController.GetCars)
+	partnerRoute.GET("/cars/:id", middlewares.APIKeyAuthMiddleware(utils.ScopeCarsRead), partnerController.GetCarByID)
+	partnerRoute.POST("/leads", middlewares.APIKeyAuthMiddleware(utils.ScopeLeadsWrite), partnerController.CreateLead)
+
 }
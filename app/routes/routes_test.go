@@ -0,0 +1,423 @@
+package routes_test
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/pkg/utils"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// png is the smallest content the upload handlers detect as a PNG image.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+// TestRoutes runs the API end to end. The steps share one database and run in order, each one
+// is compared with its golden file in testdata. The last step fails when a route of
+// routes.SetupRouter was never requested.
+func TestRoutes(t *testing.T) {
+	s := newSuite(t)
+
+	steps := []struct {
+		name string
+		run  func(*testing.T, *suite)
+	}{
+		{"public", testPublic},
+		{"auth", testAuth},
+		{"profile", testProfile},
+		{"addresses", testAddresses},
+		{"kyc", testKYC},
+		{"catalog", testCatalog},
+		{"orders", testOrders},
+		{"users", testUsers},
+		{"partner", testPartner},
+		{"privacy", testPrivacy},
+		{"trash", testTrash},
+		{"admin", testAdmin},
+		{"metrics", testMetrics},
+	}
+	ran := true
+	for _, step := range steps {
+		ran = t.Run(step.name, func(t *testing.T) { step.run(t, s) }) && ran
+	}
+
+	// A filtered run skips steps, their routes are not expected to be covered
+	if filter := flag.Lookup("test.run").Value.String(); strings.Contains(filter, "/") {
+		return
+	}
+	if missing := s.uncovered(); len(missing) > 0 {
+		t.Errorf("%d routes are not covered by the end to end tests:\n%s", len(missing), strings.Join(missing, "\n"))
+	}
+
+	var routes []string
+	for _, route := range s.routes {
+		routes = append(routes, route.Method+" "+route.Path)
+	}
+	missing, unknown := s.contract.undocumented(routes)
+	for _, route := range missing {
+		t.Errorf("swagger drift: %s is not documented", route)
+	}
+	for _, route := range unknown {
+		t.Errorf("swagger drift: %s is documented but not routed", route)
+	}
+	if !ran {
+		t.Log("earlier steps failed, later ones may fail because of them")
+	}
+}
+
+func testPublic(t *testing.T, s *suite) {
+	tr := s.transcript(t, "public")
+	tr.call("GET", "/", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/swagger/index.html", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/cars", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/cars/1", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/cars/999", "", nil).expect(http.StatusNotFound)
+	tr.call("GET", "/api/cms/brand-cars", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/brand-cars/1", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/type-cars", "", nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/type-cars/1", "", nil).expect(http.StatusOK)
+}
+
+func testAuth(t *testing.T, s *suite) {
+	tr := s.transcript(t, "auth")
+	tr.call("POST", "/api/auth/register", "", map[string]string{"username": "sari", "email": "sari@carzone.test", "password": "sari-secret"}).expect(http.StatusOK)
+	tr.call("POST", "/api/auth/register", "", map[string]string{"username": "sari", "email": "other@carzone.test", "password": "sari-secret"}).expect(http.StatusConflict)
+	tr.call("POST", "/api/auth/login", "", map[string]string{"username": "sari", "password": "wrong"}).expect(http.StatusBadRequest)
+	sari := s.login(tr, "sari", "sari-secret")
+
+	tr.call("GET", "/api/auth/me", "", nil).expect(http.StatusUnauthorized)
+	tr.call("GET", "/api/auth/me", sari, nil).expect(http.StatusOK)
+	tr.call("POST", "/api/auth/change-password", sari, map[string]string{"old_password": "wrong", "new_password": "sari-secret-2"}).expect(http.StatusUnauthorized)
+	tr.call("POST", "/api/auth/change-password", sari, map[string]string{"old_password": "sari-secret", "new_password": "sari-secret-2"}).expect(http.StatusOK)
+	s.login(tr, "sari", "sari-secret-2")
+
+	// Social login through the mock identity provider served by the same router
+	tr.call("GET", "/api/auth/oidc/unknown/login", "", nil).expect(http.StatusNotFound)
+	// sari never verified the email, the identity must not take the account over
+	authorize := tr.call("GET", "/api/auth/oidc/mock/login?login_hint=sari@carzone.test", "", nil).expect(http.StatusFound)
+	callback := tr.call("GET", authorize.header.Get("Location"), "", nil).expect(http.StatusFound)
+	tr.call("GET", callback.header.Get("Location"), "", nil).expect(http.StatusConflict)
+	if err := s.db.Table("users").Where("username = ?", "sari").Update("email_verified_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	authorize = tr.call("GET", "/api/auth/oidc/mock/login?login_hint=Sari@CarZone.test", "", nil).expect(http.StatusFound)
+	callback = tr.call("GET", authorize.header.Get("Location"), "", nil).expect(http.StatusFound)
+	tr.call("GET", callback.header.Get("Location"), "", nil).expect(http.StatusOK)
+	var linked int64
+	if err := s.db.Table("user_identities").Joins("JOIN users ON users.id = user_identities.user_id").
+		Where("users.username = ? AND user_identities.email = ?", "sari", "sari@carzone.test").Count(&linked).Error; err != nil || linked != 1 {
+		t.Fatalf("the identity was not linked to sari: %d, %v", linked, err)
+	}
+
+	// A new user is registered with the email the provider verified
+	authorize = tr.call("GET", "/api/auth/oidc/mock/login?login_hint=rina@carzone.test", "", nil).expect(http.StatusFound)
+	callback = tr.call("GET", authorize.header.Get("Location"), "", nil).expect(http.StatusFound)
+	tr.call("GET", callback.header.Get("Location"), "", nil).expect(http.StatusOK)
+	var verifiedAt time.Time
+	if err := s.db.Table("users").Where("email = ?", "rina@carzone.test").Select("email_verified_at").Scan(&verifiedAt).Error; err != nil || !verifiedAt.Equal(now) {
+		t.Fatalf("rina was registered with email_verified_at %v, %v", verifiedAt, err)
+	}
+	tr.call("GET", "/api/auth/oidc/mock/callback?error=access_denied", "", nil).expect(http.StatusBadRequest)
+}
+
+func testProfile(t *testing.T, s *suite) {
+	tr := s.transcript(t, "profile")
+	tr.call("GET", "/api/me/profile", s.user, nil).expect(http.StatusOK)
+	tr.call("PATCH", "/api/me/profile", s.user, map[string]string{"email": "Sari@CarZone.test"}).expect(http.StatusConflict)
+	tr.call("PATCH", "/api/me/profile", s.user, map[string]string{"phone_number": "081234567890", "email": "Budi.Baru@carzone.test"}).expect(http.StatusOK)
+	tr.call("POST", "/api/auth/verify-email", "", map[string]string{"token": "not-a-token"}).expect(http.StatusBadRequest)
+	tr.call("POST", "/api/auth/verify-email", "", map[string]string{"token": s.mails.lastToken(t, "budi.baru@carzone.test")}).expect(http.StatusOK)
+
+	tr.call("POST", "/api/me/profile/avatar", s.user, form{files: map[string]file{"avatar": {"avatar.txt", []byte("hello")}}}).expect(http.StatusBadRequest)
+	var avatar struct {
+		Data struct {
+			AvatarURL string `json:"avatar_url"`
+		} `json:"data"`
+	}
+	tr.call("POST", "/api/me/profile/avatar", s.user, form{files: map[string]file{"avatar": {"avatar.png", png}}}).expect(http.StatusOK).decode(&avatar)
+	tr.hide(avatar.Data.AvatarURL, "<avatar_url>")
+	tr.call("GET", avatar.Data.AvatarURL, "", nil).expect(http.StatusOK)
+	tr.call("HEAD", avatar.Data.AvatarURL, "", nil).expect(http.StatusOK)
+
+	profile := map[string]string{"username": "budi", "email": "budi.baru@carzone.test", "phone_number": "081234567890", "address": "Jl. Braga 1, Bandung"}
+	tr.call("PUT", fmt.Sprintf("/api/cms/user/profile/%d", s.userID), s.user, profile).expect(http.StatusOK)
+	tr.call("PUT", "/api/cms/user/profile/1", s.user, profile).expect(http.StatusForbidden)
+	profile["email"] = "budi.lain@carzone.test"
+	tr.call("PUT", fmt.Sprintf("/api/cms/user/profile/%d", s.userID), s.user, profile).expect(http.StatusBadRequest)
+}
+
+func testAddresses(t *testing.T, s *suite) {
+	tr := s.transcript(t, "addresses")
+	address := map[string]interface{}{
+		"label":          "Rumah",
+		"recipient_name": "Budi",
+		"phone_number":   "081234567890",
+		"street":         "Jl. Braga No. 1",
+		"kelurahan":      "Braga",
+		"kecamatan":      "Sumur Bandung",
+		"city":           "Bandung",
+		"province":       "Jawa Barat",
+		"postal_code":    "40111",
+		"is_default":     true,
+	}
+	home := tr.call("POST", "/api/me/addresses", s.user, address).expect(http.StatusCreated).id()
+	address["label"], address["postal_code"], address["is_default"] = "Kantor", "4011", false
+	tr.call("POST", "/api/me/addresses", s.user, address).expect(http.StatusBadRequest)
+	// Indonesian clients get Indonesian messages
+	address["postal_code"], address["phone_number"] = "40115", "12345"
+	tr.call("POST", "/api/me/addresses", s.user, address, "Accept-Language", "id-ID,id;q=0.9,en;q=0.8").expect(http.StatusBadRequest)
+	address["phone_number"] = "+62 812-3456-7890"
+	office := tr.call("POST", "/api/me/addresses", s.user, address).expect(http.StatusCreated).id()
+
+	address["street"] = "Jl. Asia Afrika No. 8"
+	tr.call("PUT", fmt.Sprintf("/api/me/addresses/%d", office), s.user, address).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/me/addresses/%d/default", office), s.user, nil).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/me/addresses/%d/default", home), s.user, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/me/addresses/%d", office), s.user, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/me/addresses/%d", office), s.user, nil).expect(http.StatusNotFound)
+	tr.call("GET", "/api/me/addresses", s.user, nil).expect(http.StatusOK)
+}
+
+func testKYC(t *testing.T, s *suite) {
+	tr := s.transcript(t, "kyc")
+	scan := map[string]file{"file": {"ktp.png", png}}
+	tr.call("POST", "/api/me/kyc", s.user, form{fields: map[string]string{"type": "ktp", "number": "1234"}, files: scan}).expect(http.StatusBadRequest)
+	ktp := tr.call("POST", "/api/me/kyc", s.user, form{fields: map[string]string{"type": "ktp", "number": "3273010101900001"}, files: scan}).expect(http.StatusCreated).id()
+	sim := tr.call("POST", "/api/me/kyc", s.user, form{fields: map[string]string{"type": "sim", "number": "123456789012"}, files: scan}).expect(http.StatusCreated).id()
+	tr.call("GET", "/api/me/kyc", s.user, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/me/kyc/%d/file", ktp), s.user, nil).expect(http.StatusOK)
+
+	tr.call("GET", "/api/cms/kyc", s.user, nil).expect(http.StatusForbidden)
+	tr.call("GET", "/api/cms/kyc", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/kyc/%d/file", ktp), s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/cms/kyc/%d/approve", ktp), s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/cms/kyc/%d/reject", sim), s.admin, map[string]string{}).expect(http.StatusBadRequest)
+	tr.call("POST", fmt.Sprintf("/api/cms/kyc/%d/reject", sim), s.admin, map[string]string{"reason": "Foto buram"}).expect(http.StatusOK)
+	tr.call("GET", "/api/me/kyc", s.user, nil).expect(http.StatusOK)
+}
+
+func testCatalog(t *testing.T, s *suite) {
+	tr := s.transcript(t, "catalog")
+	tr.call("POST", "/api/cms/brand-cars", s.user, map[string]string{"name": "Wuling"}).expect(http.StatusForbidden)
+	brand := tr.call("POST", "/api/cms/brand-cars", s.admin, map[string]string{"name": "Wuling"}).expect(http.StatusCreated).id()
+	tr.call("PUT", fmt.Sprintf("/api/cms/brand-cars/%d", brand), s.admin, map[string]string{"name": "Wuling Motors"}).expect(http.StatusOK)
+	carType := tr.call("POST", "/api/cms/type-cars", s.admin, map[string]string{"name": "EV"}).expect(http.StatusCreated).id()
+	tr.call("PUT", fmt.Sprintf("/api/cms/type-cars/%d", carType), s.admin, map[string]string{"name": "Electric"}).expect(http.StatusOK)
+
+	car := map[string]interface{}{"name": "Wuling Air ev", "description": "Mobil listrik kota", "price": 190_000_000, "type_id": carType, "brand_id": brand}
+	tr.call("POST", "/api/cms/cars", s.admin, map[string]interface{}{"name": "Tanpa harga"}).expect(http.StatusBadRequest)
+	tr.call("POST", "/api/cms/cars", s.admin, map[string]interface{}{"name": "Harga sen", "price": 190_000_000.5, "type_id": carType, "brand_id": brand}).expect(http.StatusBadRequest)
+	var created struct {
+		Car struct {
+			ID uint `json:"ID"`
+		} `json:"car"`
+	}
+	tr.call("POST", "/api/cms/cars", s.admin, car).expect(http.StatusCreated).decode(&created)
+	id := created.Car.ID
+	car["price"] = 185_000_000
+	tr.call("PUT", fmt.Sprintf("/api/cms/cars/%d", id), s.admin, car).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/cars/sales-data", s.admin, nil).expect(http.StatusOK)
+
+	tr.call("DELETE", fmt.Sprintf("/api/cms/cars/%d", id), s.admin, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/type-cars/%d", carType), s.admin, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/brand-cars/%d", brand), s.admin, nil).expect(http.StatusOK)
+}
+
+func testOrders(t *testing.T, s *suite) {
+	tr := s.transcript(t, "orders")
+	tr.call("POST", "/api/cms/orders", s.user, map[string]interface{}{"car_id": 1, "total_price": 1}).expect(http.StatusBadRequest)
+	order := tr.call("POST", "/api/cms/orders", s.user, map[string]interface{}{"car_id": 1}).expect(http.StatusOK).id()
+	tr.call("POST", "/api/cms/orders", s.admin, map[string]interface{}{"car_id": 1}).expect(http.StatusConflict)
+	tr.call("GET", "/api/cms/orders", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/orders?phone=0812-3456-7890", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/orders?phone=081234567890", s.user, nil).expect(http.StatusForbidden)
+	tr.call("GET", fmt.Sprintf("/api/cms/orders/%d", s.userID), s.user, nil).expect(http.StatusOK)
+
+	// The delivery snapshot is sealed at rest
+	var street string
+	if err := s.db.Raw("SELECT delivery_street FROM orders WHERE id = ?", order).Scan(&street).Error; err != nil || !encryption.IsEncrypted(street) {
+		t.Errorf("delivery_street is stored as %q, %v", street, err)
+	}
+
+	paid := map[string]interface{}{"car_id": 1, "status": true, "order_image": "bukti.jpg"}
+	tr.call("PUT", fmt.Sprintf("/api/cms/orders/%d", order), s.user, paid).expect(http.StatusOK)
+
+	payment := map[string]interface{}{"order_id": order, "payment_provider": "BCA", "no_rek": "1234567890", "amount": 265_000_000}
+	transaction := tr.call("POST", "/api/cms/transactions", s.user, payment).expect(http.StatusOK).id()
+	payment["no_rek"] = "0987654321"
+	tr.call("PUT", fmt.Sprintf("/api/cms/transactions/%d", transaction), s.user, payment).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/transactions", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/transactions/%d", order), s.user, nil).expect(http.StatusOK)
+
+	invoice := tr.call("POST", "/api/cms/invoices", s.user, map[string]interface{}{"transaction_id": transaction}).expect(http.StatusOK).id()
+	tr.call("PUT", fmt.Sprintf("/api/cms/invoices/%d", invoice), s.user, map[string]interface{}{"order_id": order, "transaction_id": transaction}).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/invoices", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/invoices/%d", order), s.user, nil).expect(http.StatusOK)
+
+	// Another buyer can neither see nor change the payments of budi's order
+	sari := s.login(tr, "sari", "sari-secret-2")
+	tr.call("GET", "/api/cms/transactions", sari, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/transactions/%d", order), sari, nil).expect(http.StatusNotFound)
+	tr.call("POST", "/api/cms/transactions", sari, payment).expect(http.StatusNotFound)
+	tr.call("PUT", fmt.Sprintf("/api/cms/transactions/%d", transaction), sari, payment).expect(http.StatusNotFound)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/transactions/%d", transaction), sari, nil).expect(http.StatusNotFound)
+	tr.call("GET", "/api/cms/invoices", sari, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/invoices/%d", order), sari, nil).expect(http.StatusNotFound)
+	tr.call("POST", "/api/cms/invoices", sari, map[string]interface{}{"transaction_id": transaction}).expect(http.StatusNotFound)
+	tr.call("PUT", fmt.Sprintf("/api/cms/invoices/%d", invoice), sari, map[string]interface{}{"transaction_id": transaction}).expect(http.StatusNotFound)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/invoices/%d", invoice), sari, nil).expect(http.StatusNotFound)
+
+	tr.call("DELETE", fmt.Sprintf("/api/cms/orders/%d", order), s.admin, nil).expect(http.StatusConflict)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/transactions/%d", transaction), s.admin, nil).expect(http.StatusConflict)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/invoices/%d", invoice), s.admin, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/transactions/%d", transaction), s.admin, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/orders/%d", order), s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/cars/1", "", nil).expect(http.StatusOK)
+}
+
+func testUsers(t *testing.T, s *suite) {
+	tr := s.transcript(t, "users")
+	tr.call("GET", "/api/cms/users", s.user, nil).expect(http.StatusForbidden)
+	tr.call("GET", "/api/cms/users", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/users/%d", s.userID), s.admin, nil).expect(http.StatusOK)
+
+	user := map[string]interface{}{"username": "andi", "email": "andi@carzone.test", "password": "andi-secret", "role_id": utils.IDRoleUser}
+	id := tr.call("POST", "/api/cms/users", s.admin, user).expect(http.StatusOK).id()
+	tr.call("POST", "/api/cms/users", s.admin, user).expect(http.StatusConflict)
+	user["phone_number"], user["password"] = "089876543210", ""
+	tr.call("PUT", fmt.Sprintf("/api/cms/users/%d", id), s.admin, user).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/users/%d", id), s.admin, nil).expect(http.StatusOK)
+
+	tr.call("GET", "/api/cms/roles", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/roles/%d", utils.IDRoleAdmin), s.admin, nil).expect(http.StatusOK)
+	role := tr.call("POST", "/api/cms/roles", s.admin, map[string]string{"role_name": "sales"}).expect(http.StatusOK).id()
+	tr.call("PUT", fmt.Sprintf("/api/cms/roles/%d", role), s.admin, map[string]string{"role_name": "marketing"}).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/roles/%d", role), s.admin, nil).expect(http.StatusOK)
+}
+
+func testPartner(t *testing.T, s *suite) {
+	tr := s.transcript(t, "partner")
+	var created struct {
+		Data struct {
+			ID  uint   `json:"id"`
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	tr.call("POST", "/api/cms/api-keys", s.admin, map[string]interface{}{"name": "OLX", "scopes": []string{"cars:write"}}).expect(http.StatusBadRequest)
+	tr.call("POST", "/api/cms/api-keys", s.admin, map[string]interface{}{"name": "OLX", "scopes": []string{"cars:read"}}).expect(http.StatusCreated).decode(&created)
+	key := created.Data.Key
+	tr.call("GET", "/api/cms/api-keys", s.admin, nil).expect(http.StatusOK)
+
+	tr.call("GET", "/api/partner/cars", "", nil).expect(http.StatusUnauthorized)
+	tr.call("GET", "/api/partner/cars", "", nil, "X-API-Key", key).expect(http.StatusOK)
+	tr.call("GET", "/api/partner/cars/2", "", nil, "X-API-Key", key).expect(http.StatusOK)
+	lead := map[string]interface{}{"car_id": 2, "name": "Rina", "email": "rina@example.com", "message": "Masih ada?"}
+	tr.call("POST", "/api/partner/leads", "", lead, "X-API-Key", key).expect(http.StatusForbidden)
+
+	tr.call("POST", "/api/cms/api-keys", s.admin, map[string]interface{}{"name": "Mobil123", "scopes": []string{"cars:read", "leads:write"}}).expect(http.StatusCreated).decode(&created)
+	tr.call("POST", "/api/partner/leads", "", lead, "X-API-Key", created.Data.Key).expect(http.StatusCreated)
+	tr.call("GET", "/api/cms/leads", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", fmt.Sprintf("/api/cms/api-keys/%d/audits", created.Data.ID), s.admin, nil).expect(http.StatusOK)
+
+	tr.call("DELETE", fmt.Sprintf("/api/cms/api-keys/%d", created.Data.ID), s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/partner/cars", "", nil, "X-API-Key", created.Data.Key).expect(http.StatusUnauthorized)
+}
+
+func testPrivacy(t *testing.T, s *suite) {
+	tr := s.transcript(t, "privacy")
+	tr.call("GET", "/api/me/export?format=json", s.user, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/me/export", s.user, nil).expect(http.StatusOK)
+
+	tr.call("POST", "/api/auth/register", "", map[string]string{"username": "dewi", "email": "dewi@carzone.test", "password": "dewi-secret"}).expect(http.StatusOK)
+	dewi := s.login(tr, "dewi", "dewi-secret")
+	// A trashed payment is still personal data of dewi
+	order := tr.call("POST", "/api/cms/orders", dewi, map[string]interface{}{"car_id": 1}).expect(http.StatusOK).id()
+	payment := map[string]interface{}{"order_id": order, "payment_provider": "BCA", "no_rek": "5555666677778888", "amount": 1_000_000}
+	transaction := tr.call("POST", "/api/cms/transactions", dewi, payment).expect(http.StatusOK).id()
+	tr.call("DELETE", fmt.Sprintf("/api/cms/transactions/%d", transaction), dewi, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/orders/%d", order), dewi, nil).expect(http.StatusOK)
+
+	tr.call("POST", "/api/me/erasure-requests", dewi, map[string]string{"password": "wrong"}).expect(http.StatusUnauthorized)
+	request := tr.call("POST", "/api/me/erasure-requests", dewi, map[string]string{"password": "dewi-secret", "reason": "Tidak dipakai lagi"}).expect(http.StatusCreated).id()
+	tr.call("DELETE", fmt.Sprintf("/api/me/erasure-requests/%d", request), dewi, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/me/erasure-requests/%d", request), dewi, nil).expect(http.StatusConflict)
+
+	request = tr.call("POST", "/api/me/erasure-requests", dewi, map[string]string{"password": "dewi-secret"}).expect(http.StatusCreated).id()
+	tr.call("GET", "/api/cms/erasure-requests", s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/cms/erasure-requests/%d/reject", request), s.admin, map[string]string{"reason": "Masih ada tagihan"}).expect(http.StatusOK)
+
+	request = tr.call("POST", "/api/me/erasure-requests", dewi, map[string]string{"password": "dewi-secret"}).expect(http.StatusCreated).id()
+	tr.call("GET", "/api/me/erasure-requests", dewi, nil).expect(http.StatusOK)
+	tr.call("POST", fmt.Sprintf("/api/cms/erasure-requests/%d/approve", request), s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", "/api/auth/login", "", map[string]string{"username": "dewi", "password": "dewi-secret"}).expect(http.StatusBadRequest)
+
+	var trashed models.Transaction
+	if err := s.db.Unscoped().First(&trashed, transaction).Error; err != nil || trashed.NoRek != "************8888" {
+		t.Errorf("the trashed payment kept the account number %q, %v", trashed.NoRek, err)
+	}
+
+	var leaked int64
+	if err := s.db.Table("audit_logs").Where("changes LIKE ?", "%dewi%").Count(&leaked).Error; err != nil || leaked > 0 {
+		t.Errorf("%d audit log entries still hold the erased username or email, %v", leaked, err)
+	}
+}
+
+func testTrash(t *testing.T, s *suite) {
+	tr := s.transcript(t, "trash")
+	tr.call("GET", "/api/cms/trash", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/trash/spaceships", s.admin, nil).expect(http.StatusNotFound)
+	tr.call("GET", "/api/cms/trash/orders", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/trash/transactions", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/trash/users", s.admin, nil).expect(http.StatusOK)
+
+	// The car of the catalog step was deleted with its brand and type, restore the brand first
+	tr.call("POST", "/api/cms/trash/cars/10/restore", s.admin, nil).expect(http.StatusConflict)
+	tr.call("POST", "/api/cms/trash/brand-cars/7/restore", s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", "/api/cms/trash/type-cars/6/restore", s.admin, nil).expect(http.StatusOK)
+	tr.call("POST", "/api/cms/trash/cars/10/restore", s.admin, nil).expect(http.StatusOK)
+
+	tr.call("DELETE", "/api/cms/trash/invoices/1", s.admin, nil).expect(http.StatusOK)
+	tr.call("DELETE", fmt.Sprintf("/api/cms/trash/roles/%d", utils.IDRoleUser+1), s.admin, nil).expect(http.StatusOK)
+
+	tr.call("GET", "/api/cron/purge-trash", "", nil).expect(http.StatusUnauthorized)
+	tr.call("GET", "/api/cron/purge-trash", "Bearer "+cronSecret, nil).expect(http.StatusOK)
+}
+
+func testAdmin(t *testing.T, s *suite) {
+	tr := s.transcript(t, "admin")
+	tr.call("POST", "/api/cms/encryption/reencrypt", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/audit-logs?entity=cars&limit=3", s.admin, nil).expect(http.StatusOK)
+	tr.call("GET", "/api/cms/audit-logs?from=yesterday", s.admin, nil).expect(http.StatusBadRequest)
+}
+
+func testMetrics(t *testing.T, s *suite) {
+	tr := s.transcript(t, "metrics")
+	tr.call("GET", "/wp-login.php", "", nil).expect(http.StatusNotFound)
+	tr.call("GET", "/metrics", "", nil).expect(http.StatusUnauthorized)
+	tr.call("GET", "/metrics", "Bearer "+cronSecret, nil).expect(http.StatusUnauthorized)
+	res := tr.call("GET", "/metrics", "Bearer "+metricsToken, nil).expect(http.StatusOK)
+
+	// The values depend on the earlier steps, only their presence is checked
+	samples := map[string]bool{}
+	for _, line := range strings.Split(string(res.body), "\n") {
+		if name, value, ok := strings.Cut(line, " "); ok && !strings.HasPrefix(line, "#") && value != "0" {
+			samples[name] = true
+		}
+	}
+	for _, name := range []string{
+		`http_request_duration_seconds_count{method="GET",route="/api/cms/cars/:id",status="200"}`,
+		`http_request_duration_seconds_count{method="GET",route="/api/cms/cars/:id",status="404"}`,
+		`http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`,
+		`go_sql_max_open_connections{db_name="sqlite"}`,
+		`carzone_orders_created_total`,
+		`carzone_transactions_recorded_total`,
+		`carzone_cars_sold_total`,
+	} {
+		if !samples[name] {
+			t.Errorf("/metrics has no %s", name)
+		}
+	}
+}
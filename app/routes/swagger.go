@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"be-car-zone/docs"
+)
+
+// ConfigureSwagger fills in the Swagger info that depends on the deployment, host is the
+// public address of the API.
+func ConfigureSwagger(environment, host string) {
+	docs.SwaggerInfo.Title = "Car Zone API"
+	docs.SwaggerInfo.Description = "This is a sample server Car Zone."
+	docs.SwaggerInfo.Version = "1.0"
+	docs.SwaggerInfo.Host = host
+	if environment == "development" {
+		docs.SwaggerInfo.Schemes = []string{"http", "https"}
+	} else {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
+}
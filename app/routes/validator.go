@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// structValidator is the binding.StructValidator of gin backed by the validator of the handlers.
+type structValidator struct {
+	validate *validator.Validate
+}
+
+// ValidateStruct validates structs, pointers to structs and slices of them like gin's default
+// validator, anything else is not validated.
+func (v structValidator) ValidateStruct(obj interface{}) error {
+	if obj == nil {
+		return nil
+	}
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.validate.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.ValidateStruct(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v structValidator) Engine() interface{} {
+	return v.validate
+}
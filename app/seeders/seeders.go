@@ -0,0 +1,190 @@
+// Package seeders fills a database with the rows the application cannot run without, the roles
+// and a first admin, and optionally with demo data. Every seeder can run any number of times.
+package seeders
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Options selects what Run seeds. The admin is skipped when AdminUsername or AdminPassword is
+// empty, see AdminSkipped.
+type Options struct {
+	AdminUsername string
+	AdminEmail    string
+	AdminPassword string
+	Demo          bool
+}
+
+// AdminSkipped reports whether Run leaves the admin out. A username without a password is
+// skipped rather than failing, the roles and the demo data can be seeded without an admin.
+func (o Options) AdminSkipped() bool {
+	return o.AdminUsername == "" || o.AdminPassword == ""
+}
+
+// Report counts the rows a run created, rows that already existed are left untouched.
+type Report struct {
+	Roles  int
+	Admin  bool
+	Brands int
+	Types  int
+	Cars   int
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("roles: %d, admin: %t, brands: %d, types: %d, cars: %d created", r.Roles, r.Admin, r.Brands, r.Types, r.Cars)
+}
+
+// Run seeds the roles, the admin and the demo data in one transaction.
+func Run(db *gorm.DB, opts Options) (Report, error) {
+	var report Report
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if report.Roles, err = Roles(tx); err != nil {
+			return err
+		}
+		if !opts.AdminSkipped() {
+			if report.Admin, err = Admin(tx, opts.AdminUsername, opts.AdminEmail, opts.AdminPassword); err != nil {
+				return err
+			}
+		}
+		if opts.Demo {
+			if report.Brands, report.Types, report.Cars, err = Demo(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// Roles creates the admin and user roles with the IDs the code relies on, see utils.IDRoleAdmin
+// and utils.IDRoleUser. A role in the trash is restored.
+func Roles(db *gorm.DB) (int, error) {
+	roles := []models.Role{
+		{ID: utils.IDRoleAdmin, RoleName: utils.RoleAdmin},
+		{ID: utils.IDRoleUser, RoleName: utils.RoleUser},
+	}
+
+	created := 0
+	for _, role := range roles {
+		var existing models.Role
+		err := db.Unscoped().First(&existing, role.ID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := db.Create(&role).Error; err != nil {
+				return created, err
+			}
+			created++
+		case err != nil:
+			return created, err
+		case existing.DeletedAt.Valid:
+			if err := db.Unscoped().Model(&existing).Update("deleted_at", nil).Error; err != nil {
+				return created, err
+			}
+		}
+	}
+	return created, nil
+}
+
+// Admin creates an admin account unless the username is taken, an existing account keeps its
+// password and role. It reports whether the account was created.
+func Admin(db *gorm.DB, username, email, password string) (bool, error) {
+	if email == "" || password == "" {
+		return false, errors.New("seeders: the admin needs an email and a password")
+	}
+	if len(password) < 8 {
+		return false, errors.New("seeders: the admin password must be at least 8 characters")
+	}
+
+	var count int64
+	if err := db.Unscoped().Model(&models.User{}).Where("username = ? OR email = ?", username, email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	verifiedAt := db.NowFunc()
+	admin := models.User{
+		Username:        username,
+		Email:           email,
+		Password:        hash,
+		RoleID:          utils.IDRoleAdmin,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	return true, db.Create(&admin).Error
+}
+
+type demoCar struct {
+	name, description, brand, carType string
+	price                             float64
+	isSecond                          bool
+}
+
+var (
+	demoBrands = []string{"Toyota", "Honda", "Mitsubishi", "Suzuki", "Daihatsu", "Hyundai"}
+	demoTypes  = []string{"MPV", "SUV", "Sedan", "Hatchback", "Pickup"}
+	demoCars   = []demoCar{
+		{"Toyota Avanza 1.5 G", "MPV tujuh penumpang untuk keluarga", "Toyota", "MPV", 265_000_000, false},
+		{"Toyota Fortuner 2.8 VRZ", "SUV diesel 4x2 dengan transmisi otomatis", "Toyota", "SUV", 620_000_000, false},
+		{"Honda Brio Satya E", "City car irit untuk harian", "Honda", "Hatchback", 180_000_000, false},
+		{"Honda Civic 1.5 Turbo 2019", "Bekas, satu tangan, servis rutin di bengkel resmi", "Honda", "Sedan", 385_000_000, true},
+		{"Mitsubishi Xpander Ultimate", "MPV dengan ground clearance tinggi", "Mitsubishi", "MPV", 320_000_000, false},
+		{"Mitsubishi Triton 2.4 GLS", "Pickup double cabin untuk usaha", "Mitsubishi", "Pickup", 480_000_000, false},
+		{"Suzuki Ertiga GX 2020", "Bekas, kilometer rendah", "Suzuki", "MPV", 195_000_000, true},
+		{"Daihatsu Terios R", "SUV kompak tujuh penumpang", "Daihatsu", "SUV", 275_000_000, false},
+		{"Hyundai Creta Prime", "SUV kompak dengan fitur keselamatan lengkap", "Hyundai", "SUV", 390_000_000, false},
+	}
+)
+
+// Demo creates sample brands, types and cars, matched on their names. It returns how many of
+// each were created.
+func Demo(db *gorm.DB) (brands, types, cars int, err error) {
+	brandIDs := map[string]int{}
+	for _, name := range demoBrands {
+		brand := models.BrandCar{Name: name}
+		result := db.Where(models.BrandCar{Name: name}).FirstOrCreate(&brand)
+		if result.Error != nil {
+			return brands, types, cars, result.Error
+		}
+		brands += int(result.RowsAffected)
+		brandIDs[name] = brand.ID
+	}
+
+	typeIDs := map[string]int{}
+	for _, name := range demoTypes {
+		carType := models.TypeCar{Name: name}
+		result := db.Where(models.TypeCar{Name: name}).FirstOrCreate(&carType)
+		if result.Error != nil {
+			return brands, types, cars, result.Error
+		}
+		types += int(result.RowsAffected)
+		typeIDs[name] = carType.ID
+	}
+
+	for _, demo := range demoCars {
+		car := models.Car{
+			Name:        demo.name,
+			Description: demo.description,
+			Price:       demo.price,
+			BrandID:     uint(brandIDs[demo.brand]),
+			TypeID:      uint(typeIDs[demo.carType]),
+			IsSecond:    demo.isSecond,
+		}
+		result := db.Where(models.Car{Name: car.Name, BrandID: car.BrandID}).FirstOrCreate(&car)
+		if result.Error != nil {
+			return brands, types, cars, result.Error
+		}
+		cars += int(result.RowsAffected)
+	}
+	return brands, types, cars, nil
+}
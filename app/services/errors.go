@@ -0,0 +1,48 @@
+// Package services holds the business rules of the order flow: pricing, reserving a car, paying
+// an order, and what may be recorded against it. Services reach the database through the
+// repositories package and report rule violations as *Error values.
+package services
+
+// Kind classifies an Error so the HTTP layer can choose a status code.
+type Kind int
+
+const (
+	// NotFound means a referenced row does not exist.
+	NotFound Kind = iota + 1
+	// Invalid means the input breaks a rule on its own, such as a negative amount.
+	Invalid
+	// Conflict means the input is fine but the current state does not allow the change.
+	Conflict
+)
+
+// Error is a business rule violation. Code is stable and names the rule for clients, Message
+// is safe to show to them.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrOrderNotFound       = &Error{NotFound, "order_not_found", "order not found"}
+	ErrTransactionNotFound = &Error{NotFound, "transaction_not_found", "transaction not found"}
+	ErrInvoiceNotFound     = &Error{NotFound, "invoice_not_found", "invoice not found"}
+	ErrCarNotFound         = &Error{NotFound, "car_not_found", "car not found"}
+	ErrAddressNotFound     = &Error{NotFound, "address_not_found", "address not found"}
+
+	ErrPriceMismatch       = &Error{Invalid, "price_mismatch", "total_price must match the price of the car"}
+	ErrInvalidAmount       = &Error{Invalid, "invalid_amount", "amount must be positive"}
+	ErrTransactionMismatch = &Error{Invalid, "transaction_mismatch", "the transaction belongs to another order"}
+
+	ErrCarSold             = &Error{Conflict, "car_sold", "the car is already sold"}
+	ErrCarReserved         = &Error{Conflict, "car_reserved", "the car is reserved by another order"}
+	ErrKYCRequired         = &Error{Conflict, "kyc_required", "the buyer's KTP must be approved before the order can be paid"}
+	ErrOrderPaid           = &Error{Conflict, "order_paid", "a paid order cannot be set back to unpaid, change its car or its price"}
+	ErrOrderHasPayments    = &Error{Conflict, "order_has_payments", "order has transactions or invoices and cannot be deleted"}
+	ErrOrderUnpaid         = &Error{Conflict, "order_unpaid", "invoices can only be issued for paid orders"}
+	ErrTransactionInvoiced = &Error{Conflict, "transaction_invoiced", "transaction has an invoice and cannot be deleted"}
+)
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/repositories"
+	"context"
+	"errors"
+	"time"
+)
+
+// InvoiceInput is what a client may set on an invoice. A zero OrderID means the order of the
+// transaction.
+type InvoiceInput struct {
+	OrderID       uint
+	TransactionID uint
+}
+
+// InvoiceService issues invoices for payments of paid orders.
+type InvoiceService struct {
+	Store repositories.Store
+	Now   func() time.Time
+}
+
+// List returns the invoices of paid orders the viewer may see, newest first. Admins see every
+// invoice, buyers the invoices of their own orders.
+func (s *InvoiceService) List(ctx context.Context, viewer models.Viewer) ([]models.Invoice, error) {
+	invoices, err := s.Store.Invoices().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := make([]models.Invoice, 0, len(invoices))
+	for _, invoice := range invoices {
+		if invoice.Order.Status && viewer.CanSeePrivate(invoice.Order.UserID) {
+			paid = append(paid, invoice)
+		}
+	}
+	return paid, nil
+}
+
+// ListByOrder returns the invoices of an order of the viewer.
+func (s *InvoiceService) ListByOrder(ctx context.Context, viewer models.Viewer, orderID uint) ([]models.Invoice, error) {
+	if _, err := owned(ctx, s.Store, viewer, orderID); err != nil {
+		return nil, err
+	}
+	return s.Store.Invoices().ListByOrder(ctx, orderID)
+}
+
+// Create issues an invoice for a payment of the viewer.
+func (s *InvoiceService) Create(ctx context.Context, viewer models.Viewer, in InvoiceInput) (models.Invoice, error) {
+	var id uint
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		orderID, err := validateInvoice(ctx, store, viewer, in)
+		if err != nil {
+			return err
+		}
+
+		invoice := models.Invoice{
+			OrderID:       orderID,
+			TransactionID: in.TransactionID,
+			CreatedAt:     s.Now(),
+		}
+		if err := store.Invoices().Create(ctx, &invoice); err != nil {
+			return err
+		}
+		id = invoice.ID
+		return nil
+	})
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	return s.Store.Invoices().Get(ctx, id)
+}
+
+// Update points an invoice of the viewer at another payment of the viewer.
+func (s *InvoiceService) Update(ctx context.Context, viewer models.Viewer, id uint, in InvoiceInput) (models.Invoice, error) {
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		invoice, err := ownedInvoice(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+		orderID, err := validateInvoice(ctx, store, viewer, in)
+		if err != nil {
+			return err
+		}
+
+		invoice.OrderID = orderID
+		invoice.TransactionID = in.TransactionID
+		invoice.UpdatedAt = s.Now()
+		return store.Invoices().Save(ctx, &invoice)
+	})
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	return s.Store.Invoices().Get(ctx, id)
+}
+
+// Delete moves an invoice of the viewer to the trash.
+func (s *InvoiceService) Delete(ctx context.Context, viewer models.Viewer, id uint) error {
+	return s.Store.Atomic(ctx, func(store repositories.Store) error {
+		invoice, err := ownedInvoice(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+		return store.Invoices().Delete(ctx, &invoice)
+	})
+}
+
+// validateInvoice checks that the transaction pays a paid order of the viewer and returns that
+// order's ID.
+func validateInvoice(ctx context.Context, store repositories.Store, viewer models.Viewer, in InvoiceInput) (uint, error) {
+	transaction, err := ownedTransaction(ctx, store, viewer, in.TransactionID)
+	if err != nil {
+		return 0, err
+	}
+	if in.OrderID != 0 && in.OrderID != transaction.OrderID {
+		return 0, ErrTransactionMismatch
+	}
+	if !transaction.Order.Status {
+		return 0, ErrOrderUnpaid
+	}
+	return transaction.OrderID, nil
+}
+
+// ownedInvoice loads an invoice of an order the viewer may change. The invoices of other buyers
+// are not found, like invoices that do not exist.
+func ownedInvoice(ctx context.Context, store repositories.Store, viewer models.Viewer, id uint) (models.Invoice, error) {
+	invoice, err := store.Invoices().Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && !viewer.CanSeePrivate(invoice.Order.UserID)) {
+		return models.Invoice{}, ErrInvoiceNotFound
+	}
+	return invoice, err
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/repositories"
+	"context"
+	"errors"
+	"time"
+)
+
+// OrderInput is what a client may set on an order. A zero TotalPrice means the price of the car,
+// a nil AddressID means the buyer's default address. UserID is the buyer of a new order, an
+// existing order keeps its buyer.
+type OrderInput struct {
+	UserID     uint
+	CarID      uint
+	TotalPrice float64
+	Status     bool
+	OrderImage string
+	AddressID  *uint
+}
+
+// OrderService applies the ordering rules. An unpaid order reserves its car, no other order can
+// be placed for it; paying an order marks the car sold and needs an approved KTP. A paid order
+// keeps its car and price and cannot become unpaid again.
+type OrderService struct {
+	Store   repositories.Store
+	Now     func() time.Time
+	Metrics *metrics.Metrics
+}
+
+// List returns every order, newest first.
+func (s *OrderService) List(ctx context.Context) ([]models.Order, error) {
+	return s.Store.Orders().List(ctx)
+}
+
+// ListByDeliveryPhone returns the orders delivered to a phone number, newest first.
+func (s *OrderService) ListByDeliveryPhone(ctx context.Context, phone string) ([]models.Order, error) {
+	return s.Store.Orders().ListByDeliveryPhone(ctx, phone)
+}
+
+// ListByUser returns the orders of a buyer.
+func (s *OrderService) ListByUser(ctx context.Context, userID uint) ([]models.Order, error) {
+	return s.Store.Orders().ListByUser(ctx, userID)
+}
+
+// Create places an order for in.UserID.
+func (s *OrderService) Create(ctx context.Context, in OrderInput) (models.Order, error) {
+	var id uint
+	var sold bool
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		car, err := available(ctx, store, in.CarID, 0)
+		if err != nil {
+			return err
+		}
+		price, err := carPrice(car, in.TotalPrice)
+		if err != nil {
+			return err
+		}
+		if in.Status {
+			if err := requireKYC(ctx, store, in.UserID); err != nil {
+				return err
+			}
+		}
+
+		order := models.Order{
+			UserID:     in.UserID,
+			CarID:      car.ID,
+			TotalPrice: price,
+			Status:     in.Status,
+			OrderImage: in.OrderImage,
+			CreatedAt:  s.Now(),
+		}
+		// Snapshot the delivery address so later address book edits do not change the order
+		address, err := deliveryAddress(ctx, store, in.UserID, in.AddressID)
+		if err != nil {
+			return err
+		}
+		if address != nil {
+			order.AddressID = &address.ID
+			order.DeliveryAddress = address.DeliveryAddress
+		}
+
+		if err := store.Orders().Create(ctx, &order); err != nil {
+			return err
+		}
+		id, sold = order.ID, order.Status
+		if order.Status {
+			return store.Cars().SetSold(ctx, car.ID, true)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// Counted once committed, a rolled back order was never placed
+	s.Metrics.OrderCreated()
+	if sold {
+		s.Metrics.CarSold()
+	}
+	return s.Store.Orders().Get(ctx, id)
+}
+
+// Update changes an order of the viewer, admins may change any order. Paying it checks the
+// buyer's KTP and marks the car sold.
+func (s *OrderService) Update(ctx context.Context, viewer models.Viewer, id uint, in OrderInput) (models.Order, error) {
+	var sold bool
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		order, err := owned(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+
+		if order.Status {
+			// Only the proof of payment and the delivery address remain editable
+			priceChanged := in.TotalPrice != 0 && in.TotalPrice != order.TotalPrice
+			if !in.Status || in.CarID != order.CarID || priceChanged {
+				return ErrOrderPaid
+			}
+		} else {
+			car, err := available(ctx, store, in.CarID, order.ID)
+			if err != nil {
+				return err
+			}
+			if order.TotalPrice, err = carPrice(car, in.TotalPrice); err != nil {
+				return err
+			}
+			// Only an order moving to paid needs the check, paid orders stay editable
+			if in.Status {
+				if err := requireKYC(ctx, store, order.UserID); err != nil {
+					return err
+				}
+			}
+		}
+		paying := in.Status && !order.Status
+
+		order.CarID = in.CarID
+		order.Status = in.Status
+		order.OrderImage = in.OrderImage
+		order.UpdatedAt = s.Now()
+
+		// The snapshot is only replaced when another address is explicitly chosen
+		if in.AddressID != nil && (order.AddressID == nil || *order.AddressID != *in.AddressID) {
+			address, err := deliveryAddress(ctx, store, order.UserID, in.AddressID)
+			if err != nil {
+				return err
+			}
+			order.AddressID = &address.ID
+			order.DeliveryAddress = address.DeliveryAddress
+		}
+
+		if err := store.Orders().Save(ctx, &order); err != nil {
+			return err
+		}
+		if paying {
+			sold = true
+			return store.Cars().SetSold(ctx, order.CarID, true)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if sold {
+		s.Metrics.CarSold()
+	}
+	return s.Store.Orders().Get(ctx, id)
+}
+
+// Delete moves an order of the viewer to the trash, which releases its car. Admins may delete any
+// order. Orders with transactions or invoices are kept.
+func (s *OrderService) Delete(ctx context.Context, viewer models.Viewer, id uint) error {
+	return s.Store.Atomic(ctx, func(store repositories.Store) error {
+		order, err := owned(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+
+		paid, err := store.Orders().HasPayments(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if paid {
+			return ErrOrderHasPayments
+		}
+
+		if err := store.Orders().Delete(ctx, &order); err != nil {
+			return err
+		}
+		if order.Status {
+			return store.Cars().SetSold(ctx, order.CarID, false)
+		}
+		return nil
+	})
+}
+
+// Restore takes an order out of the trash. The car must still be free: a restored unpaid order
+// reserves it again and a restored paid order marks it sold again.
+func (s *OrderService) Restore(ctx context.Context, id uint) error {
+	return s.Store.Atomic(ctx, func(store repositories.Store) error {
+		order, err := store.Orders().GetDeleted(ctx, id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := available(ctx, store, order.CarID, order.ID); err != nil {
+			return err
+		}
+		if err := store.Orders().Restore(ctx, &order); err != nil {
+			return err
+		}
+		if order.Status {
+			return store.Cars().SetSold(ctx, order.CarID, true)
+		}
+		return nil
+	})
+}
+
+// owned loads an order the viewer may change. The orders of other buyers are not found, like
+// orders that do not exist.
+func owned(ctx context.Context, store repositories.Store, viewer models.Viewer, id uint) (models.Order, error) {
+	order, err := store.Orders().Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && viewer.Role != utils.RoleAdmin && order.UserID != viewer.UserID) {
+		return models.Order{}, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// available locks the car and checks that it can be ordered, orderID is the order asking for it
+// when it already exists.
+func available(ctx context.Context, store repositories.Store, carID, orderID uint) (models.Car, error) {
+	car, err := store.Cars().Lock(ctx, carID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return car, ErrCarNotFound
+	}
+	if err != nil {
+		return car, err
+	}
+	if car.Sold {
+		return car, ErrCarSold
+	}
+
+	reserved, err := store.Orders().HasOpen(ctx, car.ID, orderID)
+	if err != nil {
+		return car, err
+	}
+	if reserved {
+		return car, ErrCarReserved
+	}
+	return car, nil
+}
+
+// carPrice is the price an order for car is placed at. Cars sell at their listed price, a client
+// sending another total has a stale listing.
+func carPrice(car models.Car, requested float64) (float64, error) {
+	if requested != 0 && requested != car.Price {
+		return 0, ErrPriceMismatch
+	}
+	return car.Price, nil
+}
+
+// requireKYC checks the buyer's KTP, vehicle registration needs it before an order is paid.
+func requireKYC(ctx context.Context, store repositories.Store, userID uint) error {
+	approved, err := store.KYC().KTPApproved(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return ErrKYCRequired
+	}
+	return nil
+}
+
+// deliveryAddress picks the address to snapshot on an order: the requested one, or the user's
+// default. Without a default the order has no address.
+func deliveryAddress(ctx context.Context, store repositories.Store, userID uint, addressID *uint) (*models.UserAddress, error) {
+	var address models.UserAddress
+	var err error
+	if addressID != nil {
+		address, err = store.Addresses().Get(ctx, userID, *addressID)
+	} else {
+		address, err = store.Addresses().Default(ctx, userID)
+	}
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		if addressID == nil {
+			return nil, nil
+		}
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/utils"
+	"be-car-zone/app/repositories/fake"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	buyer    = uint(1)
+	avanza   = uint(10)
+	fortuner = uint(11)
+)
+
+var (
+	now   = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	owner = models.Viewer{UserID: buyer, Role: utils.RoleUser}
+	staff = models.Viewer{UserID: 100, Role: utils.RoleAdmin}
+)
+
+func newStore() *fake.Store {
+	store := fake.NewStore()
+	store.Data.Users[buyer] = models.User{ID: buyer, Username: "buyer"}
+	store.Data.Cars[avanza] = models.Car{ID: avanza, Name: "Avanza", Price: 265_000_000}
+	store.Data.Cars[fortuner] = models.Car{ID: fortuner, Name: "Fortuner", Price: 620_000_000}
+	store.Data.Addresses[20] = models.UserAddress{ID: 20, UserID: buyer, IsDefault: true, DeliveryAddress: models.DeliveryAddress{City: "Bandung"}}
+	return store
+}
+
+func orderService(store *fake.Store) *OrderService {
+	return &OrderService{Store: store, Now: func() time.Time { return now }}
+}
+
+func TestCreateOrderPricesAndReservesTheCar(t *testing.T) {
+	store := newStore()
+	orders := orderService(store)
+	ctx := context.Background()
+
+	order, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.TotalPrice != 265_000_000 || order.Car.Name != "Avanza" || !order.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if order.DeliveryAddress.City != "Bandung" {
+		t.Fatalf("the default address should be snapshotted, got %+v", order.DeliveryAddress)
+	}
+
+	if _, err := orders.Create(ctx, OrderInput{UserID: 2, CarID: avanza}); !errors.Is(err, ErrCarReserved) {
+		t.Fatalf("a reserved car was ordered again: %v", err)
+	}
+	if _, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: fortuner, TotalPrice: 1}); !errors.Is(err, ErrPriceMismatch) {
+		t.Fatalf("a stale price was accepted: %v", err)
+	}
+	if _, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: 99}); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("an unknown car was ordered: %v", err)
+	}
+	missing := uint(99)
+	if _, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: fortuner, AddressID: &missing}); !errors.Is(err, ErrAddressNotFound) {
+		t.Fatalf("an unknown address was accepted: %v", err)
+	}
+}
+
+func TestPayingAnOrderNeedsKYCAndSellsTheCar(t *testing.T) {
+	store := newStore()
+	orders := orderService(store)
+	ctx := context.Background()
+
+	order, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := OrderInput{UserID: buyer, CarID: avanza, Status: true}
+	if _, err := orders.Update(ctx, owner, order.ID, paid); !errors.Is(err, ErrKYCRequired) {
+		t.Fatalf("paid without an approved KTP: %v", err)
+	}
+
+	store.Data.ApprovedKTP[buyer] = true
+	if _, err := orders.Update(ctx, owner, order.ID, paid); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Data.Cars[avanza].Sold {
+		t.Fatal("paying should mark the car sold")
+	}
+	if _, err := orders.Create(ctx, OrderInput{UserID: 2, CarID: avanza}); !errors.Is(err, ErrCarSold) {
+		t.Fatalf("a sold car was ordered: %v", err)
+	}
+
+	for name, input := range map[string]OrderInput{
+		"unpaid": {UserID: buyer, CarID: avanza},
+		"car":    {UserID: buyer, CarID: fortuner, Status: true},
+		"price":  {UserID: buyer, CarID: avanza, Status: true, TotalPrice: 1},
+	} {
+		if _, err := orders.Update(ctx, owner, order.ID, input); !errors.Is(err, ErrOrderPaid) {
+			t.Errorf("%s: a paid order changed: %v", name, err)
+		}
+	}
+	if _, err := orders.Update(ctx, owner, order.ID, OrderInput{UserID: buyer, CarID: avanza, Status: true, OrderImage: "proof.jpg"}); err != nil {
+		t.Fatalf("the proof of payment should stay editable: %v", err)
+	}
+}
+
+func TestOrdersAreChangedByTheirBuyer(t *testing.T) {
+	store := newStore()
+	store.Data.ApprovedKTP[buyer] = true
+	orders := orderService(store)
+	ctx := context.Background()
+
+	order, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger := models.Viewer{UserID: 2, Role: utils.RoleUser}
+	if _, err := orders.Update(ctx, stranger, order.ID, OrderInput{CarID: avanza, Status: true}); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("another user updated the order: %v", err)
+	}
+	if err := orders.Delete(ctx, stranger, order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("another user deleted the order: %v", err)
+	}
+
+	// The KTP checked is the buyer's, whoever pays the order
+	paid, err := orders.Update(ctx, staff, order.ID, OrderInput{UserID: 2, CarID: avanza, Status: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.UserID != buyer {
+		t.Fatalf("the buyer changed to %d", paid.UserID)
+	}
+}
+
+func TestDeleteOrderReleasesTheCar(t *testing.T) {
+	store := newStore()
+	store.Data.ApprovedKTP[buyer] = true
+	orders := orderService(store)
+	transactions := &TransactionService{Store: store, Now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	order, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza, Status: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction, err := transactions.Create(ctx, owner, TransactionInput{OrderID: order.ID, PaymentProvider: "BCA", Amount: order.TotalPrice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Delete(ctx, owner, order.ID); !errors.Is(err, ErrOrderHasPayments) {
+		t.Fatalf("an order with payments was deleted: %v", err)
+	}
+
+	if err := transactions.Delete(ctx, owner, transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Delete(ctx, owner, order.ID); err != nil {
+		t.Fatal(err)
+	}
+	if store.Data.Cars[avanza].Sold {
+		t.Fatal("deleting a paid order should put the car back on sale")
+	}
+}
+
+func TestRestoreOrderChecksTheCar(t *testing.T) {
+	store := newStore()
+	store.Data.ApprovedKTP[buyer] = true
+	orders := orderService(store)
+	ctx := context.Background()
+
+	paid, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza, Status: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Delete(ctx, owner, paid.ID); err != nil {
+		t.Fatal(err)
+	}
+	other, err := orders.Create(ctx, OrderInput{UserID: 2, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Restore(ctx, paid.ID); !errors.Is(err, ErrCarReserved) {
+		t.Fatalf("restored an order for a reserved car: %v", err)
+	}
+
+	if err := orders.Delete(ctx, staff, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Restore(ctx, paid.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Data.Cars[avanza].Sold {
+		t.Fatal("restoring a paid order should mark the car sold again")
+	}
+	if err := orders.Restore(ctx, other.ID); !errors.Is(err, ErrCarSold) {
+		t.Fatalf("restored an order for a sold car: %v", err)
+	}
+	if err := orders.Restore(ctx, paid.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("restored a live order: %v", err)
+	}
+}
+
+func TestFailedWriteRollsBack(t *testing.T) {
+	store := newStore()
+	store.Data.ApprovedKTP[buyer] = true
+	store.Fail = func(operation string) error {
+		if operation == "cars.set_sold" {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+
+	if _, err := orderService(store).Create(context.Background(), OrderInput{UserID: buyer, CarID: avanza, Status: true}); err == nil {
+		t.Fatal("the failure should be returned")
+	}
+	if len(store.Data.Orders) != 0 {
+		t.Fatalf("the order should be rolled back, got %v", store.Data.Orders)
+	}
+}
+
+func TestTransactionRules(t *testing.T) {
+	store := newStore()
+	transactions := &TransactionService{Store: store, Now: func() time.Time { return now }}
+	ctx := context.Background()
+	order, err := orderService(store).Create(ctx, OrderInput{UserID: buyer, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := transactions.Create(ctx, owner, TransactionInput{OrderID: order.ID, Amount: 0}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("an empty payment was accepted: %v", err)
+	}
+	if _, err := transactions.Create(ctx, owner, TransactionInput{OrderID: 99, Amount: 1}); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("a payment for an unknown order was accepted: %v", err)
+	}
+	transaction, err := transactions.Create(ctx, owner, TransactionInput{OrderID: order.ID, Amount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !transaction.TransactionDate.Equal(now) || transaction.Order.ID != order.ID {
+		t.Fatalf("unexpected transaction %+v", transaction)
+	}
+}
+
+func TestInvoicesOnlyForPaidOrders(t *testing.T) {
+	store := newStore()
+	ctx := context.Background()
+	orders := orderService(store)
+	transactions := &TransactionService{Store: store, Now: func() time.Time { return now }}
+	invoices := &InvoiceService{Store: store, Now: func() time.Time { return now }}
+
+	order, err := orders.Create(ctx, OrderInput{UserID: buyer, CarID: avanza})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction, err := transactions.Create(ctx, owner, TransactionInput{OrderID: order.ID, Amount: order.TotalPrice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoices.Create(ctx, owner, InvoiceInput{TransactionID: transaction.ID}); !errors.Is(err, ErrOrderUnpaid) {
+		t.Fatalf("an unpaid order was invoiced: %v", err)
+	}
+
+	store.Data.ApprovedKTP[buyer] = true
+	if _, err := orders.Update(ctx, owner, order.ID, OrderInput{UserID: buyer, CarID: avanza, Status: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoices.Create(ctx, owner, InvoiceInput{OrderID: order.ID + 1, TransactionID: transaction.ID}); !errors.Is(err, ErrTransactionMismatch) {
+		t.Fatalf("an invoice mixed two orders: %v", err)
+	}
+	invoice, err := invoices.Create(ctx, owner, InvoiceInput{TransactionID: transaction.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.OrderID != order.ID {
+		t.Fatalf("the order should come from the transaction, got %d", invoice.OrderID)
+	}
+	if err := transactions.Delete(ctx, owner, transaction.ID); !errors.Is(err, ErrTransactionInvoiced) {
+		t.Fatalf("an invoiced payment was deleted: %v", err)
+	}
+}
+
+func TestPaymentsAreChangedByTheBuyer(t *testing.T) {
+	store := newStore()
+	store.Data.ApprovedKTP[buyer] = true
+	ctx := context.Background()
+	transactions := &TransactionService{Store: store, Now: func() time.Time { return now }}
+	invoices := &InvoiceService{Store: store, Now: func() time.Time { return now }}
+
+	order, err := orderService(store).Create(ctx, OrderInput{UserID: buyer, CarID: avanza, Status: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger := models.Viewer{UserID: 2, Role: utils.RoleUser}
+	payment := TransactionInput{OrderID: order.ID, PaymentProvider: "BCA", Amount: order.TotalPrice}
+	if _, err := transactions.Create(ctx, stranger, payment); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("another user paid the order: %v", err)
+	}
+	transaction, err := transactions.Create(ctx, owner, payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := transactions.Update(ctx, stranger, transaction.ID, payment); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("another user changed the payment: %v", err)
+	}
+	if err := transactions.Delete(ctx, stranger, transaction.ID); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("another user deleted the payment: %v", err)
+	}
+	if _, err := transactions.ListByOrder(ctx, stranger, order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("another user listed the payments: %v", err)
+	}
+	if listed, err := transactions.List(ctx, stranger); err != nil || len(listed) != 0 {
+		t.Fatalf("another user sees %d payments, %v", len(listed), err)
+	}
+	if listed, err := transactions.List(ctx, staff); err != nil || len(listed) != 1 {
+		t.Fatalf("admins see %d payments, %v", len(listed), err)
+	}
+
+	// Moving a payment to an order of someone else is refused too
+	other, err := orderService(store).Create(ctx, OrderInput{UserID: 2, CarID: fortuner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment.OrderID = other.ID
+	if _, err := transactions.Update(ctx, owner, transaction.ID, payment); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("the payment moved to another buyer's order: %v", err)
+	}
+
+	if _, err := invoices.Create(ctx, stranger, InvoiceInput{TransactionID: transaction.ID}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("another user invoiced the payment: %v", err)
+	}
+	invoice, err := invoices.Create(ctx, owner, InvoiceInput{TransactionID: transaction.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoices.Update(ctx, stranger, invoice.ID, InvoiceInput{TransactionID: transaction.ID}); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Fatalf("another user changed the invoice: %v", err)
+	}
+	if err := invoices.Delete(ctx, stranger, invoice.ID); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Fatalf("another user deleted the invoice: %v", err)
+	}
+	if listed, err := invoices.List(ctx, stranger); err != nil || len(listed) != 0 {
+		t.Fatalf("another user sees %d invoices, %v", len(listed), err)
+	}
+	if err := invoices.Delete(ctx, staff, invoice.ID); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"be-car-zone/app/models"
+	"be-car-zone/app/pkg/metrics"
+	"be-car-zone/app/repositories"
+	"context"
+	"errors"
+	"time"
+)
+
+// TransactionInput is what a client may set on a payment.
+type TransactionInput struct {
+	OrderID         uint
+	PaymentProvider string
+	NoRek           string
+	Amount          float64
+}
+
+// TransactionService records payments against orders.
+type TransactionService struct {
+	Store   repositories.Store
+	Now     func() time.Time
+	Metrics *metrics.Metrics
+}
+
+// List returns the transactions the viewer may see, newest first. Admins see every transaction,
+// buyers the payments of their own orders.
+func (s *TransactionService) List(ctx context.Context, viewer models.Viewer) ([]models.Transaction, error) {
+	transactions, err := s.Store.Transactions().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]models.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if viewer.CanSeePrivate(transaction.Order.UserID) {
+			visible = append(visible, transaction)
+		}
+	}
+	return visible, nil
+}
+
+// ListByOrder returns the payments of an order of the viewer.
+func (s *TransactionService) ListByOrder(ctx context.Context, viewer models.Viewer, orderID uint) ([]models.Transaction, error) {
+	if _, err := owned(ctx, s.Store, viewer, orderID); err != nil {
+		return nil, err
+	}
+	return s.Store.Transactions().ListByOrder(ctx, orderID)
+}
+
+// Create records a payment made now for an order of the viewer.
+func (s *TransactionService) Create(ctx context.Context, viewer models.Viewer, in TransactionInput) (models.Transaction, error) {
+	var id uint
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		if err := s.validate(ctx, store, viewer, in); err != nil {
+			return err
+		}
+
+		transaction := models.Transaction{
+			OrderID:         in.OrderID,
+			PaymentProvider: in.PaymentProvider,
+			NoRek:           in.NoRek,
+			Amount:          in.Amount,
+			TransactionDate: s.Now(),
+			CreatedAt:       s.Now(),
+		}
+		if err := store.Transactions().Create(ctx, &transaction); err != nil {
+			return err
+		}
+		id = transaction.ID
+		return nil
+	})
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	s.Metrics.TransactionRecorded()
+	return s.Store.Transactions().Get(ctx, id)
+}
+
+// Update corrects a payment of the viewer, the transaction date stays. It can only be moved to
+// another order of the viewer.
+func (s *TransactionService) Update(ctx context.Context, viewer models.Viewer, id uint, in TransactionInput) (models.Transaction, error) {
+	err := s.Store.Atomic(ctx, func(store repositories.Store) error {
+		transaction, err := ownedTransaction(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+		if err := s.validate(ctx, store, viewer, in); err != nil {
+			return err
+		}
+
+		transaction.OrderID = in.OrderID
+		transaction.PaymentProvider = in.PaymentProvider
+		transaction.NoRek = in.NoRek
+		transaction.Amount = in.Amount
+		transaction.UpdatedAt = s.Now()
+		return store.Transactions().Save(ctx, &transaction)
+	})
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	return s.Store.Transactions().Get(ctx, id)
+}
+
+// Delete moves a payment of the viewer to the trash unless it was invoiced.
+func (s *TransactionService) Delete(ctx context.Context, viewer models.Viewer, id uint) error {
+	return s.Store.Atomic(ctx, func(store repositories.Store) error {
+		transaction, err := ownedTransaction(ctx, store, viewer, id)
+		if err != nil {
+			return err
+		}
+
+		invoiced, err := store.Transactions().IsInvoiced(ctx, transaction.ID)
+		if err != nil {
+			return err
+		}
+		if invoiced {
+			return ErrTransactionInvoiced
+		}
+		return store.Transactions().Delete(ctx, &transaction)
+	})
+}
+
+func (s *TransactionService) validate(ctx context.Context, store repositories.Store, viewer models.Viewer, in TransactionInput) error {
+	if in.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	_, err := owned(ctx, store, viewer, in.OrderID)
+	return err
+}
+
+// ownedTransaction loads a payment of an order the viewer may change. The payments of other
+// buyers are not found, like payments that do not exist.
+func ownedTransaction(ctx context.Context, store repositories.Store, viewer models.Viewer, id uint) (models.Transaction, error) {
+	transaction, err := store.Transactions().Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && !viewer.CanSeePrivate(transaction.Order.UserID)) {
+		return models.Transaction{}, ErrTransactionNotFound
+	}
+	return transaction, err
+}
@@ -0,0 +1,85 @@
+// Command carzone runs maintenance tasks against the Car Zone database.
+//
+//	carzone migrate up [n]    apply pending migrations, at most n of them
+//	carzone migrate down [n]  roll back the last n migrations, 1 by default
+//	carzone migrate status    list migrations and when they were applied
+//	carzone migrate version   print the current and the expected schema version
+//	carzone migrate baseline v  record the migrations up to v as applied without running them
+//	carzone seed [flags]      create the roles, the initial admin and optionally demo data
+//	carzone config [flags]    validate the configuration and print it with secrets redacted
+//
+// The database settings come from the environment and the config file, see config.Load.
+package main
+
+import (
+	"be-car-zone/app/config"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const usage = `usage: carzone <command> [arguments]
+
+commands:
+  migrate up [n]     apply pending migrations, at most n of them
+  migrate down [n]   roll back the last n migrations, 1 by default
+  migrate status     list migrations and when they were applied
+  migrate version    print the current and the expected schema version
+  migrate baseline v record the migrations up to v as applied without running
+                     them, for a database created by AutoMigrate or by hand
+                     whose schema was checked to match them
+  seed [flags]       create the roles, the initial admin and optionally demo data,
+                     see "carzone seed -h"
+  config [flags]     validate the configuration and print it with secrets redacted,
+                     see "carzone config -h"
+
+The configuration is read from the environment and from CONFIG_FILE, .env by default.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = withConfig(func(cfg *config.Config) error { return runMigrate(cfg.Database, os.Args[2:]) })
+	case "seed":
+		err = withConfig(func(cfg *config.Config) error { return runSeed(cfg, os.Args[2:]) })
+	case "config":
+		err = runConfig(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", os.Args[1], usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func withConfig(run func(cfg *config.Config) error) error {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return run(cfg)
+}
+
+func runConfig(args []string) error {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Printf("usage: carzone config [flags]\n\nflags:\n%s", config.Usage())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	fmt.Print(cfg)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"be-car-zone/app/config"
+	"be-car-zone/app/pkg/migrate"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func runMigrate(db config.Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs a subcommand: up, down, status, version or baseline")
+	}
+	steps, err := stepsArg(args[1:])
+	if err != nil {
+		return err
+	}
+
+	migrator, err := config.NewMigrator(config.OpenDataBase(db))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		for _, migration := range applied {
+			fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("already up to date")
+		}
+		if errors.Is(err, migrate.ErrUnversioned) {
+			return fmt.Errorf("%w. Check that the schema matches the migrations, then record it with \"carzone migrate baseline <version>\"", err)
+		}
+		return err
+	case "baseline":
+		if steps == 0 {
+			return errors.New("migrate baseline needs the version the schema matches, e.g. \"carzone migrate baseline 1\"")
+		}
+		recorded, err := migrator.Baseline(ctx, int64(steps))
+		for _, migration := range recorded {
+			fmt.Printf("recorded %d_%s as applied\n", migration.Version, migration.Name)
+		}
+		return err
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Up == "" {
+				appliedAt += " (unknown to this build)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	case "version":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		var current int64
+		for _, status := range statuses {
+			if status.AppliedAt != nil && status.Version > current {
+				current = status.Version
+			}
+		}
+		fmt.Printf("current %d, expected %d\n", current, migrator.Latest())
+		return migrator.Check(ctx)
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+func stepsArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("the number of migrations must be a positive number, got %q", args[0])
+	}
+	return steps, nil
+}
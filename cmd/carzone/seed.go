@@ -0,0 +1,45 @@
+package main
+
+import (
+	"be-car-zone/app/config"
+	"be-car-zone/app/pkg/encryption"
+	"be-car-zone/app/seeders"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runSeed(cfg *config.Config, args []string) error {
+	opts := seeders.Options{
+		AdminUsername: cfg.Seed.AdminUsername,
+		AdminEmail:    cfg.Seed.AdminEmail,
+		AdminPassword: cfg.Seed.AdminPassword,
+		Demo:          cfg.Seed.Demo,
+	}
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.StringVar(&opts.AdminUsername, "admin-username", opts.AdminUsername, "username of the initial admin (ADMIN_USERNAME)")
+	flags.StringVar(&opts.AdminEmail, "admin-email", opts.AdminEmail, "email of the initial admin (ADMIN_EMAIL)")
+	flags.StringVar(&opts.AdminPassword, "admin-password", opts.AdminPassword, "password of the initial admin, prefer ADMIN_PASSWORD so it stays out of the shell history")
+	flags.BoolVar(&opts.Demo, "demo", opts.Demo, "also create sample brands, types and cars (SEED_DEMO)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.AdminUsername != "" && opts.AdminSkipped() {
+		fmt.Fprintf(os.Stderr, "ADMIN_PASSWORD is empty, the admin %q is not created. Set it or pass -admin-password to create it.\n", opts.AdminUsername)
+	}
+
+	// The admin is a user, its encrypted columns need the keyring
+	ring, err := cfg.Keyring()
+	if err != nil {
+		return err
+	}
+	encryption.SetDefault(ring)
+
+	report, err := seeders.Run(config.OpenDataBase(cfg.Database), opts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	return nil
+}
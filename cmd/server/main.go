@@ -0,0 +1,120 @@
+// Command server runs the Car Zone API as a standalone HTTP server. On Vercel the API is served
+// by the function in api/vercel.go instead.
+//
+// On SIGINT or SIGTERM the server stops accepting connections and waits up to
+// SERVER_SHUTDOWN_TIMEOUT for in-flight requests before it exits. The settings come from flags,
+// the environment and the config file, see config.Load; "server -h" lists the flags.
+//
+// With METRICS_ADDR set the Prometheus metrics are served at /metrics on that address too, an
+// admin port to keep private.
+package main
+
+import (
+	"be-car-zone/app"
+	"be-car-zone/app/config"
+	"be-car-zone/app/pkg/logging"
+	"be-car-zone/app/pkg/metrics"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Printf("usage: server [flags]\n\nflags:\n%s", config.Usage())
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v\n\nflags:\n%s", err, config.Usage())
+	}
+
+	// Everything logs through the structured logger, the log package included
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	if cfg.Environment != "development" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	appMetrics := metrics.New()
+	engine, cleanup, err := app.New(app.Config{Settings: cfg, Logger: logger, Metrics: appMetrics})
+	if err != nil {
+		logger.Error("Cannot start the application", "error", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           engine,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server running", "addr", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// The admin address is private, /metrics needs no token there
+	var adminServer *http.Server
+	adminErr := make(chan error, 1)
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", appMetrics.Handler())
+		adminServer = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux, ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout}
+		go func() {
+			logger.Info("Metrics server running", "addr", adminServer.Addr)
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				adminErr <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		logger.Error("Error starting server", "error", err)
+		cleanup()
+		os.Exit(1)
+	case err := <-adminErr:
+		logger.Error("Error starting metrics server", "error", err)
+		cleanup()
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away
+	stop()
+
+	logger.Info("Shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Shutdown", "error", err)
+	}
+	if adminServer != nil {
+		adminServer.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Server", "error", err)
+	}
+
+	logger.Info("Server stopped")
+}
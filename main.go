@@ -1,22 +0,0 @@
-package main
-
-import (
-	"be-car-zone/api"
-	"net/http"
-)
-
-// func main() {
-// 	// Menentukan port untuk server
-// 	port := utils.Getenv("HOST", "localhost:8080")
-
-// 	// Menjalankan server HTTP
-// 	log.Printf("Server running on %s", port)
-// 	err := api.App.Run(port)
-// 	if err != nil {
-// 		log.Fatal("Error starting server: ", err)
-// 	}
-// }
-
-func main() {
-	http.HandleFunc("/", api.Handler)
-}